@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khicago/irr"
+)
+
+// DefaultWorkHours 是默认的工作时间, 周一到周五的 9:00 到 18:00
+const DefaultWorkHours = "9-18"
+
+// activityHeatmap 是按星期和小时统计的提交数, 第一维从周一 (0) 到周日 (6), 第二维是 0-23 点
+type activityHeatmap [7][24]int
+
+// activitySummary 统计工作时间外和周末的提交; 两者不重叠, 周末的提交只计入 Weekend
+type activitySummary struct {
+	// TimeZone 是统计使用的时区, author 表示每个提交作者自己的时区
+	TimeZone        string  `json:"time_zone"`
+	WorkHours       string  `json:"work_hours"`
+	AfterHours      int     `json:"after_hours_commits"`
+	AfterHoursShare float64 `json:"after_hours_share"`
+	Weekend         int     `json:"weekend_commits"`
+	WeekendShare    float64 `json:"weekend_share"`
+}
+
+// activityClock 决定提交时间按哪个时区和工作时间解读
+type activityClock struct {
+	// loc 为 nil 时使用作者提交时记录的时区
+	loc        *time.Location
+	start, end int
+}
+
+// newActivityClock 解析 --tz 和 --work-hours; tz 为空时使用作者时区, Local 表示本机时区
+func newActivityClock(tz, workHours string) (activityClock, error) {
+	clock := activityClock{}
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return activityClock{}, irr.Wrap(err, "unknown time zone %q", tz)
+		}
+		clock.loc = loc
+	}
+	if workHours == "" {
+		workHours = DefaultWorkHours
+	}
+	from, to, ok := strings.Cut(workHours, "-")
+	start, errStart := strconv.Atoi(strings.TrimSpace(from))
+	end, errEnd := strconv.Atoi(strings.TrimSpace(to))
+	if !ok || errStart != nil || errEnd != nil || start < 0 || end > 24 || start >= end {
+		return activityClock{}, irr.Error("invalid work hours %q, want START-END such as 9-18", workHours)
+	}
+	clock.start, clock.end = start, end
+	return clock, nil
+}
+
+// zone 返回报告中显示的时区名
+func (c activityClock) zone() string {
+	if c.loc == nil {
+		return "author"
+	}
+	return c.loc.String()
+}
+
+func (c activityClock) workHours() string {
+	return fmt.Sprintf("%02d:00-%02d:00", c.start, c.end)
+}
+
+func (c activityClock) local(t time.Time) time.Time {
+	if c.loc == nil {
+		return t
+	}
+	return t.In(c.loc)
+}
+
+// weekend 判断 t 是否在周六或周日
+func (c activityClock) weekend(t time.Time) bool {
+	day := c.local(t).Weekday()
+	return day == time.Saturday || day == time.Sunday
+}
+
+// afterHours 判断 t 是否在工作日的工作时间之外
+func (c activityClock) afterHours(t time.Time) bool {
+	if c.weekend(t) {
+		return false
+	}
+	hour := c.local(t).Hour()
+	return hour < c.start || hour >= c.end
+}
+
+// getActivityHeatmap 按作者时间统计每个星期几和小时的提交数
+func getActivityHeatmap(commits []commitRecord, clock activityClock) activityHeatmap {
+	var heatmap activityHeatmap
+	for _, commit := range commits {
+		t := clock.local(commit.AuthorDate)
+		weekday := (int(t.Weekday()) + 6) % 7
+		heatmap[weekday][t.Hour()]++
+	}
+	return heatmap
+}
+
+// getActivitySummary 统计工作时间外和周末提交的数量及占比
+func getActivitySummary(commits []commitRecord, clock activityClock) activitySummary {
+	summary := activitySummary{TimeZone: clock.zone(), WorkHours: clock.workHours()}
+	for _, commit := range commits {
+		if clock.weekend(commit.AuthorDate) {
+			summary.Weekend++
+		} else if clock.afterHours(commit.AuthorDate) {
+			summary.AfterHours++
+		}
+	}
+	summary.AfterHoursShare = share(summary.AfterHours, len(commits))
+	summary.WeekendShare = share(summary.Weekend, len(commits))
+	return summary
+}
+
+// share 返回 n / total, 保留三位小数
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*1000) / 1000
+}
+
+var heatmapWeekdays = [7]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+var heatmapShades = []string{"░░", "▒▒", "▓▓", "██"}
+
+// writeHeatmapText 用字符画出 7×24 热力图, 每小时两列, 没有提交的格子是 ·
+func writeHeatmapText(sb *strings.Builder, heatmap activityHeatmap) {
+	maxValue := 0
+	for _, hours := range heatmap {
+		for _, n := range hours {
+			maxValue = max(maxValue, n)
+		}
+	}
+	header := "    "
+	for hour := 0; hour < 24; hour += 3 {
+		header += fmt.Sprintf("%02d    ", hour)
+	}
+	sb.WriteString(strings.TrimRight(header, " ") + "\n")
+	for day, hours := range heatmap {
+		row := heatmapWeekdays[day] + " "
+		for _, n := range hours {
+			if n == 0 {
+				row += "· "
+				continue
+			}
+			row += heatmapShades[(n*len(heatmapShades)-1)/maxValue]
+		}
+		sb.WriteString(strings.TrimRight(row, " ") + "\n")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivityHeatmapUsesAuthorLocalTime(t *testing.T) {
+	commits, err := loadCommits(newFixtureRepo(t, 3))
+	if err != nil {
+		t.Fatalf("loadCommits() error = %v", err)
+	}
+	clock, err := newActivityClock("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	heatmap := getActivityHeatmap(commits, clock)
+	// 2026-01-01 是周四, 提交在 UTC 9、10、11 点
+	for hour := 9; hour <= 11; hour++ {
+		if heatmap[3][hour] != 1 {
+			t.Errorf("heatmap[Thu][%d] = %d, want 1", hour, heatmap[3][hour])
+		}
+	}
+
+	shanghai := time.FixedZone("+0800", 8*3600)
+	late := []commitRecord{{AuthorDate: time.Date(2026, 1, 4, 23, 30, 0, 0, shanghai)}}
+	if heatmap = getActivityHeatmap(late, clock); heatmap[6][23] != 1 {
+		t.Errorf("heatmap = %v, want Sunday 23:00 in the author's zone", heatmap)
+	}
+
+	utc, err := newActivityClock("UTC", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heatmap = getActivityHeatmap(late, utc); heatmap[6][15] != 1 {
+		t.Errorf("heatmap = %v, want Sunday 15:00 in UTC", heatmap)
+	}
+}
+
+func TestActivitySummaryCountsAfterHoursAndWeekends(t *testing.T) {
+	berlin := time.FixedZone("+0100", 3600)
+	at := func(day, hour int) commitRecord {
+		return commitRecord{AuthorDate: time.Date(2026, 3, day, hour, 0, 0, 0, berlin)}
+	}
+	// 2026-03-02 是周一, 03-07 是周六
+	commits := []commitRecord{at(2, 8), at(2, 9), at(2, 17), at(2, 18), at(3, 23), at(7, 12), at(8, 2), at(4, 10)}
+
+	clock, err := newActivityClock("", "9-18")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := getActivitySummary(commits, clock)
+	want := activitySummary{TimeZone: "author", WorkHours: "09:00-18:00", AfterHours: 3, AfterHoursShare: 0.375, Weekend: 2, WeekendShare: 0.25}
+	if got != want {
+		t.Errorf("getActivitySummary() = %+v, want %+v", got, want)
+	}
+
+	// 在 UTC 中, 周一 08:00 (+01:00) 是 07:00, 周一 18:00 是 17:00, 周二 23:00 是 22:00
+	utc, err := newActivityClock("UTC", "8-17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got = getActivitySummary(commits, utc); got.AfterHours != 3 || got.Weekend != 2 || got.TimeZone != "UTC" {
+		t.Errorf("getActivitySummary(UTC, 8-17) = %+v, want 3 after-hours and 2 weekend commits", got)
+	}
+
+	if got = getActivitySummary(nil, clock); got.AfterHoursShare != 0 || got.WeekendShare != 0 {
+		t.Errorf("getActivitySummary(nil) = %+v, want zero shares", got)
+	}
+}
+
+func TestNewActivityClockRejectsBadInput(t *testing.T) {
+	for _, tc := range []struct{ tz, hours string }{{"Mars/Base", ""}, {"", "18-9"}, {"", "9"}, {"", "9-25"}, {"", "a-b"}} {
+		if _, err := newActivityClock(tc.tz, tc.hours); err == nil {
+			t.Errorf("newActivityClock(%q, %q) error = nil, want error", tc.tz, tc.hours)
+		}
+	}
+}
+
+func TestWriteHeatmapText(t *testing.T) {
+	var heatmap activityHeatmap
+	heatmap[0][9] = 4
+	heatmap[6][23] = 1
+
+	var sb strings.Builder
+	writeHeatmapText(&sb, heatmap)
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	if len(lines) != 8 || !strings.HasPrefix(lines[0], "    00    03") {
+		t.Fatalf("heatmap text = %q, want a header and 7 rows", sb.String())
+	}
+	if want := "Mon " + strings.Repeat("· ", 9) + "██"; !strings.HasPrefix(lines[1], want) {
+		t.Errorf("Monday row = %q, want the 09:00 cell at full shade", lines[1])
+	}
+	if !strings.HasSuffix(lines[7], "░░") {
+		t.Errorf("Sunday row = %q, want the 23:00 cell at the lightest shade", lines[7])
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bagaking/botheater/utils"
+	"github.com/khicago/irr"
+)
+
+// auditConfig 是配置文件中 audit 部分
+type auditConfig struct {
+	// Enabled 开启审计日志
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Path 默认为 $XDG_STATE_HOME/commitron/audit.jsonl
+	Path string `yaml:"path,omitempty"`
+	// IncludeContent 同时记录提示词、问题和模型返回的内容
+	IncludeContent bool `yaml:"include_content,omitempty"`
+}
+
+// auditRecord 是审计日志中的一行, 对应一次模型调用; Outcome 为 ok、error, 或预算拦下、请求没有发送时的 blocked
+type auditRecord struct {
+	Time         time.Time        `json:"time"`
+	Repo         string           `json:"repo,omitempty"`
+	Head         string           `json:"head,omitempty"`
+	Provider     string           `json:"provider"`
+	ProviderType string           `json:"provider_type"`
+	Endpoint     string           `json:"endpoint,omitempty"`
+	Model        string           `json:"model,omitempty"`
+	DiffSHA256   string           `json:"diff_sha256"`
+	Files        []string         `json:"files"`
+	Compaction   *questionReport  `json:"compaction,omitempty"`
+	Redactions   []redactionCount `json:"redactions"`
+	Bytes        int              `json:"bytes"`
+	Tokens       int              `json:"tokens"`
+	DurationMS   int64            `json:"duration_ms"`
+	Outcome      string           `json:"outcome"`
+	Error        string           `json:"error,omitempty"`
+	Content      *auditedContent  `json:"content,omitempty"`
+}
+
+// auditedContent 仅在 include_content 开启时记录
+type auditedContent struct {
+	Prompt   string `json:"prompt"`
+	Question string `json:"question"`
+	Response string `json:"response,omitempty"`
+}
+
+// auditLog 以追加方式写入 JSONL 审计日志
+type auditLog struct {
+	path           string
+	includeContent bool
+	now            func() time.Time
+
+	repoOnce   sync.Once
+	repo, head string
+}
+
+// newAuditLog 依据配置创建审计日志, 未开启时返回 nil
+func newAuditLog(conf auditConfig) (*auditLog, error) {
+	if !conf.Enabled && conf.Path == "" {
+		return nil, nil
+	}
+	path := conf.Path
+	if path == "" {
+		dir, err := stateDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "audit.jsonl")
+	}
+	return &auditLog{path: path, includeContent: conf.IncludeContent, now: time.Now}, nil
+}
+
+// stateDir 返回 $XDG_STATE_HOME/commitron, 未设置时使用 ~/.local/state/commitron
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "commitron"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", irr.Wrap(err, "failed to locate state directory")
+	}
+	return filepath.Join(home, ".local", "state", "commitron"), nil
+}
+
+// wrap 为 ask 的每次调用 (包括重试) 追加一条审计记录
+//
+// 审计日志无法写入时不发起请求
+func (a *auditLog) wrap(ask askQuestionFunc, p resolvedProvider, diff string, req modelRequest) askQuestionFunc {
+	return func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		a.repoOnce.Do(func() {
+			a.repo, _ = executeGitCommand("rev-parse", "--show-toplevel")
+			a.head, _ = executeGitCommand("rev-parse", "HEAD")
+			a.repo, a.head = strings.TrimSpace(a.repo), strings.TrimSpace(a.head)
+		})
+
+		record := auditRecord{
+			Time:         a.now().UTC(),
+			Repo:         a.repo,
+			Head:         a.head,
+			Provider:     p.displayName(),
+			ProviderType: p.Type,
+			Endpoint:     maskEndpoint(endpoint),
+			Model:        p.Model,
+			DiffSHA256:   sha256Hex(diff),
+			Files:        sentFiles(diff, req.Compaction),
+			Bytes:        len(prompt) + len(question),
+			Tokens:       utils.CountTokens(prompt) + utils.CountTokens(question),
+		}
+		if record.Redactions = req.Redactions; record.Redactions == nil {
+			record.Redactions = []redactionCount{}
+		}
+		if len(req.Compaction.Summarized)+len(req.Compaction.Truncated)+len(req.Compaction.Dropped) > 0 {
+			record.Compaction = &req.Compaction
+		}
+
+		// 先打开日志文件, 无法记录时不发起请求
+		f, err := a.open()
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		start := a.now()
+		answer, err := ask(ctx, endpoint, prompt, question)
+		record.DurationMS = a.now().Sub(start).Milliseconds()
+		var blocked *budgetExceededError
+		switch {
+		case err == nil:
+			record.Outcome = "ok"
+		case errors.As(err, &blocked):
+			// 被预算拦下的请求没有发送, 不算后端失败
+			record.Outcome, record.Error = "blocked", err.Error()
+		default:
+			record.Outcome, record.Error = "error", err.Error()
+		}
+		if a.includeContent {
+			record.Content = &auditedContent{Prompt: prompt, Question: question, Response: answer}
+		}
+
+		if writeErr := writeAuditRecord(f, record); writeErr != nil {
+			return "", writeErr
+		}
+		return answer, err
+	}
+}
+
+func (a *auditLog) open() (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o700); err != nil {
+		return nil, irr.Wrap(err, "failed to create audit log directory")
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, irr.Wrap(err, "failed to open audit log")
+	}
+	return f, nil
+}
+
+func writeAuditRecord(f *os.File, record auditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return irr.Wrap(err, "failed to encode audit record")
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return irr.Wrap(err, "failed to write audit log")
+	}
+	return nil
+}
+
+// sentFiles 返回实际发送给模型的文件, 截断后被丢弃的文件不计入
+func sentFiles(diff string, report questionReport) []string {
+	dropped := make(map[string]bool, len(report.Dropped))
+	for _, p := range report.Dropped {
+		dropped[p] = true
+	}
+	files := make([]string, 0)
+	for _, f := range parseDiff(diff) {
+		if !dropped[f.Path()] {
+			files = append(files, f.Path())
+		}
+	}
+	return files
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
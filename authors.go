@@ -0,0 +1,127 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/khicago/irr"
+)
+
+// identityRule 匹配一个身份: 精确的名字、精确的邮箱或正则
+type identityRule struct {
+	name  string
+	email string
+	re    *regexp.Regexp
+}
+
+// authorMatcher 判断提交是否属于某个人, 作者和 Co-authored-by 都计入
+type authorMatcher struct {
+	rules []identityRule
+}
+
+// newAuthorMatcher 解析 --committer 的取值
+//
+// 取值是 identities 中的键时展开为其下的全部身份; 其余取值中, /.../ 是匹配 "Name <email>" 的正则,
+// 含 @ 的是邮箱, 否则是名字; 名字和邮箱都按大小写不敏感的方式精确匹配
+func newAuthorMatcher(specs []string, identities map[string][]string) (authorMatcher, error) {
+	var expanded []string
+	for _, spec := range specs {
+		if aliases, ok := identities[spec]; ok {
+			expanded = append(expanded, aliases...)
+			continue
+		}
+		expanded = append(expanded, spec)
+	}
+
+	var m authorMatcher
+	for _, spec := range expanded {
+		spec = strings.TrimSpace(spec)
+		switch {
+		case spec == "":
+			continue
+		case len(spec) > 2 && strings.HasPrefix(spec, "/") && strings.HasSuffix(spec, "/"):
+			re, err := regexp.Compile(spec[1 : len(spec)-1])
+			if err != nil {
+				return authorMatcher{}, irr.Wrap(err, "invalid committer pattern %s", spec)
+			}
+			m.rules = append(m.rules, identityRule{re: re})
+		case strings.Contains(spec, "@"):
+			m.rules = append(m.rules, identityRule{email: strings.Trim(spec, "<>")})
+		default:
+			m.rules = append(m.rules, identityRule{name: spec})
+		}
+	}
+	if len(m.rules) == 0 {
+		return authorMatcher{}, irr.Error("Please provide the committer using the --committer flag")
+	}
+	return m, nil
+}
+
+// matches 判断单个身份是否匹配
+func (m authorMatcher) matches(name, email string) bool {
+	for _, r := range m.rules {
+		switch {
+		case r.re != nil:
+			if r.re.MatchString(formatContact(name, email)) {
+				return true
+			}
+		case r.email != "":
+			if strings.EqualFold(r.email, email) {
+				return true
+			}
+		case strings.EqualFold(r.name, name):
+			return true
+		}
+	}
+	return false
+}
+
+// matchCommit 判断提交的作者或任一共同作者是否匹配
+func (m authorMatcher) matchCommit(c commitRecord) bool {
+	if m.matches(c.AuthorName, c.AuthorEmail) {
+		return true
+	}
+	for _, co := range c.CoAuthors {
+		if m.matches(parseContact(co)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseContact 解析 "Name <email>", 没有尖括号时整体视为名字
+func parseContact(contact string) (name, email string) {
+	contact = strings.TrimSpace(contact)
+	open, end := strings.LastIndex(contact, "<"), strings.LastIndex(contact, ">")
+	if open < 0 || end < open {
+		return contact, ""
+	}
+	return strings.TrimSpace(contact[:open]), strings.TrimSpace(contact[open+1 : end])
+}
+
+func formatContact(name, email string) string {
+	return name + " <" + email + ">"
+}
+
+// mailmapContacts 通过 git check-mailmap 将 "Name <email>" 映射为 .mailmap 中的规范身份
+//
+// 查询失败时 (例如 git 版本过旧) 保留原值
+func mailmapContacts(dir string, contacts []string) map[string]string {
+	mapped := make(map[string]string, len(contacts))
+	const batch = 256
+	for start := 0; start < len(contacts); start += batch {
+		chunk := contacts[start:min(start+batch, len(contacts))]
+		out, err := executeGitCommandIn(dir, append([]string{"check-mailmap"}, chunk...)...)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+		if len(lines) != len(chunk) {
+			continue
+		}
+		for i, contact := range chunk {
+			mapped[contact] = lines[i]
+		}
+	}
+	return mapped
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthorMatcherMatchesExactly(t *testing.T) {
+	m, err := newAuthorMatcher([]string{"ann", "/<bot-.*@ci\\.example\\.com>$/", "me"}, map[string][]string{
+		"me": {"Jo Smith", "jo@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("newAuthorMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		name, email string
+		want        bool
+	}{
+		{"Ann", "ann@example.com", true},
+		{"Joanne", "joanne@example.com", false},
+		{"bot", "bot-42@ci.example.com", true},
+		{"bot", "bot-42@ci.example.com.evil", false},
+		{"Jo Smith", "other@example.com", true},
+		{"Joe", "JO@example.com", true},
+		{"Jo", "jo@example.org", false},
+	}
+	for _, tt := range tests {
+		if got := m.matches(tt.name, tt.email); got != tt.want {
+			t.Errorf("matches(%q, %q) = %v, want %v", tt.name, tt.email, got, tt.want)
+		}
+	}
+
+	if _, err = newAuthorMatcher([]string{"/(/"}, nil); err == nil {
+		t.Error("newAuthorMatcher(invalid regex) error = nil, want error")
+	}
+	if _, err = newAuthorMatcher([]string{" "}, nil); err == nil {
+		t.Error("newAuthorMatcher(blank) error = nil, want error")
+	}
+}
+
+func TestParseContact(t *testing.T) {
+	name, email := parseContact(" Bob B <bob@example.com> ")
+	if name != "Bob B" || email != "bob@example.com" {
+		t.Fatalf("parseContact() = %q, %q, want Bob B and bob@example.com", name, email)
+	}
+	if name, email = parseContact("just a name"); name != "just a name" || email != "" {
+		t.Fatalf("parseContact(no email) = %q, %q, want name only", name, email)
+	}
+}
+
+func TestGetUserCommitsRespectsMailmapAndCoAuthors(t *testing.T) {
+	dir := t.TempDir()
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	as := func(name, email string) []string {
+		return []string{"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email, "GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email}
+	}
+
+	git(nil, "init", "-q", "-b", "main")
+	mailmap := "Ann Lee <ann@example.com>\nAnn Lee <ann@example.com> <ann.lee@old.example.com>\nAnn Lee <ann@example.com> Bob Pair <bob@example.com>\n"
+	if err := os.WriteFile(filepath.Join(dir, ".mailmap"), []byte(mailmap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(as("Ann", "ann@example.com"), "add", ".mailmap")
+	git(as("Ann", "ann@example.com"), "commit", "-q", "-m", "add mailmap")
+	git(as("A. Lee", "ann.lee@old.example.com"), "commit", "-q", "--allow-empty", "-m", "old spelling")
+	git(as("Joanne", "joanne@example.com"), "commit", "-q", "--allow-empty", "-m", "not ann")
+	git(as("Carol", "carol@example.com"), "commit", "-q", "--allow-empty", "-m", "paired", "-m", "Co-authored-by: Bob Pair <bob@example.com>")
+
+	commits, err := getUserCommits(dir, insightOptions{Committers: []string{"Ann Lee"}})
+	if err != nil {
+		t.Fatalf("getUserCommits() error = %v", err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	want := []string{"paired", "old spelling", "add mailmap"}
+	if len(subjects) != len(want) {
+		t.Fatalf("getUserCommits() subjects = %v, want %v", subjects, want)
+	}
+	for i := range want {
+		if subjects[i] != want[i] {
+			t.Fatalf("getUserCommits() subjects = %v, want %v", subjects, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khicago/irr"
+)
+
+const (
+	defaultCacheTTL        = 7 * 24 * time.Hour
+	defaultCacheMaxEntries = 256
+)
+
+// cacheConfig 是配置文件中 cache 部分
+type cacheConfig struct {
+	// Dir 默认为 $XDG_CACHE_HOME/commitron
+	Dir string `yaml:"dir,omitempty"`
+	// TTL 是缓存条目的有效期, 默认 7 天
+	TTL time.Duration `yaml:"ttl,omitempty"`
+	// MaxEntries 是保留的最大条目数, 超出时淘汰最旧的条目
+	MaxEntries int `yaml:"max_entries,omitempty"`
+}
+
+// responseCache 按 diff 与提示词缓存模型返回的提交信息, 每个条目是目录下的一个 JSON 文件
+type responseCache struct {
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Provider  string    `json:"provider"`
+	Message   string    `json:"message"`
+}
+
+// newResponseCache 依据配置创建缓存, 未配置的字段使用默认值
+func newResponseCache(conf cacheConfig) (*responseCache, error) {
+	dir := conf.Dir
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, irr.Wrap(err, "failed to locate cache directory")
+		}
+		dir = filepath.Join(base, "commitron")
+	}
+
+	c := &responseCache{dir: dir, ttl: conf.TTL, maxEntries: conf.MaxEntries, now: time.Now}
+	if c.ttl <= 0 {
+		c.ttl = defaultCacheTTL
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultCacheMaxEntries
+	}
+	return c, nil
+}
+
+// cacheKey 由规范化后的 diff、提示词和后端标识计算缓存键
+func cacheKey(diff, prompt string, p providerConfig) string {
+	h := sha256.New()
+	for _, part := range []string{normalizeDiff(diff), prompt, p.Type, p.Endpoint, p.Model} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeDiff 去掉不影响语义的差异: 换行风格、行尾空白和 index 行
+func normalizeDiff(diff string) string {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	normalized := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "index ") {
+			continue
+		}
+		normalized = append(normalized, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimRight(strings.Join(normalized, "\n"), "\n")
+}
+
+func (c *responseCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get 返回未过期的缓存条目, 过期条目会被删除
+func (c *responseCache) get(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil || c.now().Sub(entry.CreatedAt) > c.ttl {
+		_ = os.Remove(c.path(key))
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// put 写入缓存条目, 并在超出容量时淘汰最旧的条目
+func (c *responseCache) put(key, provider, message string) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return irr.Wrap(err, "failed to create cache directory")
+	}
+	data, err := json.Marshal(cacheEntry{CreatedAt: c.now(), Provider: provider, Message: message})
+	if err != nil {
+		return irr.Wrap(err, "failed to encode cache entry")
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return irr.Wrap(err, "failed to write cache entry")
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return irr.Wrap(err, "failed to write cache entry")
+	}
+	return c.prune()
+}
+
+// prune 按修改时间淘汰超出 maxEntries 的条目
+func (c *responseCache) prune() error {
+	entries, err := c.entries()
+	if err != nil || len(entries) <= c.maxEntries {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, entry := range entries[:len(entries)-c.maxEntries] {
+		if err = os.Remove(filepath.Join(c.dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return irr.Wrap(err, "failed to evict cache entry")
+		}
+	}
+	return nil
+}
+
+func (c *responseCache) entries() ([]os.FileInfo, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, irr.Wrap(err, "failed to read cache directory")
+	}
+
+	var infos []os.FileInfo
+	for _, entry := range dirEntries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// clear 删除全部缓存条目, 返回删除的数量
+func (c *responseCache) clear() (int, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if err = os.Remove(filepath.Join(c.dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, irr.Wrap(err, "failed to remove cache entry")
+		}
+	}
+	return len(entries), nil
+}
+
+// clearCache 清空 configPath 中 cache.dir 指定的本地响应缓存, configPath 为空时使用默认配置
+func clearCache(configPath string) error {
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	c, err := newResponseCache(conf.Cache)
+	if err != nil {
+		return err
+	}
+	n, err := c.clear()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d cached message(s) from %s\n", n, c.dir)
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, conf cacheConfig) *responseCache {
+	t.Helper()
+
+	if conf.Dir == "" {
+		conf.Dir = t.TempDir()
+	}
+	c, err := newResponseCache(conf)
+	if err != nil {
+		t.Fatalf("newResponseCache() error = %v", err)
+	}
+	return c
+}
+
+func TestResponseCacheExpiresEntries(t *testing.T) {
+	c := newTestCache(t, cacheConfig{TTL: time.Hour})
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	if err := c.put("key", "doubao", "fix: cached"); err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+	entry, ok := c.get("key")
+	if !ok || entry.Message != "fix: cached" || entry.Provider != "doubao" {
+		t.Fatalf("get() = %+v, %v, want cached entry", entry, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok = c.get("key"); ok {
+		t.Fatal("get() after TTL ok = true, want expired")
+	}
+	if _, err := os.Stat(c.path("key")); !os.IsNotExist(err) {
+		t.Fatalf("expired entry still on disk, stat error = %v", err)
+	}
+}
+
+func TestResponseCacheEvictsOldestEntries(t *testing.T) {
+	c := newTestCache(t, cacheConfig{MaxEntries: 2})
+	base := time.Now()
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := c.put(key, "doubao", key); err != nil {
+			t.Fatalf("put(%s) error = %v", key, err)
+		}
+		// 显式设置修改时间, 避免文件系统时间精度影响淘汰顺序
+		stamp := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(c.path(key), stamp, stamp); err != nil {
+			t.Fatalf("Chtimes() error = %v", err)
+		}
+	}
+	if err := c.prune(); err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+
+	if _, ok := c.get("key0"); ok {
+		t.Error("oldest entry key0 survived eviction")
+	}
+	for _, key := range []string{"key1", "key2"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("entry %s evicted, want kept", key)
+		}
+	}
+
+	n, err := c.clear()
+	if err != nil || n != 2 {
+		t.Fatalf("clear() = %d, %v, want 2 removed", n, err)
+	}
+}
+
+func TestCacheKeyNormalizesDiff(t *testing.T) {
+	p := providerConfig{Type: ProviderTypeDoubao, Endpoint: "test-endpoint"}
+	a := cacheKey("diff --git a/x b/x\nindex 111..222 100644\n+x  \n", "prompt", p)
+	b := cacheKey("diff --git a/x b/x\r\nindex 333..444 100644\r\n+x\r\n\n", "prompt", p)
+	if a != b {
+		t.Fatal("cacheKey() differs for diffs that only differ in whitespace and index lines")
+	}
+	if a == cacheKey("diff --git a/x b/x\n+y\n", "prompt", p) {
+		t.Fatal("cacheKey() equal for different diffs")
+	}
+	if a == cacheKey("diff --git a/x b/x\n+x\n", "other prompt", p) {
+		t.Fatal("cacheKey() equal for different prompts")
+	}
+	if a == cacheKey("diff --git a/x b/x\n+x\n", "prompt", providerConfig{Type: ProviderTypeOllama, Model: "m"}) {
+		t.Fatal("cacheKey() equal for different providers")
+	}
+}
+
+func TestAskProvidersServesRepeatedDiffFromCache(t *testing.T) {
+	calls := 0
+	doubaoAsk := func(context.Context, string, string, string) (string, error) {
+		calls++
+		return "fix(main): bump x", nil
+	}
+	opts := commentOptions{
+		Diff:      providerTestDiff,
+		AccessKey: "test-ak",
+		SecretKey: "test-sk",
+		Endpoint:  "test-endpoint",
+		Cache:     newTestCache(t, cacheConfig{}),
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := askProviders(context.Background(), opts, doubaoAsk, modelRequest{Prompt: "prompt", Question: "question"})
+		if err != nil {
+			t.Fatalf("askProviders() run %d error = %v", i, err)
+		}
+		if got != "fix(main): bump x" {
+			t.Fatalf("askProviders() run %d = %q, want cached answer", i, got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("model called %d times, want 1 with cache", calls)
+	}
+
+	opts.Cache = nil
+	if _, err := askProviders(context.Background(), opts, doubaoAsk, modelRequest{Prompt: "prompt", Question: "question"}); err != nil {
+		t.Fatalf("askProviders() without cache error = %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("model called %d times, want call when cache disabled", calls)
+	}
+}
+
+func TestClearCacheUsesConfiguredDir(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("cache:\n  dir: "+dir+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newResponseCache(cacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("newResponseCache() error = %v", err)
+	}
+	if err = c.put("key", "doubao", "feat: cached"); err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+
+	if err = clearCache(configPath); err != nil {
+		t.Fatalf("clearCache() error = %v", err)
+	}
+	if _, ok := c.get("key"); ok {
+		t.Error("entry in the configured cache.dir survived clearCache")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"path"
+	"strings"
+
+	"github.com/khicago/irr"
+)
+
+// codeownersLocations 是 GitHub 查找 CODEOWNERS 的位置, 按优先级排列
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeownersRule 是 CODEOWNERS 中的一行
+type codeownersRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// parseCodeowners 解析 CODEOWNERS, 忽略空行和注释
+func parseCodeowners(content string) []codeownersRule {
+	var rules []codeownersRule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, codeownersRule{Pattern: fields[0], Owners: append([]string{}, fields[1:]...)})
+	}
+	return rules
+}
+
+// loadCodeowners 读取 rev 中的 CODEOWNERS: path 非空时读取该路径 (相对仓库根目录), 否则依次查找默认位置
+//
+// 返回实际读取的位置; 没有找到默认位置时位置为空, 不视为错误
+func loadCodeowners(dir, rev, path string) ([]codeownersRule, string, error) {
+	if path != "" {
+		path = strings.TrimPrefix(path, "./")
+		content, err := executeGitCommandIn(dir, "show", rev+":"+path)
+		if err != nil {
+			return nil, "", irr.Wrap(err, "failed to read CODEOWNERS file %s at %s", path, rev)
+		}
+		return parseCodeowners(content), path, nil
+	}
+	for _, location := range codeownersLocations {
+		content, err := executeGitCommandIn(dir, "show", rev+":"+location)
+		if err == nil {
+			return parseCodeowners(content), location, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// matchesDir 判断规则是否覆盖目录 dir 中的文件; dir 为 root 时只有 * 这样的全局规则匹配
+//
+// 只处理目录级别的写法: *, /a/b/, a/b/**, docs/ 以及含 * 的目录名, 文件级的规则 (如 *.go) 不会匹配目录
+func (r codeownersRule) matchesDir(dir string) bool {
+	pattern := r.Pattern
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.Trim(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	for _, suffix := range []string{"/**", "/*"} {
+		pattern = strings.TrimSuffix(pattern, suffix)
+	}
+	if pattern == "" || pattern == "*" || pattern == "**" {
+		return true
+	}
+	if dir == "root" {
+		return false
+	}
+
+	segments := strings.Split(dir, "/")
+	for i := range segments {
+		candidate := segments[i]
+		if anchored {
+			candidate = strings.Join(segments[:i+1], "/")
+		}
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// codeownersFor 返回最后一条匹配 dir 的规则中的负责人, 与 GitHub 的规则一致
+func codeownersFor(rules []codeownersRule, dir string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].matchesDir(dir) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// codeownerMatcher 将 CODEOWNERS 中的负责人对应到提交作者: 邮箱直接匹配, @用户名通过配置中 identities 的同名键匹配
+//
+// 无法对应的负责人 (如 @org/team 或没有配置的用户名) 返回 false
+func codeownerMatcher(owner string, identities map[string][]string) (authorMatcher, bool) {
+	if strings.HasPrefix(owner, "@") {
+		handle := strings.TrimPrefix(owner, "@")
+		if _, ok := identities[handle]; !ok {
+			return authorMatcher{}, false
+		}
+		m, err := newAuthorMatcher([]string{handle}, identities)
+		return m, err == nil
+	}
+	if strings.Contains(owner, "@") {
+		m, err := newAuthorMatcher([]string{owner}, nil)
+		return m, err == nil
+	}
+	return authorMatcher{}, false
+}
+
+// codeownersPattern 返回目录在 CODEOWNERS 中的写法, root 使用 *
+func codeownersPattern(dir string) string {
+	if dir == "root" {
+		return "*"
+	}
+	return "/" + dir + "/"
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeownersFor(t *testing.T) {
+	rules := parseCodeowners("# owners\n* @lead\n\ndocs/ @writer\n/svc/api/ @api ann@example.com\nsvc/*/internal/** @core\n*.go @gopher\n")
+	for dir, want := range map[string]string{
+		"root":             "@lead",
+		"docs":             "@writer",
+		"svc/docs":         "@writer",
+		"svc/api":          "@api ann@example.com",
+		"svc/api/v2":       "@api ann@example.com",
+		"svc/db/internal":  "@core",
+		"svc/db":           "@lead",
+		"apisvc/api":       "@lead",
+		"svc/api/internal": "@core",
+	} {
+		if got := strings.Join(codeownersFor(rules, dir), " "); got != want {
+			t.Errorf("codeownersFor(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestCodeownerMatcher(t *testing.T) {
+	identities := map[string][]string{"ann": {"ann@example.com", "/^ann\\./"}}
+	m, ok := codeownerMatcher("@ann", identities)
+	if !ok || !m.matches("Ann", "ann@example.com") || m.matches("Bob", "bob@example.com") {
+		t.Errorf("codeownerMatcher(@ann) = %v, want a matcher for ann's identities", ok)
+	}
+	if m, ok = codeownerMatcher("Bob@Example.com", nil); !ok || !m.matches("Bob", "bob@example.com") {
+		t.Errorf("codeownerMatcher(email) = %v, want a case-insensitive email match", ok)
+	}
+	for _, owner := range []string{"@org/team", "@nobody", "lead"} {
+		if _, ok = codeownerMatcher(owner, identities); ok {
+			t.Errorf("codeownerMatcher(%q) = true, want false", owner)
+		}
+	}
+	if codeownersPattern("root") != "*" || codeownersPattern("svc/api") != "/svc/api/" {
+		t.Error("codeownersPattern() want * for root and /dir/ otherwise")
+	}
+}
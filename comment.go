@@ -19,43 +19,106 @@ import (
 
 type askQuestionFunc func(context.Context, string, string, string) (string, error)
 
+// commentOptions 汇总 comment 子命令的输入
+type commentOptions struct {
+	Diff      string
+	AccessKey string
+	SecretKey string
+	Endpoint  string
+	Prompt    string
+
+	// NoShortcuts 禁用确定性规则, 强制调用模型
+	NoShortcuts bool
+	// Offline 不调用模型, 直接使用本地启发式生成草稿
+	Offline bool
+	// Retry 控制模型调用的超时与重试
+	Retry retryPolicy
+	// ConfigPath 是配置文件路径, 为空时使用默认路径
+	ConfigPath string
+	// Providers 是按顺序尝试的模型后端, 为空时使用 defaultProviders
+	Providers []providerConfig
+	// NoCache 禁用本地响应缓存
+	NoCache bool
+	// Cache 是本地响应缓存, 为 nil 时不读写缓存
+	Cache *responseCache
+	// DryRun 只打印将要发送的内容, 不联系模型
+	DryRun bool
+	// Audit 记录每次模型调用, 为 nil 时不记录
+	Audit *auditLog
+	// Usage 记录每次模型调用的用量并执行月度预算, 为 nil 时不记录
+	Usage *usageLedger
+}
+
 // autoComment generates a commit comment based on the provided diff information.
-func autoComment(ctx context.Context, diff, ak, sk, ep, pp string) error {
-	return autoCommentWithAsk(ctx, diff, ak, sk, ep, pp, SimpleQuestion)
+func autoComment(ctx context.Context, opts commentOptions) error {
+	conf, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+	opts.Providers = conf.Providers
+	if !opts.NoCache {
+		if opts.Cache, err = newResponseCache(conf.Cache); err != nil {
+			return err
+		}
+	}
+	if opts.Audit, err = newAuditLog(conf.Audit); err != nil {
+		return err
+	}
+	if opts.Usage, err = newUsageLedger(conf.Usage); err != nil {
+		return err
+	}
+	return autoCommentWithAsk(ctx, opts, SimpleQuestion)
+}
+
+func autoCommentWithAsk(ctx context.Context, opts commentOptions, ask askQuestionFunc) error {
+	if opts.DryRun {
+		if strings.TrimSpace(opts.Diff) == "" {
+			return irr.Error("Please provide the diff information using the --diff (or -d) flag")
+		}
+		fmt.Print(describeDryRun(opts, typer.Or(opts.Prompt, defaultPrompt), readGitBlob))
+		return nil
+	}
+
+	comment, err := generateComment(ctx, opts, ask)
+	if err != nil {
+		return err
+	}
+
+	// Print the generated comment
+	fmt.Println(comment)
+	return nil
 }
 
-func autoCommentWithAsk(ctx context.Context, diff, ak, sk, ep, pp string, ask askQuestionFunc) error {
+// generateComment 生成提交信息, 简单变更由确定性规则直接给出, 其余交给模型
+//
+// 模型按 fallback 链依次尝试, 默认链在 doubao 不可用 (缺少凭证/端点, 或调用失败) 时退化为本地启发式草稿
+func generateComment(ctx context.Context, opts commentOptions, ask askQuestionFunc) (string, error) {
 	// disable logrus to hide bot debug
 	logrus.SetOutput(io.Discard)
 
+	diff := opts.Diff
 	// Check if the -diff flag is provided
 	if strings.TrimSpace(diff) == "" {
-		return irr.Error("Please provide the diff information using the --diff (or -d) flag")
+		return "", irr.Error("Please provide the diff information using the --diff (or -d) flag")
 	}
 
-	// Use command-line flags for access key and secret key if provided
-	ak = firstNonBlank(ak, coze.EnvKeyVOLCAccessKey.Read())
-	sk = firstNonBlank(sk, coze.EnvKeyVOLCSecretKey.Read())
-	ep = firstNonBlank(ep, coze.EnvKeyDoubaoEndpoint.Read())
-
-	// Check if the access key and secret key are set
-	if ak == "" || sk == "" {
-		return irr.Error("Please provide the access key and secret key using flags or environment variables")
+	if !opts.NoShortcuts {
+		if msg, ok := shortcutMessage(diff); ok {
+			return msg, nil
+		}
 	}
-	if ep == "" {
-		return irr.Error("Please provide the endpoint using flags or environment variables")
+	if opts.Offline {
+		return heuristicMessage(diff, readGitBlob), nil
 	}
-	previousAccessKey := coze.VOLC_ACCESSKEY
-	previousSecretKey := coze.VOLC_SECRETKEY
-	coze.VOLC_ACCESSKEY = ak
-	coze.VOLC_SECRETKEY = sk
-	defer func() {
-		coze.VOLC_ACCESSKEY = previousAccessKey
-		coze.VOLC_SECRETKEY = previousSecretKey
-	}()
 
 	// Set the prompt for the AI model
-	prompt := typer.Or(pp, `# Role:你是一个训练有素的代码分析员, 请根据以下的代码差异信息，生成一个简洁的提交注释
+	req := modelRequest{Prompt: typer.Or(opts.Prompt, defaultPrompt)}
+	req.Question, req.Compaction = compactQuestion(diff, readGitBlob)
+	return askProviders(ctx, opts, ask, req)
+}
+
+// defaultPrompt 是未指定 --prompt 时使用的提示词
+const defaultPrompt = `# Role:你是一个训练有素的代码分析员, 请根据以下的代码差异信息，生成一个简洁的提交注释
 
 # Constrains
 - 语言简洁, 用英文输出
@@ -75,17 +138,7 @@ feat (commitron): Add Git commit-msg hook installation
 - Check for existing commit-msg hook and append commitron hook if necessary
 - Create a new commit-msg hook file with commitron hook if it doesn't exist
 - Allow specifying access key, secret key, and endpoint during hook installation
-`)
-
-	comment, err := ask(ctx, ep, prompt, buildQuestion(diff))
-	if err != nil {
-		return irr.Wrap(err, "failed to generate comment")
-	}
-
-	// Print the generated comment
-	fmt.Println(comment)
-	return nil
-}
+`
 
 func firstNonBlank(values ...string) string {
 	for _, value := range values {
@@ -97,10 +150,35 @@ func firstNonBlank(values ...string) string {
 }
 
 func buildQuestion(diffInfo string) string {
+	return buildQuestionWithBlobs(diffInfo, readGitBlob)
+}
+
+// buildQuestionWithBlobs 构造发送给模型的问题, diff 过大时进行压缩
+//
+// 压缩时超大的 Go 文件会附带由 load 读取新旧 blob 得到的符号变更摘要
+func buildQuestionWithBlobs(diffInfo string, load blobLoader) string {
+	question, _ := compactQuestion(diffInfo, load)
+	return question
+}
+
+// questionReport 记录构造问题时对各文件的压缩情况
+type questionReport struct {
+	// Summarized 是超过 maxFileLength, 只保留元信息和符号摘要的文件
+	Summarized []string `json:"summarized,omitempty"`
+	// Truncated 是最终截断时被截去一部分的文件
+	Truncated []string `json:"truncated,omitempty"`
+	// Dropped 是最终截断时被完全丢弃的文件
+	Dropped []string `json:"dropped,omitempty"`
+}
+
+// compactQuestion 构造发送给模型的问题, 并报告哪些文件被压缩、截断或丢弃
+func compactQuestion(diffInfo string, load blobLoader) (string, questionReport) {
+	var report questionReport
+
 	diffInfo = "DiffInfo 如下:\n" + diffInfo
 	// 计算 diff 信息的总字数
 	if utils.CountTokens(diffInfo) < maxDiffLength {
-		return diffInfo
+		return diffInfo, report
 	}
 
 	// 将 diff 信息按文件切分
@@ -127,9 +205,13 @@ func buildQuestion(diffInfo string) string {
 					filteredLines = append(filteredLines, line)
 				}
 			}
+			if symbols := largeFileSymbols(file, load); symbols != "" {
+				filteredLines = append(filteredLines, "Go 符号变更 (+ 新增, - 删除, ~ 修改):", symbols)
+			}
 			filteredLines = append(filteredLines, fmt.Sprintf("--Large Diff End -- (以上修改内容超过 %d, 已省略)。\n", maxFileLength))
 			filteredFile := strings.Join(filteredLines, "\n")
 			filteredDiff = append(filteredDiff, filteredFile)
+			report.Summarized = appendChunkPath(report.Summarized, file)
 		}
 	}
 
@@ -138,9 +220,40 @@ func buildQuestion(diffInfo string) string {
 
 	// 如果筛选后的 diff 信息仍然超过 32k,则进行截断
 	if utils.CountTokens(diffInfo) > maxDiffLength {
-		return truncateRunes(diffInfo, maxDiffLength)
+		offset := 0
+		for i, chunk := range filteredDiff {
+			if i > 0 {
+				offset += len([]rune("diff --git"))
+			}
+			end := offset + len([]rune(chunk))
+			switch {
+			case offset >= maxDiffLength:
+				report.Dropped = appendChunkPath(report.Dropped, files[i])
+			case end > maxDiffLength:
+				report.Truncated = appendChunkPath(report.Truncated, files[i])
+			}
+			offset = end
+		}
+		return truncateRunes(diffInfo, maxDiffLength), report
+	}
+	return diffInfo, report
+}
+
+// appendChunkPath 将按 "diff --git" 切分出的片段对应的文件路径加入列表, 非文件片段忽略
+func appendChunkPath(paths []string, chunk string) []string {
+	if parsed := parseDiff("diff --git" + chunk); len(parsed) > 0 {
+		return append(paths, parsed[0].Path())
+	}
+	return paths
+}
+
+// largeFileSymbols 为单个超大文件生成 Go 符号变更摘要, 非 Go 文件返回空串
+func largeFileSymbols(file string, load blobLoader) string {
+	parsed := parseDiff("diff --git" + file)
+	if len(parsed) == 0 {
+		return ""
 	}
-	return diffInfo
+	return formatSymbolChanges(goSymbolChanges(parsed[0], load))
 }
 
 func truncateRunes(s string, maxRunes int) string {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -21,13 +22,12 @@ func restoreCozeCredentials(t *testing.T) {
 
 func TestAutoCommentRejectsInvalidInputBeforeModelCall(t *testing.T) {
 	tests := []struct {
-		name     string
-		diff     string
-		ak       string
-		sk       string
-		ep       string
-		wantErr  string
-		clearEnv bool
+		name    string
+		diff    string
+		ak      string
+		sk      string
+		ep      string
+		wantErr string
 	}{
 		{
 			name:    "empty diff",
@@ -44,21 +44,6 @@ func TestAutoCommentRejectsInvalidInputBeforeModelCall(t *testing.T) {
 			ep:      "test-endpoint",
 			wantErr: "Please provide the diff information",
 		},
-		{
-			name:     "missing credentials",
-			diff:     "diff --git a/a.txt b/a.txt\n",
-			ep:       "test-endpoint",
-			wantErr:  "Please provide the access key and secret key",
-			clearEnv: true,
-		},
-		{
-			name:     "missing endpoint",
-			diff:     "diff --git a/a.txt b/a.txt\n",
-			ak:       "test-access-key",
-			sk:       "test-secret-key",
-			wantErr:  "Please provide the endpoint",
-			clearEnv: true,
-		},
 	}
 
 	ask := func(context.Context, string, string, string) (string, error) {
@@ -68,13 +53,7 @@ func TestAutoCommentRejectsInvalidInputBeforeModelCall(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.clearEnv {
-				t.Setenv("VOLC_ACCESSKEY", "")
-				t.Setenv("VOLC_SECRETKEY", "")
-				t.Setenv("DOUBAO_ENDPOINT", "")
-			}
-
-			err := autoCommentWithAsk(context.Background(), tt.diff, tt.ak, tt.sk, tt.ep, "", ask)
+			err := autoCommentWithAsk(context.Background(), commentOptions{Diff: tt.diff, AccessKey: tt.ak, SecretKey: tt.sk, Endpoint: tt.ep}, ask)
 			if err == nil {
 				t.Fatal("autoComment() error = nil, want validation error")
 			}
@@ -85,6 +64,65 @@ func TestAutoCommentRejectsInvalidInputBeforeModelCall(t *testing.T) {
 	}
 }
 
+func TestGenerateCommentFallsBackToHeuristicWithoutModelAccess(t *testing.T) {
+	diff := "diff --git a/pkg/store.go b/pkg/store.go\n" +
+		"--- a/pkg/store.go\n" +
+		"+++ b/pkg/store.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-func helper() int { return 1 }\n" +
+		"+func helper() int { return 2 }\n"
+
+	tests := []struct {
+		name string
+		opts commentOptions
+		ask  askQuestionFunc
+	}{
+		{
+			name: "missing credentials",
+			opts: commentOptions{Diff: diff, Endpoint: "test-endpoint"},
+		},
+		{
+			name: "missing endpoint",
+			opts: commentOptions{Diff: diff, AccessKey: "test-access-key", SecretKey: "test-secret-key"},
+		},
+		{
+			name: "offline flag",
+			opts: commentOptions{Diff: diff, AccessKey: "test-access-key", SecretKey: "test-secret-key", Endpoint: "test-endpoint", Offline: true},
+		},
+		{
+			name: "endpoint unreachable",
+			opts: commentOptions{Diff: diff, AccessKey: "test-access-key", SecretKey: "test-secret-key", Endpoint: "test-endpoint"},
+			ask: func(context.Context, string, string, string) (string, error) {
+				return "", errors.New("dial tcp: connection refused")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VOLC_ACCESSKEY", "")
+			t.Setenv("VOLC_SECRETKEY", "")
+			t.Setenv("DOUBAO_ENDPOINT", "")
+
+			ask := tt.ask
+			if ask == nil {
+				ask = func(context.Context, string, string, string) (string, error) {
+					t.Fatal("generateComment called the model without model access")
+					return "", nil
+				}
+			}
+			got, err := generateComment(context.Background(), tt.opts, ask)
+			if err != nil {
+				t.Fatalf("generateComment() error = %v, want heuristic draft", err)
+			}
+			want := "chore(pkg): update store.go\n\n- update pkg/store.go (+1 -1)\n  ~ func helper"
+			if got != want {
+				t.Fatalf("generateComment() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
 func TestAutoCommentPassesCallerContextToModel(t *testing.T) {
 	type contextKey struct{}
 	ctx := context.WithValue(context.Background(), contextKey{}, "caller-context")
@@ -104,7 +142,7 @@ func TestAutoCommentPassesCallerContextToModel(t *testing.T) {
 		return "fix(test): keep caller context", nil
 	}
 
-	err := autoCommentWithAsk(ctx, "diff --git a/a.txt b/a.txt\n", "test-access-key", "test-secret-key", "test-endpoint", "custom prompt", ask)
+	err := autoCommentWithAsk(ctx, commentOptions{Diff: "diff --git a/a.txt b/a.txt\n", AccessKey: "test-access-key", SecretKey: "test-secret-key", Endpoint: "test-endpoint", Prompt: "custom prompt"}, ask)
 	if err != nil {
 		t.Fatalf("autoCommentWithAsk returned error: %v", err)
 	}
@@ -136,7 +174,7 @@ func TestAutoCommentUsesResolvedFlags(t *testing.T) {
 		return "fix(comment): use flag credentials", nil
 	}
 
-	err := autoCommentWithAsk(context.Background(), "diff --git a/a.txt b/a.txt\n", "flag-access-key", "flag-sk", "flag-endpoint", "", ask)
+	err := autoCommentWithAsk(context.Background(), commentOptions{Diff: "diff --git a/a.txt b/a.txt\n", AccessKey: "flag-access-key", SecretKey: "flag-sk", Endpoint: "flag-endpoint"}, ask)
 	if err != nil {
 		t.Fatalf("autoCommentWithAsk() error = %v, want nil", err)
 	}
@@ -171,7 +209,7 @@ func TestAutoCommentFallsBackToEnvForBlankCredentialFlags(t *testing.T) {
 		return "fix(comment): resolve blank flags from env", nil
 	}
 
-	err := autoCommentWithAsk(context.Background(), "diff --git a/a.txt b/a.txt\n", " \t", "\n", " ", "", ask)
+	err := autoCommentWithAsk(context.Background(), commentOptions{Diff: "diff --git a/a.txt b/a.txt\n", AccessKey: " \t", SecretKey: "\n", Endpoint: " "}, ask)
 	if err != nil {
 		t.Fatalf("autoCommentWithAsk() error = %v, want nil", err)
 	}
@@ -206,7 +244,7 @@ func TestAutoCommentResolvesMixedFlagAndEnvValues(t *testing.T) {
 		return "fix(comment): resolve mixed flag and env credentials", nil
 	}
 
-	err := autoCommentWithAsk(context.Background(), "diff --git a/a.txt b/a.txt\n", "flag-ak", " ", "flag-endpoint", "", ask)
+	err := autoCommentWithAsk(context.Background(), commentOptions{Diff: "diff --git a/a.txt b/a.txt\n", AccessKey: "flag-ak", SecretKey: " ", Endpoint: "flag-endpoint"}, ask)
 	if err != nil {
 		t.Fatalf("autoCommentWithAsk() error = %v, want nil", err)
 	}
@@ -305,3 +343,26 @@ func TestBuildQuestionTruncatesFilteredDiffWhenSummaryStillTooLarge(t *testing.T
 		t.Fatalf("buildQuestion output does not include large diff marker")
 	}
 }
+
+func TestBuildQuestionSummarizesGoSymbolsForLargeFiles(t *testing.T) {
+	diff := "diff --git a/store.go b/store.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/store.go\n" +
+		"+++ b/store.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		strings.Repeat("+// padding\n", maxDiffLength/10)
+	load := fakeBlobLoader(map[string]string{"1111111": symbolsOldSource, "2222222": symbolsNewSource})
+
+	got := buildQuestionWithBlobs(diff, load)
+	if !strings.Contains(got, "--Large Diff Start --") {
+		t.Fatalf("buildQuestionWithBlobs() output does not include large diff marker")
+	}
+	for _, want := range []string{"~ method (*Store).Add", "+ type Option", "- func legacy"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("buildQuestionWithBlobs() output missing symbol %q", want)
+		}
+	}
+	if strings.Contains(got, "+// padding") {
+		t.Errorf("buildQuestionWithBlobs() kept large hunk content, want compacted summary")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// 以下是 summary 和 standup 在问题中描述提交的公共部分, 只包含提交信息、路径和行数, 不包含代码
+
+const (
+	// riskLargeLines 是被标为大改动的提交的最少增删行数
+	riskLargeLines = 500
+	// riskWideFiles 是被标为大范围改动的提交的最少文件数
+	riskWideFiles = 20
+	// maxListedFiles 是问题中每个提交或每组改动列出的文件数上限, 其余只给出数量
+	maxListedFiles = 8
+	// maxBodyRunes 是问题中每个提交正文摘录的长度上限
+	maxBodyRunes = 300
+)
+
+// dependencyFiles 是依赖清单, 修改它们的提交被标为有风险
+var dependencyFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "package.json": true, "package-lock.json": true, "yarn.lock": true,
+	"pnpm-lock.yaml": true, "cargo.toml": true, "cargo.lock": true, "requirements.txt": true, "pyproject.toml": true,
+	"pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "gemfile": true, "gemfile.lock": true,
+}
+
+// commitEntry 是问题中的一个提交: 日期、短哈希、标题、行数、风险、正文摘录和文件
+func commitEntry(c commitRecord) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- %s %s %s [+%d -%d, %d files]", c.AuthorDate.Format("2006-01-02"), c.ShortHash(), c.Subject, c.Added(), c.Removed(), len(c.Files)))
+	if risks := commitRisks(c); len(risks) > 0 {
+		sb.WriteString(" risk: " + strings.Join(risks, ", "))
+	}
+	sb.WriteString("\n")
+	if excerpt := commitBodyExcerpt(c.Body); excerpt != "" {
+		sb.WriteString("  " + excerpt + "\n")
+	}
+	files := make([]string, 0, maxListedFiles)
+	for i, f := range c.Files {
+		if i == maxListedFiles {
+			files = append(files, fmt.Sprintf("%d more", len(c.Files)-i))
+			break
+		}
+		if f.Binary {
+			files = append(files, f.Path+" (binary)")
+		} else {
+			files = append(files, fmt.Sprintf("%s (+%d -%d)", f.Path, f.Added, f.Removed))
+		}
+	}
+	if len(files) > 0 {
+		sb.WriteString("  files: " + strings.Join(files, ", ") + "\n")
+	}
+	return sb.String()
+}
+
+// commitBodyExcerpt 返回正文第一段去掉 trailer 后压成一行的摘录
+func commitBodyExcerpt(body string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(body), "\n\n")
+	var lines []string
+	for _, line := range strings.Split(paragraph, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || lintTrailerPattern.MatchString(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	excerpt := strings.Join(lines, " ")
+	if truncated := truncateRunes(excerpt, maxBodyRunes); truncated != excerpt {
+		return truncated + "..."
+	}
+	return excerpt
+}
+
+// commitAuthors 返回提交中出现的作者名字, 不包含邮箱
+func commitAuthors(commits []commitRecord) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range commits {
+		if !seen[c.AuthorName] {
+			seen[c.AuthorName] = true
+			names = append(names, c.AuthorName)
+		}
+	}
+	return names
+}
+
+// commitRisks 返回提交值得关注的原因: 不兼容变更、大改动、大范围改动和依赖变更
+func commitRisks(c commitRecord) []string {
+	var risks []string
+	cc, _ := parseConventionalSubject(c.Subject)
+	if cc.Breaking || hasBreakingFooter(c.Body) {
+		risks = append(risks, "breaking change")
+	}
+	if lines := c.Added() + c.Removed(); lines >= riskLargeLines {
+		risks = append(risks, fmt.Sprintf("large (%d lines)", lines))
+	}
+	if len(c.Files) >= riskWideFiles {
+		risks = append(risks, fmt.Sprintf("wide (%d files)", len(c.Files)))
+	}
+	for _, f := range c.Files {
+		if dependencyFiles[strings.ToLower(path.Base(f.Path))] {
+			risks = append(risks, "dependencies")
+			break
+		}
+	}
+	return risks
+}
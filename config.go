@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/khicago/irr"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvKeyConfigPath 指定配置文件路径的环境变量
+const EnvKeyConfigPath = "COMMITRON_CONFIG"
+
+// appConfig 是 commitron 配置文件的内容, 默认位于 $XDG_CONFIG_HOME/commitron/config.yaml
+type appConfig struct {
+	// Providers 是按顺序尝试的模型后端, 为空时使用 doubao + offline
+	Providers []providerConfig `yaml:"providers,omitempty"`
+	// Cache 控制本地响应缓存
+	Cache cacheConfig `yaml:"cache,omitempty"`
+	// Audit 控制模型调用的审计日志
+	Audit auditConfig `yaml:"audit,omitempty"`
+	// Usage 控制用量账本、价格表与月度预算
+	Usage usageConfig `yaml:"usage,omitempty"`
+	// Identities 将 insight --committer 的一个键展开为同一个人的多个名字、邮箱或 /正则/
+	Identities map[string][]string `yaml:"identities,omitempty"`
+	// Lint 是 lint 检查提交信息时使用的风格和阈值
+	Lint lintConfig `yaml:"lint,omitempty"`
+	// Standup 是 standup 默认汇总的本地仓库
+	Standup standupConfig `yaml:"standup,omitempty"`
+}
+
+// defaultConfigPath 返回配置文件路径, 优先使用 COMMITRON_CONFIG
+func defaultConfigPath() string {
+	if p := os.Getenv(EnvKeyConfigPath); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "commitron", "config.yaml")
+}
+
+// loadConfig 读取配置文件, path 为空时使用默认路径; 未显式指定且默认路径不存在时返回空配置
+func loadConfig(path string) (appConfig, error) {
+	explicit := path != "" || os.Getenv(EnvKeyConfigPath) != ""
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if path == "" {
+		return appConfig{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return appConfig{}, nil
+		}
+		return appConfig{}, irr.Wrap(err, "failed to read config %s", path)
+	}
+
+	var conf appConfig
+	if err = yaml.Unmarshal(data, &conf); err != nil {
+		return appConfig{}, irr.Wrap(err, "failed to parse config %s", path)
+	}
+	return conf, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultReworkDays 是默认的返工窗口: feat 之后多少天内修改相同文件的 fix 算作返工
+const DefaultReworkDays = 14
+
+// maxReportedScopes 是报告中列出的 scope 数量上限
+const maxReportedScopes = 10
+
+var (
+	// conventionalSubjectPattern 匹配 type(scope)!: description
+	conventionalSubjectPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()\r\n]*)\))?(!)?: +\S`)
+	breakingFooterPattern      = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+)
+
+// conventionalCommit 是解析后的 Conventional Commits 标题
+type conventionalCommit struct {
+	Type     string
+	Scope    string
+	Breaking bool
+}
+
+// parseConventionalSubject 解析 type(scope)!: description 形式的标题, type 统一为小写; 不符合时返回 false
+func parseConventionalSubject(subject string) (conventionalCommit, bool) {
+	m := conventionalSubjectPattern.FindStringSubmatch(subject)
+	if m == nil {
+		return conventionalCommit{}, false
+	}
+	return conventionalCommit{Type: strings.ToLower(m[1]), Scope: strings.TrimSpace(m[2]), Breaking: m[3] == "!"}, true
+}
+
+// hasBreakingFooter 判断提交正文中是否有 BREAKING CHANGE 脚注
+func hasBreakingFooter(body string) bool {
+	return breakingFooterPattern.MatchString(body)
+}
+
+// isGeneratedSubject 判断标题是否由 git 生成, 如 Merge branch 和 git revert 的 Revert "...", 它们不计入规范率
+func isGeneratedSubject(subject string) bool {
+	return strings.HasPrefix(subject, "Merge ") || strings.HasPrefix(subject, `Revert "`)
+}
+
+// countEntry 是一个分类的提交数和占比
+type countEntry struct {
+	Name    string  `json:"name"`
+	Commits int     `json:"commits"`
+	Share   float64 `json:"share"`
+}
+
+// reworkSummary 统计 feat 之后的返工: ReworkDays 天内修改了相同文件的 fix
+type reworkSummary struct {
+	ReworkDays int `json:"rework_days"`
+	Feats      int `json:"feat_commits"`
+	Fixes      int `json:"fix_commits"`
+	// ReworkFixes 是修改了近期 feat 所改文件的 fix, ReworkedFeats 是之后被这样的 fix 修改过的 feat
+	ReworkFixes   int `json:"rework_fixes"`
+	ReworkedFeats int `json:"reworked_feats"`
+	// Rate 是 ReworkedFeats / Feats
+	Rate float64 `json:"rework_rate"`
+}
+
+// conventionalSummary 是 Conventional Commits 规范的统计
+//
+// Merge 和 Revert 这类 git 生成的标题计入 Ignored, 不参与其他统计
+type conventionalSummary struct {
+	Commits            int           `json:"commits"`
+	Ignored            int           `json:"ignored"`
+	Conforming         int           `json:"conforming"`
+	NonConforming      int           `json:"non_conforming"`
+	NonConformingShare float64       `json:"non_conforming_share"`
+	Breaking           int           `json:"breaking_changes"`
+	Types              []countEntry  `json:"types"`
+	Scopes             []countEntry  `json:"scopes"`
+	Rework             reworkSummary `json:"rework"`
+}
+
+// getConventionalSummary 按标题统计类型、scope、不规范的提交和不兼容变更, 并计算 feat 之后的返工率
+func getConventionalSummary(commits []commitRecord, reworkDays int) conventionalSummary {
+	summary := conventionalSummary{Commits: len(commits)}
+	types, scopes := make(map[string]int), make(map[string]int)
+	parsed := make(map[string]conventionalCommit, len(commits))
+	for _, c := range commits {
+		if isGeneratedSubject(c.Subject) {
+			summary.Ignored++
+			continue
+		}
+		cc, ok := parseConventionalSubject(c.Subject)
+		if ok {
+			summary.Conforming++
+			types[cc.Type]++
+			if cc.Scope != "" {
+				scopes[cc.Scope]++
+			}
+			parsed[c.Hash] = cc
+		} else {
+			summary.NonConforming++
+		}
+		if cc.Breaking || hasBreakingFooter(c.Body) {
+			summary.Breaking++
+		}
+	}
+	considered := summary.Conforming + summary.NonConforming
+	summary.NonConformingShare = share(summary.NonConforming, considered)
+	summary.Types = countEntries(types, considered, 0)
+	summary.Scopes = countEntries(scopes, considered, maxReportedScopes)
+	summary.Rework = getReworkSummary(commits, parsed, reworkDays)
+	return summary
+}
+
+// getReworkSummary 按作者时间从旧到新找出修改了 reworkDays 天内 feat 所改文件的 fix
+func getReworkSummary(commits []commitRecord, parsed map[string]conventionalCommit, reworkDays int) reworkSummary {
+	summary := reworkSummary{ReworkDays: reworkDays}
+	sorted := append([]commitRecord(nil), commits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].AuthorDate.Before(sorted[j].AuthorDate) })
+
+	type featTouch struct {
+		hash string
+		at   time.Time
+	}
+	window := time.Duration(reworkDays) * 24 * time.Hour
+	lastFeat := make(map[string]featTouch)
+	reworked := make(map[string]bool)
+	for _, c := range sorted {
+		switch parsed[c.Hash].Type {
+		case "feat":
+			summary.Feats++
+			for _, f := range c.Files {
+				lastFeat[f.Path] = featTouch{hash: c.Hash, at: c.AuthorDate}
+			}
+		case "fix":
+			summary.Fixes++
+			rework := false
+			for _, f := range c.Files {
+				if feat, ok := lastFeat[f.Path]; ok && c.AuthorDate.Sub(feat.at) <= window {
+					rework = true
+					reworked[feat.hash] = true
+				}
+			}
+			if rework {
+				summary.ReworkFixes++
+			}
+		}
+	}
+	summary.ReworkedFeats = len(reworked)
+	summary.Rate = share(summary.ReworkedFeats, summary.Feats)
+	return summary
+}
+
+// countEntries 按提交数降序返回前 n 项, n <= 0 时返回全部; 数量相同时按名字排序
+func countEntries(counts map[string]int, total, n int) []countEntry {
+	entries := make([]countEntry, 0, len(counts))
+	for name, count := range counts {
+		entries = append(entries, countEntry{Name: name, Commits: count, Share: share(count, total)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Commits != entries[j].Commits {
+			return entries[i].Commits > entries[j].Commits
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConventionalSubject(t *testing.T) {
+	for subject, want := range map[string]conventionalCommit{
+		"feat: add login":             {Type: "feat"},
+		"Fix(api): nil map":           {Type: "fix", Scope: "api"},
+		"refactor(core)!: drop v1":    {Type: "refactor", Scope: "core", Breaking: true},
+		"chore!: bump go":             {Type: "chore", Breaking: true},
+		"docs( readme ): typo":        {Type: "docs", Scope: "readme"},
+		"build(deps): bump yaml.v3 x": {Type: "build", Scope: "deps"},
+	} {
+		if got, ok := parseConventionalSubject(subject); !ok || got != want {
+			t.Errorf("parseConventionalSubject(%q) = %+v, %v, want %+v", subject, got, ok, want)
+		}
+	}
+	for _, subject := range []string{"Add login", "feat:add login", "feat(api: x", "feat: ", "WIP", "fix(a)(b): x"} {
+		if got, ok := parseConventionalSubject(subject); ok {
+			t.Errorf("parseConventionalSubject(%q) = %+v, want non-conforming", subject, got)
+		}
+	}
+	if !hasBreakingFooter("details\n\nBREAKING CHANGE: config moved\n") || !hasBreakingFooter("BREAKING-CHANGE: x") || hasBreakingFooter("mentions BREAKING CHANGE: inline") {
+		t.Error("hasBreakingFooter() want only footer lines to count")
+	}
+}
+
+func TestConventionalSummaryCountsTypesScopesAndRework(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC).AddDate(0, 0, n) }
+	commit := func(hash string, n int, subject string, files ...string) commitRecord {
+		c := commitRecord{Hash: hash, AuthorDate: day(n), Subject: subject}
+		for _, f := range files {
+			c.Files = append(c.Files, fileStat{Path: f})
+		}
+		return c
+	}
+	breaking := commit("c5", 6, "feat(api): v2 routes", "api/v2.go")
+	breaking.Body = "BREAKING CHANGE: v1 routes are gone"
+	// 故意乱序, 返工按作者时间计算
+	commits := []commitRecord{
+		commit("c3", 3, "fix(api): crash on empty body", "api/handler.go"),
+		commit("c1", 0, "feat(api): add handler", "api/handler.go", "api/routes.go"),
+		commit("c2", 1, "feat(db)!: new schema", "db/schema.sql"),
+		commit("c4", 30, "fix(db): index", "db/schema.sql"),
+		breaking,
+		commit("c6", 7, "update readme", "README.md"),
+		commit("c7", 8, "Merge branch 'x'"),
+		commit("c8", 9, `Revert "feat: y"`, "y.go"),
+		commit("c9", 7, "fix: routes", "api/routes.go"),
+	}
+
+	summary := getConventionalSummary(commits, 14)
+	if summary.Commits != 9 || summary.Ignored != 2 || summary.Conforming != 6 || summary.NonConforming != 1 || summary.NonConformingShare != 0.143 {
+		t.Errorf("summary = %+v, want 6 conforming, 1 non-conforming and 2 ignored", summary)
+	}
+	if summary.Breaking != 2 {
+		t.Errorf("breaking = %d, want 2 from ! and the footer", summary.Breaking)
+	}
+	var types, scopes []string
+	for _, e := range summary.Types {
+		types = append(types, e.Name)
+	}
+	for _, e := range summary.Scopes {
+		scopes = append(scopes, e.Name)
+	}
+	if strings.Join(types, ",") != "feat,fix" || summary.Types[0].Commits != 3 || strings.Join(scopes, ",") != "api,db" {
+		t.Errorf("types = %+v, scopes = %+v", summary.Types, summary.Scopes)
+	}
+	want := reworkSummary{ReworkDays: 14, Feats: 3, Fixes: 3, ReworkFixes: 2, ReworkedFeats: 1, Rate: 0.333}
+	if summary.Rework != want {
+		t.Errorf("rework = %+v, want %+v: the db fix came after the window", summary.Rework, want)
+	}
+}
+
+func TestGitLogReadsBodies(t *testing.T) {
+	at := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+	dir := newScriptRepo(t, scriptCommit("Ann <ann@example.com>", at, "feat: a\n\nBREAKING CHANGE: removed b\n", scriptFile("a.go", "a\n"))+
+		scriptCommit("Ann <ann@example.com>", at.Add(time.Hour), "fix: b\n\nbody\x1fwith separator\n", scriptFile("a.go", "b\n")))
+	commits, err := loadCommits(dir)
+	if err != nil {
+		t.Fatalf("loadCommits() error = %v", err)
+	}
+	if len(commits) != 2 || commits[0].Body != "body\x1fwith separator" || commits[1].Body != "BREAKING CHANGE: removed b" || commits[0].Subject != "fix: b" || len(commits[0].Files) != 1 {
+		t.Errorf("commits = %+v, want bodies read intact", commits)
+	}
+}
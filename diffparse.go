@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// fileDiff 是 unified diff 中单个文件的解析结果
+type fileDiff struct {
+	OldPath, NewPath string
+	OldBlob, NewBlob string
+
+	IsNew, IsDeleted, IsRename, IsBinary bool
+	Similarity                           int
+
+	Added, Removed int
+	AddedLines     []string
+	RemovedLines   []string
+	HunkHeaders    []string
+
+	// Raw 是该文件完整的 diff 文本 (包含 diff --git 头)
+	Raw string
+}
+
+// Path 返回变更后的文件路径, 删除文件时返回原路径
+func (f fileDiff) Path() string {
+	if f.IsDeleted || f.NewPath == "" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// parseDiff 将 git diff 输出按文件解析
+func parseDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var current *fileDiff
+	var raw strings.Builder
+	inHunk := false
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Raw = raw.String()
+		files = append(files, *current)
+		current = nil
+		raw.Reset()
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			current = &fileDiff{}
+			current.OldPath, current.NewPath = parseDiffGitPaths(strings.TrimPrefix(line, "diff --git "))
+			inHunk = false
+		}
+		if current == nil {
+			continue
+		}
+		raw.WriteString(line)
+		raw.WriteString("\n")
+
+		if inHunk {
+			switch {
+			case strings.HasPrefix(line, "@@ "):
+				current.HunkHeaders = append(current.HunkHeaders, line)
+			case strings.HasPrefix(line, "+"):
+				current.Added++
+				current.AddedLines = append(current.AddedLines, line[1:])
+			case strings.HasPrefix(line, "-"):
+				current.Removed++
+				current.RemovedLines = append(current.RemovedLines, line[1:])
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+			current.HunkHeaders = append(current.HunkHeaders, line)
+		case strings.HasPrefix(line, "new file mode"):
+			current.IsNew = true
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.IsDeleted = true
+		case strings.HasPrefix(line, "rename from "):
+			current.IsRename = true
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			current.IsRename = true
+			current.NewPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "similarity index "):
+			current.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+		case strings.HasPrefix(line, "index "):
+			current.OldBlob, current.NewBlob = parseIndexLine(strings.TrimPrefix(line, "index "))
+		case strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch"):
+			current.IsBinary = true
+		case strings.HasPrefix(line, "--- "):
+			if p := strings.TrimPrefix(line, "--- "); p != "/dev/null" {
+				current.OldPath = strings.TrimPrefix(p, "a/")
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+				current.NewPath = strings.TrimPrefix(p, "b/")
+			}
+		}
+	}
+	flush()
+	return files
+}
+
+// parseDiffGitPaths 解析 "a/x b/y" 形式的路径对
+func parseDiffGitPaths(s string) (string, string) {
+	// 路径中不含空格时, 直接按 " b/" 切分即可; 含空格时取对称切分
+	if idx := strings.Index(s, " b/"); idx >= 0 && strings.HasPrefix(s, "a/") {
+		return strings.TrimPrefix(s[:idx], "a/"), s[idx+len(" b/"):]
+	}
+	parts := strings.Fields(s)
+	if len(parts) != 2 {
+		return s, s
+	}
+	return strings.TrimPrefix(parts[0], "a/"), strings.TrimPrefix(parts[1], "b/")
+}
+
+// parseIndexLine 解析 "abc123..def456 100644" 形式的 blob 信息
+func parseIndexLine(s string) (string, string) {
+	blobs := strings.Fields(s)
+	if len(blobs) == 0 {
+		return "", ""
+	}
+	oldBlob, newBlob, ok := strings.Cut(blobs[0], "..")
+	if !ok {
+		return "", ""
+	}
+	return nullBlob(oldBlob), nullBlob(newBlob)
+}
+
+func nullBlob(blob string) string {
+	if strings.Trim(blob, "0") == "" {
+		return ""
+	}
+	return blob
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bagaking/botheater/utils"
+)
+
+// describeDryRun 描述 comment 将会发送的全部内容, 不联系任何模型
+func describeDryRun(opts commentOptions, prompt string, load blobLoader) string {
+	question, report := compactQuestion(opts.Diff, load)
+	question, redactions := redactSecrets(question)
+
+	sb := strings.Builder{}
+	sb.WriteString("# Dry run: nothing was sent\n")
+
+	if !opts.NoShortcuts {
+		if msg, ok := shortcutMessage(opts.Diff); ok {
+			sb.WriteString("\n## Shortcut\n\n")
+			sb.WriteString("This diff is answered without the model:\n\n")
+			sb.WriteString(msg + "\n")
+		}
+	}
+
+	writeProviderChain(&sb, opts)
+
+	sb.WriteString("\n## Compaction\n\n")
+	if len(report.Summarized)+len(report.Truncated)+len(report.Dropped) == 0 {
+		sb.WriteString("The diff is sent in full.\n")
+	}
+	writeFileList(&sb, fmt.Sprintf("Summarized (over %d bytes, hunks replaced by headers and Go symbols)", maxFileLength), report.Summarized)
+	writeFileList(&sb, fmt.Sprintf("Truncated (cut at %d characters)", maxDiffLength), report.Truncated)
+	writeFileList(&sb, "Dropped (after the truncation point)", report.Dropped)
+	writeRedactions(&sb, redactions)
+
+	promptTokens, questionTokens := utils.CountTokens(prompt), utils.CountTokens(question)
+	sb.WriteString("\n## Token estimate\n\n")
+	sb.WriteString(fmt.Sprintf("- Prompt: %d\n- Question: %d\n- Total: %d\n", promptTokens, questionTokens, promptTokens+questionTokens))
+
+	sb.WriteString("\n## Prompt\n\n")
+	sb.WriteString(prompt + "\n")
+	sb.WriteString("\n## Question\n\n")
+	sb.WriteString(question + "\n")
+	return sb.String()
+}
+
+// describeQuestionDryRun 描述 askWithQuestion 将会发送的全部内容, 不联系任何模型
+func describeQuestionDryRun(opts commentOptions, req modelRequest) string {
+	sb := strings.Builder{}
+	req = redactRequest(req)
+	sb.WriteString("# Dry run: nothing was sent\n")
+	writeProviderChain(&sb, opts)
+	writeRedactions(&sb, req.Redactions)
+
+	promptTokens, questionTokens := utils.CountTokens(req.Prompt), utils.CountTokens(req.Question)
+	sb.WriteString("\n## Token estimate\n\n")
+	sb.WriteString(fmt.Sprintf("- Prompt: %d\n- Question: %d\n- Total: %d\n", promptTokens, questionTokens, promptTokens+questionTokens))
+
+	sb.WriteString("\n## Prompt\n\n")
+	sb.WriteString(req.Prompt + "\n")
+	sb.WriteString("\n## Question\n\n")
+	sb.WriteString(req.Question)
+	return sb.String()
+}
+
+// writeProviderChain 列出将按顺序尝试的后端, 端点只显示首尾
+func writeProviderChain(sb *strings.Builder, opts commentOptions) {
+	sb.WriteString("\n## Providers\n\n")
+	chain := opts.Providers
+	if opts.Offline {
+		chain = []providerConfig{{Name: ProviderTypeOffline, Type: ProviderTypeOffline}}
+	} else if len(chain) == 0 {
+		chain = defaultProviders
+	}
+	for i, conf := range chain {
+		p, err := resolveProvider(conf, opts, nil)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("%d. %s (%s): unavailable, %v\n", i+1, conf.displayName(), conf.Type, err))
+			continue
+		}
+		line := fmt.Sprintf("%d. %s (%s)", i+1, conf.displayName(), conf.Type)
+		if p.Endpoint != "" {
+			line += " endpoint=" + maskEndpoint(p.Endpoint)
+		}
+		if p.Model != "" {
+			line += " model=" + p.Model
+		}
+		sb.WriteString(line + "\n")
+	}
+}
+
+// writeRedactions 列出发送前替换的秘密, 只显示规则名和次数
+func writeRedactions(sb *strings.Builder, redactions []redactionCount) {
+	sb.WriteString("\n## Redactions\n\n")
+	if len(redactions) == 0 {
+		sb.WriteString("Nothing looked like a secret.\n")
+	}
+	for _, r := range redactions {
+		sb.WriteString(fmt.Sprintf("- %s: %d\n", r.Rule, r.Count))
+	}
+}
+
+func writeFileList(sb *strings.Builder, title string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	sb.WriteString(title + ":\n")
+	for _, p := range paths {
+		sb.WriteString("- " + p + "\n")
+	}
+}
+
+// maskEndpoint 只保留端点首尾各 4 个字符, 避免在日志和截图中泄露完整值
+func maskEndpoint(endpoint string) string {
+	runes := []rune(endpoint)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", len(runes)-8) + string(runes[len(runes)-4:])
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompactQuestionReportsSummarizedTruncatedAndDroppedFiles(t *testing.T) {
+	var diff strings.Builder
+	writeFile := func(name string, size int) {
+		diff.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n", name, name, name, name))
+		diff.WriteString("+" + strings.Repeat("x", size) + "\n")
+	}
+	writeFile("large.txt", maxFileLength+1)
+	for i := 0; i < 10; i++ {
+		writeFile(fmt.Sprintf("small%d.txt", i), maxFileLength/2)
+	}
+
+	question, report := compactQuestion(diff.String(), nil)
+	if len([]rune(question)) != maxDiffLength {
+		t.Fatalf("compactQuestion() returned %d runes, want %d", len([]rune(question)), maxDiffLength)
+	}
+	if len(report.Summarized) != 1 || report.Summarized[0] != "large.txt" {
+		t.Errorf("report.Summarized = %v, want [large.txt]", report.Summarized)
+	}
+	if len(report.Truncated) != 1 {
+		t.Fatalf("report.Truncated = %v, want exactly one file cut at the limit", report.Truncated)
+	}
+	if !strings.Contains(question, "a/"+report.Truncated[0]) {
+		t.Errorf("truncated file %s is not present in the question", report.Truncated[0])
+	}
+	if len(report.Dropped) == 0 {
+		t.Fatal("report.Dropped is empty, want files after the truncation point")
+	}
+	for _, dropped := range report.Dropped {
+		if strings.Contains(question, "a/"+dropped) {
+			t.Errorf("dropped file %s is still present in the question", dropped)
+		}
+	}
+	if got := len(report.Summarized) + len(report.Truncated) + len(report.Dropped); got > 11 {
+		t.Errorf("report lists %d files, want at most 11", got)
+	}
+}
+
+func TestAutoCommentDryRunDoesNotContactModel(t *testing.T) {
+	ask := func(context.Context, string, string, string) (string, error) {
+		t.Fatal("dry run called the model")
+		return "", nil
+	}
+	opts := commentOptions{
+		Diff:      providerTestDiff,
+		AccessKey: "test-ak",
+		SecretKey: "test-sk",
+		Endpoint:  "ep-20240101-secret-id",
+		DryRun:    true,
+	}
+	if err := autoCommentWithAsk(context.Background(), opts, ask); err != nil {
+		t.Fatalf("autoCommentWithAsk() dry run error = %v", err)
+	}
+
+	got := describeDryRun(opts, "test prompt", nil)
+	for _, want := range []string{
+		"1. doubao (doubao) endpoint=ep-2*************t-id",
+		"2. offline (offline)",
+		"The diff is sent in full.",
+		"## Token estimate",
+		"test prompt",
+		"DiffInfo 如下:\n" + providerTestDiff,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("describeDryRun() missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ep-20240101-secret-id") {
+		t.Errorf("describeDryRun() leaks the full endpoint:\n%s", got)
+	}
+}
+
+func TestMaskEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"":                       "",
+		"short":                  "*****",
+		"ep-123456789":           "ep-1****6789",
+		"http://localhost:11434": "http**************1434",
+	}
+	for in, want := range tests {
+		if got := maskEndpoint(in); got != want {
+			t.Errorf("maskEndpoint(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
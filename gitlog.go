@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khicago/irr"
+)
+
+const (
+	gitLogRecordSep  = "\x1e"
+	gitLogFieldSep   = "\x1f"
+	gitLogTrailerSep = "\x1d"
+
+	// gitLogFormat 每个提交以 \x1e 开头, 字段之间以 \x1f 分隔; -z 时头部和 numstat 条目都以 NUL 结尾
+	//
+	// 作者使用 %aN/%aE, 即经过 .mailmap 映射的规范身份. 正文放在最后, 其中可能出现任意字符
+	gitLogFormat = gitLogRecordSep + "%H" + gitLogFieldSep + "%aN" + gitLogFieldSep + "%aE" +
+		gitLogFieldSep + "%aI" + gitLogFieldSep + "%cI" +
+		gitLogFieldSep + "%(trailers:key=Co-authored-by,valueonly,separator=%x1d)" + gitLogFieldSep + "%s" + gitLogFieldSep + "%b"
+)
+
+// commitRecord 是 git log 中的一个提交及其 numstat
+type commitRecord struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	AuthorDate  time.Time
+	CommitDate  time.Time
+	// CoAuthors 是 Co-authored-by 中的 "Name <email>"
+	CoAuthors []string
+	Subject   string
+	// Body 是提交信息中标题之后的正文, 包括 trailer
+	Body  string
+	Files []fileStat
+}
+
+// fileStat 是提交中一个文件的 numstat
+type fileStat struct {
+	Path string
+	// OldPath 仅在重命名时非空
+	OldPath string
+	Added   int
+	Removed int
+	// Binary 为 true 时 numstat 不统计行数
+	Binary bool
+}
+
+// Added 返回提交新增的总行数
+func (c commitRecord) Added() int {
+	n := 0
+	for _, f := range c.Files {
+		n += f.Added
+	}
+	return n
+}
+
+// Removed 返回提交删除的总行数
+func (c commitRecord) Removed() int {
+	n := 0
+	for _, f := range c.Files {
+		n += f.Removed
+	}
+	return n
+}
+
+// ShortHash 返回 7 位短哈希
+func (c commitRecord) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// streamGitLog 在 dir 中执行一次 git log --numstat -z, 逐个提交回调 fn
+//
+// dir 为空时使用当前目录; fn 返回错误时停止读取. 总是打开重命名检测, 不受 diff.renames 配置影响
+func streamGitLog(dir string, args []string, fn func(commitRecord) error) error {
+	cmdArgs := append([]string{"log", "-z", "-M", "--numstat", "--format=" + gitLogFormat}, args...)
+	cmd := exec.Command("git", cmdArgs...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return irr.Wrap(err, "failed to read git log")
+	}
+	if err = cmd.Start(); err != nil {
+		return irr.Wrap(err, "failed to run git log")
+	}
+
+	parseErr := parseGitLog(stdout, fn)
+	if parseErr != nil {
+		// 提前停止时丢弃剩余输出, 让 git 正常退出
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err = cmd.Wait(); err != nil {
+		return irr.Wrap(err, "git log failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return parseErr
+}
+
+// renameTracker 记录 git log 中出现过的重命名, 让旧提交中的路径跟随到文件现在的路径
+//
+// git log 从新到旧输出, 所以必须按输出顺序对每个提交先 follow 再 observe:
+// 重命名之后在旧路径上新建的文件不会被误认为是被重命名的文件
+type renameTracker map[string]string
+
+// follow 将提交中的路径改写为文件现在的路径, OldPath 保持不变
+func (t renameTracker) follow(c commitRecord) commitRecord {
+	if len(t) == 0 {
+		return c
+	}
+	files := make([]fileStat, len(c.Files))
+	for i, f := range c.Files {
+		f.Path = t.resolve(f.Path)
+		files[i] = f
+	}
+	c.Files = files
+	return c
+}
+
+// observe 记录提交中的重命名, 应在 follow 之后调用
+func (t renameTracker) observe(c commitRecord) {
+	for _, f := range c.Files {
+		if f.OldPath != "" && f.OldPath != f.Path {
+			t[f.OldPath] = f.Path
+		}
+	}
+}
+
+// resolve 沿重命名链找到 path 现在的路径; 文件被改回原名时链中会出现环, 此时停在环上
+func (t renameTracker) resolve(path string) string {
+	for i := 0; i < len(t); i++ {
+		next, ok := t[path]
+		if !ok || next == path {
+			break
+		}
+		path = next
+	}
+	return path
+}
+
+// loadCommits 读取 git log 的全部提交
+func loadCommits(dir string, args ...string) ([]commitRecord, error) {
+	var commits []commitRecord
+	err := streamGitLog(dir, args, func(c commitRecord) error {
+		commits = append(commits, c)
+		return nil
+	})
+	return commits, err
+}
+
+// parseGitLog 解析 gitLogFormat 格式的 git log -z --numstat 输出
+func parseGitLog(r io.Reader, fn func(commitRecord) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	scanner.Split(splitNUL)
+
+	var (
+		current commitRecord
+		started bool
+	)
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		return fn(current)
+	}
+
+	for scanner.Scan() {
+		token := strings.TrimLeft(scanner.Text(), "\n")
+		if token == "" {
+			continue
+		}
+
+		if strings.HasPrefix(token, gitLogRecordSep) {
+			if err := flush(); err != nil {
+				return err
+			}
+			c, err := parseGitLogHeader(token[len(gitLogRecordSep):])
+			if err != nil {
+				return err
+			}
+			current, started = c, true
+			continue
+		}
+		if !started {
+			return irr.Error("unexpected git log output before the first commit: %q", token)
+		}
+
+		parts := strings.SplitN(token, "\t", 3)
+		if len(parts) != 3 {
+			return irr.Error("unexpected numstat entry %q in commit %s", token, current.Hash)
+		}
+		stat := fileStat{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Added, _ = strconv.Atoi(parts[0])
+			stat.Removed, _ = strconv.Atoi(parts[1])
+		}
+		// 重命名时路径为空, 之后的两个 NUL 结尾字段依次是旧路径和新路径
+		if stat.Path == "" {
+			if !scanner.Scan() {
+				break
+			}
+			stat.OldPath = scanner.Text()
+			if !scanner.Scan() {
+				break
+			}
+			stat.Path = scanner.Text()
+		}
+		current.Files = append(current.Files, stat)
+	}
+	if err := scanner.Err(); err != nil {
+		return irr.Wrap(err, "failed to read git log")
+	}
+	return flush()
+}
+
+func parseGitLogHeader(header string) (commitRecord, error) {
+	fields := strings.SplitN(header, gitLogFieldSep, 8)
+	if len(fields) != 8 {
+		return commitRecord{}, irr.Error("unexpected git log header %q", header)
+	}
+	authorDate, err := time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return commitRecord{}, irr.Wrap(err, "invalid author date in commit %s", fields[0])
+	}
+	commitDate, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return commitRecord{}, irr.Wrap(err, "invalid commit date in commit %s", fields[0])
+	}
+	var coAuthors []string
+	for _, co := range strings.Split(fields[5], gitLogTrailerSep) {
+		if co = strings.TrimSpace(co); co != "" {
+			coAuthors = append(coAuthors, co)
+		}
+	}
+	return commitRecord{
+		Hash:        fields[0],
+		AuthorName:  fields[1],
+		AuthorEmail: fields[2],
+		AuthorDate:  authorDate,
+		CommitDate:  commitDate,
+		CoAuthors:   coAuthors,
+		Subject:     fields[6],
+		Body:        strings.TrimSpace(fields[7]),
+	}, nil
+}
+
+// splitNUL 是以 NUL 分隔的 bufio.SplitFunc
+func splitNUL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
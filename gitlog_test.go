@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newScriptRepo 在 main 分支上用 git fast-import 执行 script 生成仓库
+func newScriptRepo(tb testing.TB, script string) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "-b", "main", dir).CombinedOutput(); err != nil {
+		tb.Fatalf("git init: %v: %s", err, out)
+	}
+	cmd := exec.Command("git", "fast-import", "--quiet")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("git fast-import: %v: %s", err, out)
+	}
+	return dir
+}
+
+// scriptCommit 返回 fast-import 的一个提交, author 形如 "Name <email>", body 是文件操作
+func scriptCommit(author string, at time.Time, message, body string) string {
+	stamp := fmt.Sprintf("%s %d +0000", author, at.Unix())
+	return fmt.Sprintf("commit refs/heads/main\nauthor %s\ncommitter %s\ndata %d\n%s\n%s\n", stamp, stamp, len(message), message, body)
+}
+
+// scriptFile 返回 fast-import 中写入一个文件的操作
+func scriptFile(path, content string) string {
+	return fmt.Sprintf("M 644 inline %s\ndata %d\n%s\n", path, len(content), content)
+}
+
+// newFixtureRepo 用 git fast-import 生成一个包含 n 个提交的仓库, 提交者在 Alice 和 Bob 之间交替
+func newFixtureRepo(tb testing.TB, n int) string {
+	tb.Helper()
+
+	var script strings.Builder
+	base := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		author := "Alice <alice@example.com>"
+		if i%2 == 1 {
+			author = "Bob <bob@example.com>"
+		}
+		content := strings.Repeat(fmt.Sprintf("line %d\n", i), i%7+1)
+		script.WriteString(scriptCommit(author, base.Add(time.Duration(i)*time.Hour), fmt.Sprintf("change %d", i),
+			scriptFile(fmt.Sprintf("pkg%d/file%d.go", i%5, i%20), content)))
+	}
+	return newScriptRepo(tb, script.String())
+}
+
+func TestParseGitLogHandlesRenamesBinariesAndEmptyCommits(t *testing.T) {
+	header := func(hash, subject string) string {
+		return gitLogRecordSep + strings.Join([]string{hash, "Alice", "alice@example.com", "2026-01-05T10:00:00+08:00", "2026-01-05T11:00:00+08:00", "", subject, ""}, gitLogFieldSep) + "\x00"
+	}
+	raw := header("aaaaaaaaaa", "empty") +
+		header("bbbbbbbbbb", "rename and binary") + "\n1\t0\t\x00old.txt\x00new.txt\x00-\t-\timg.png\x00" +
+		header("cccccccccc", "tabs\tin subject") + "\n3\t2\tdir/a.go\x00"
+
+	var commits []commitRecord
+	err := parseGitLog(strings.NewReader(raw), func(c commitRecord) error {
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseGitLog() error = %v", err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("parseGitLog() returned %d commits, want 3", len(commits))
+	}
+	if len(commits[0].Files) != 0 {
+		t.Errorf("empty commit files = %+v, want none", commits[0].Files)
+	}
+	want := []fileStat{{Path: "new.txt", OldPath: "old.txt", Added: 1}, {Path: "img.png", Binary: true}}
+	if len(commits[1].Files) != 2 || commits[1].Files[0] != want[0] || commits[1].Files[1] != want[1] {
+		t.Errorf("rename commit files = %+v, want %+v", commits[1].Files, want)
+	}
+	if c := commits[2]; c.Subject != "tabs\tin subject" || c.Added() != 3 || c.Removed() != 2 || c.ShortHash() != "ccccccc" {
+		t.Errorf("commit = %+v, want subject with tab and 3/2 lines", c)
+	}
+	if got := commits[2].CommitDate.Format("2006-01-02 15:04"); got != "2026-01-05 11:00" {
+		t.Errorf("commit date = %s, want committer local time", got)
+	}
+}
+
+func TestLoadCommitsReadsFixtureRepoInOnePass(t *testing.T) {
+	dir := newFixtureRepo(t, 20)
+
+	commits, err := getUserCommits(dir, insightOptions{Committers: []string{"Bob"}})
+	if err != nil {
+		t.Fatalf("getUserCommits() error = %v", err)
+	}
+	if len(commits) != 10 {
+		t.Fatalf("getUserCommits() returned %d commits, want 10", len(commits))
+	}
+	for _, c := range commits {
+		if c.AuthorEmail != "bob@example.com" || len(c.Files) != 1 {
+			t.Fatalf("commit = %+v, want Bob's commit touching one file", c)
+		}
+	}
+
+	stats := getUserStats(commits, nil)
+	if stats.TotalCommits != 10 || stats.TotalAdded == 0 {
+		t.Fatalf("getUserStats() = %+v, want 10 commits with added lines", stats)
+	}
+
+	report, err := collectInsight(dir, insightOptions{Committers: []string{"Bob"}, DirDepth: 1})
+	if err != nil {
+		t.Fatalf("collectInsight() error = %v", err)
+	}
+	if report.Commits != 10 || report.MainCommits != 10 || report.NonMainCommits != 0 {
+		t.Fatalf("collectInsight() = %+v, want Bob's 10 commits on main", report)
+	}
+}
+
+func BenchmarkCollectInsight(b *testing.B) {
+	dir := newFixtureRepo(b, 2000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := collectInsight(dir, insightOptions{Committers: []string{"Alice"}, DirDepth: 1}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRenameTrackerFollowsHistoryToTheCurrentPath(t *testing.T) {
+	// 从新到旧: c.go 改名为 d.go 之前, 它由 b.go 改名而来, 而 b.go 又来自 a.go
+	log := []commitRecord{
+		{Hash: "5", Files: []fileStat{{Path: "a.go", Added: 1}}}, // 改名之后在旧路径上新建的文件
+		{Hash: "4", Files: []fileStat{{Path: "d.go", OldPath: "c.go"}}},
+		{Hash: "3", Files: []fileStat{{Path: "c.go", OldPath: "b.go", Added: 2}}},
+		{Hash: "2", Files: []fileStat{{Path: "b.go", OldPath: "a.go"}}},
+		{Hash: "1", Files: []fileStat{{Path: "a.go", Added: 5}, {Path: "other.go", Added: 1}}},
+	}
+	want := [][]string{{"a.go"}, {"d.go"}, {"d.go"}, {"d.go"}, {"d.go", "other.go"}}
+
+	renames := make(renameTracker)
+	for i, c := range log {
+		c = renames.follow(c)
+		renames.observe(c)
+		for j, f := range c.Files {
+			if f.Path != want[i][j] {
+				t.Errorf("commit %s file %d = %s, want %s", c.Hash, j, f.Path, want[i][j])
+			}
+		}
+	}
+	if log[4].Files[0].Path != "a.go" {
+		t.Errorf("follow() modified the input commit: %+v", log[4].Files)
+	}
+
+	// 改回原名形成环时不会死循环
+	cycle := renameTracker{"x": "y", "y": "x"}
+	if got := cycle.resolve("x"); got != "x" && got != "y" {
+		t.Errorf("resolve() in a cycle = %s", got)
+	}
+}
@@ -5,17 +5,18 @@ go 1.22.3
 require (
 	github.com/bagaking/botheater v0.0.0-20240804054820-8f0276d08613
 	github.com/bagaking/easycmd v0.0.0-20240210081455-99838b3fc09b
+	github.com/cenkalti/backoff/v4 v4.3.0
 	github.com/khicago/got v0.0.0-20240720113131-2d29fd22f532
 	github.com/khicago/irr v0.0.0-20240309052027-df085c2216f6
 	github.com/sirupsen/logrus v1.9.3
 	github.com/urfave/cli/v2 v2.3.0
+	gopkg.in/yaml.v3 v3.0.1
 
 )
 
 require (
 	github.com/bagaking/goulp v0.0.0-20240621115658-1f21cb392e5d // indirect
 	github.com/bytedance/gopkg v0.0.0-20240802064923-4d9cac1af28c // indirect
-	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.4 // indirect
 	github.com/google/martian v2.1.0+incompatible // indirect
 	github.com/google/uuid v1.6.0 // indirect
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khicago/irr"
+)
+
+// goModule 是仓库中的一个 Go 模块, Dir 是 go.mod 所在目录, 仓库根目录为空
+type goModule struct {
+	Dir  string
+	Path string
+}
+
+// directoryGrouper 将文件路径映射到报告中的目录分组
+type directoryGrouper func(file string) string
+
+// newDirectoryGrouper 按 depth 层目录分组; modules 非空时 Go 文件按所属包的导入路径分组
+func newDirectoryGrouper(depth int, modules []goModule) directoryGrouper {
+	return func(file string) string {
+		if pkg, ok := goPackageOf(file, modules); ok {
+			return pkg
+		}
+		return getDirectoryAtDepth(file, depth)
+	}
+}
+
+// goPackageOf 返回 Go 文件所属包的导入路径; 不是 Go 文件或不在任何模块中时返回 false
+func goPackageOf(file string, modules []goModule) (string, bool) {
+	if !strings.HasSuffix(file, ".go") {
+		return "", false
+	}
+	dir := path.Dir(file)
+	if dir == "." {
+		dir = ""
+	}
+	// modules 按目录从深到浅排列, 第一个匹配的就是最近的 go.mod
+	for _, m := range modules {
+		if m.Dir == "" || dir == m.Dir || strings.HasPrefix(dir, m.Dir+"/") {
+			rel := strings.TrimPrefix(strings.TrimPrefix(dir, m.Dir), "/")
+			if rel == "" {
+				return m.Path, true
+			}
+			return m.Path + "/" + rel, true
+		}
+	}
+	return "", false
+}
+
+// loadGoModules 读取 rev 中所有 go.mod 的 module 声明, 按目录从深到浅排列
+func loadGoModules(dir, rev string) ([]goModule, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, irr.Error("invalid ref %q", rev)
+	}
+	output, err := executeGitCommandIn(dir, "ls-tree", "-r", "-z", "--name-only", rev)
+	if err != nil {
+		return nil, irr.Wrap(err, "failed to list files at %s", rev)
+	}
+	var modules []goModule
+	for _, file := range strings.Split(output, "\x00") {
+		if path.Base(file) != "go.mod" {
+			continue
+		}
+		content, err := executeGitCommandIn(dir, "show", rev+":"+file)
+		if err != nil {
+			return nil, irr.Wrap(err, "failed to read %s at %s", file, rev)
+		}
+		modulePath := parseModulePath(content)
+		if modulePath == "" {
+			continue
+		}
+		moduleDir := path.Dir(file)
+		if moduleDir == "." {
+			moduleDir = ""
+		}
+		modules = append(modules, goModule{Dir: moduleDir, Path: modulePath})
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if len(modules[i].Dir) != len(modules[j].Dir) {
+			return len(modules[i].Dir) > len(modules[j].Dir)
+		}
+		return modules[i].Dir < modules[j].Dir
+	})
+	return modules, nil
+}
+
+// parseModulePath 返回 go.mod 中 module 指令的路径, 没有时返回空
+func parseModulePath(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted
+		}
+		return fields[1]
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestGoPackageOfUsesTheNearestModule(t *testing.T) {
+	modules := []goModule{{Dir: "tools/gen", Path: "example.com/gen"}, {Dir: "", Path: "example.com/app"}}
+	for file, want := range map[string]string{
+		"main.go":                "example.com/app",
+		"svc/api/handler.go":     "example.com/app/svc/api",
+		"tools/gen/main.go":      "example.com/gen",
+		"tools/gen/cmd/gen.go":   "example.com/gen/cmd",
+		"tools/generate/main.go": "example.com/app/tools/generate",
+	} {
+		if got, ok := goPackageOf(file, modules); !ok || got != want {
+			t.Errorf("goPackageOf(%q) = %q, %v, want %q", file, got, ok, want)
+		}
+	}
+	if _, ok := goPackageOf("svc/api/README.md", modules); ok {
+		t.Error("goPackageOf(README.md) = true, want false for non-Go files")
+	}
+	if _, ok := goPackageOf("main.go", nil); ok {
+		t.Error("goPackageOf() without modules = true, want false")
+	}
+
+	group := newDirectoryGrouper(2, modules[:1])
+	if got := group("svc/api/v1/README.md"); got != "svc/api" {
+		t.Errorf("group(README.md) = %q, want svc/api at depth 2", got)
+	}
+}
+
+func TestParseModulePath(t *testing.T) {
+	for content, want := range map[string]string{
+		"module example.com/app\n\ngo 1.22\n":    "example.com/app",
+		"// comment\nmodule \"example.com/q\"\n": "example.com/q",
+		"module example.com/c // trailing\n":     "example.com/c",
+		"go 1.22\n":                              "",
+	} {
+		if got := parseModulePath(content); got != want {
+			t.Errorf("parseModulePath(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// blobLoader 根据 blob hash 读取文件内容
+type blobLoader func(blob string) ([]byte, error)
+
+// symbolChange 描述一个 Go 符号的变更
+type symbolChange struct {
+	Op   byte // '+' 新增, '-' 删除, '~' 修改
+	Kind string
+	Name string
+}
+
+func (s symbolChange) String() string {
+	return fmt.Sprintf("%c %s %s", s.Op, s.Kind, s.Name)
+}
+
+// readGitBlob 通过 git cat-file 读取 blob 内容
+func readGitBlob(blob string) ([]byte, error) {
+	return exec.Command("git", "cat-file", "blob", blob).Output()
+}
+
+// goSymbolChanges 对比 Go 文件新旧版本, 列出新增/删除/修改的函数、方法、类型和测试
+//
+// 优先读取 index 行中的 blob 做 go/ast 对比; blob 不可用时退化为扫描 diff 中的声明行
+func goSymbolChanges(f fileDiff, load blobLoader) []symbolChange {
+	if !strings.HasSuffix(f.Path(), ".go") || f.IsBinary {
+		return nil
+	}
+	isTest := strings.HasSuffix(f.Path(), "_test.go")
+
+	oldSyms, okOld := loadGoSymbols(f.OldBlob, f.IsNew, load, isTest)
+	newSyms, okNew := loadGoSymbols(f.NewBlob, f.IsDeleted, load, isTest)
+	if !okOld || !okNew {
+		return scanGoSymbolChanges(f, isTest)
+	}
+	return diffGoSymbols(oldSyms, newSyms)
+}
+
+// goSymbol 记录符号的类别和源码指纹
+type goSymbol struct {
+	kind string
+	body string
+}
+
+func loadGoSymbols(blob string, absent bool, load blobLoader, isTest bool) (map[string]goSymbol, bool) {
+	if absent {
+		// 新增或删除的文件, 对应一侧没有符号
+		return map[string]goSymbol{}, true
+	}
+	if blob == "" || load == nil {
+		return nil, false
+	}
+	src, err := load(blob)
+	if err != nil {
+		return nil, false
+	}
+	syms, err := parseGoSymbols(src, isTest)
+	if err != nil {
+		return nil, false
+	}
+	return syms, true
+}
+
+// parseGoSymbols 解析源码中的顶层声明
+func parseGoSymbols(src []byte, isTest bool) (map[string]goSymbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	syms := make(map[string]goSymbol)
+	render := func(node ast.Node) string {
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, fset, node)
+		return buf.String()
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name, kind := d.Name.Name, "func"
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = fmt.Sprintf("(%s).%s", render(d.Recv.List[0].Type), d.Name.Name)
+				kind = "method"
+			} else if isTest && isTestFuncName(d.Name.Name) {
+				kind = "test"
+			}
+			syms[name] = goSymbol{kind: kind, body: render(d)}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					syms[ts.Name.Name] = goSymbol{kind: "type", body: render(ts)}
+				}
+			}
+		}
+	}
+	return syms, nil
+}
+
+func isTestFuncName(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func diffGoSymbols(oldSyms, newSyms map[string]goSymbol) []symbolChange {
+	var changes []symbolChange
+	for name, sym := range newSyms {
+		old, ok := oldSyms[name]
+		switch {
+		case !ok:
+			changes = append(changes, symbolChange{Op: '+', Kind: sym.kind, Name: name})
+		case old.body != sym.body:
+			changes = append(changes, symbolChange{Op: '~', Kind: sym.kind, Name: name})
+		}
+	}
+	for name, sym := range oldSyms {
+		if _, ok := newSyms[name]; !ok {
+			changes = append(changes, symbolChange{Op: '-', Kind: sym.kind, Name: name})
+		}
+	}
+	sortSymbolChanges(changes)
+	return changes
+}
+
+var (
+	goFuncDeclPattern = regexp.MustCompile(`^func\s+(\([^)]*\)\s*)?([A-Za-z_]\w*)`)
+	goTypeDeclPattern = regexp.MustCompile(`^type\s+([A-Za-z_]\w*)\b`)
+)
+
+// scanGoSymbolChanges 在无法读取 blob 时, 依据 diff 中增删的声明行和 hunk 上下文推断符号变更
+func scanGoSymbolChanges(f fileDiff, isTest bool) []symbolChange {
+	added := scanGoDecls(f.AddedLines, isTest)
+	removed := scanGoDecls(f.RemovedLines, isTest)
+
+	seen := make(map[string]bool)
+	var changes []symbolChange
+	for name, kind := range added {
+		op := byte('+')
+		if _, ok := removed[name]; ok {
+			op = '~'
+		}
+		changes = append(changes, symbolChange{Op: op, Kind: kind, Name: name})
+		seen[name] = true
+	}
+	for name, kind := range removed {
+		if !seen[name] {
+			changes = append(changes, symbolChange{Op: '-', Kind: kind, Name: name})
+			seen[name] = true
+		}
+	}
+
+	// hunk 头中的函数上下文表示该函数内部有修改
+	for _, header := range f.HunkHeaders {
+		parts := strings.SplitN(header, "@@", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		for name, kind := range scanGoDecls([]string{strings.TrimSpace(parts[2])}, isTest) {
+			if !seen[name] {
+				changes = append(changes, symbolChange{Op: '~', Kind: kind, Name: name})
+				seen[name] = true
+			}
+		}
+	}
+	sortSymbolChanges(changes)
+	return changes
+}
+
+func scanGoDecls(lines []string, isTest bool) map[string]string {
+	decls := make(map[string]string)
+	for _, line := range lines {
+		if m := goFuncDeclPattern.FindStringSubmatch(line); m != nil {
+			name, kind := m[2], "func"
+			if m[1] != "" {
+				if recv := strings.Fields(strings.Trim(m[1], "() ")); len(recv) > 0 {
+					name = fmt.Sprintf("(%s).%s", recv[len(recv)-1], m[2])
+					kind = "method"
+				}
+			} else if isTest && isTestFuncName(name) {
+				kind = "test"
+			}
+			decls[name] = kind
+			continue
+		}
+		if m := goTypeDeclPattern.FindStringSubmatch(line); m != nil {
+			decls[m[1]] = "type"
+		}
+	}
+	return decls
+}
+
+func sortSymbolChanges(changes []symbolChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Op < changes[j].Op
+	})
+}
+
+// formatSymbolChanges 将符号变更格式化为紧凑的文本, 每行一个符号
+func formatSymbolChanges(changes []symbolChange) string {
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, "  "+change.String())
+	}
+	return strings.Join(lines, "\n")
+}
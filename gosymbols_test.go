@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const symbolsOldSource = `package demo
+
+type Store struct{ items []string }
+
+func (s *Store) Add(item string) { s.items = append(s.items, item) }
+
+func helper() int { return 1 }
+
+func legacy() {}
+`
+
+const symbolsNewSource = `package demo
+
+type Store struct{ items []string }
+
+type Option func(*Store)
+
+func (s *Store) Add(item string) { s.items = append(s.items, strings.TrimSpace(item)) }
+
+func helper() int { return 1 }
+`
+
+func fakeBlobLoader(blobs map[string]string) blobLoader {
+	return func(blob string) ([]byte, error) {
+		src, ok := blobs[blob]
+		if !ok {
+			return nil, errors.New("blob not found")
+		}
+		return []byte(src), nil
+	}
+}
+
+func TestGoSymbolChangesComparesBlobs(t *testing.T) {
+	f := fileDiff{OldPath: "store.go", NewPath: "store.go", OldBlob: "1111111", NewBlob: "2222222"}
+	load := fakeBlobLoader(map[string]string{"1111111": symbolsOldSource, "2222222": symbolsNewSource})
+
+	got := formatSymbolChanges(goSymbolChanges(f, load))
+	want := strings.Join([]string{
+		"  ~ method (*Store).Add",
+		"  + type Option",
+		"  - func legacy",
+	}, "\n")
+	if got != want {
+		t.Fatalf("goSymbolChanges() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGoSymbolChangesMarksTestsInNewFile(t *testing.T) {
+	f := fileDiff{OldPath: "store_test.go", NewPath: "store_test.go", NewBlob: "3333333", IsNew: true}
+	load := fakeBlobLoader(map[string]string{"3333333": "package demo\n\nfunc TestAdd(t *testing.T) {}\n\nfunc newStore() {}\n"})
+
+	got := goSymbolChanges(f, load)
+	if len(got) != 2 {
+		t.Fatalf("goSymbolChanges() = %v, want 2 changes", got)
+	}
+	if got[0].String() != "+ test TestAdd" || got[1].String() != "+ func newStore" {
+		t.Fatalf("goSymbolChanges() = %v, want [+ test TestAdd + func newStore]", got)
+	}
+}
+
+func TestGoSymbolChangesFallsBackToDiffScan(t *testing.T) {
+	diff := "diff --git a/store.go b/store.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/store.go\n" +
+		"+++ b/store.go\n" +
+		"@@ -3,6 +3,8 @@ func (s *Store) Add(item string) {\n" +
+		"+type Option func(*Store)\n" +
+		"-func legacy() {}\n" +
+		"-func helper() int { return 1 }\n" +
+		"+func helper() int { return 2 }\n"
+
+	files := parseDiff(diff)
+	if len(files) != 1 {
+		t.Fatalf("parseDiff() returned %d files, want 1", len(files))
+	}
+	got := formatSymbolChanges(goSymbolChanges(files[0], fakeBlobLoader(nil)))
+	want := strings.Join([]string{
+		"  ~ method (*Store).Add",
+		"  + type Option",
+		"  ~ func helper",
+		"  - func legacy",
+	}, "\n")
+	if got != want {
+		t.Fatalf("goSymbolChanges() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGoSymbolChangesWithoutIndexLineScansDiff(t *testing.T) {
+	diff := "diff --git a/store.go b/store.go\n" +
+		"--- a/store.go\n" +
+		"+++ b/store.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-func helper() int { return 1 }\n" +
+		"+func helper() int { return 2 }\n"
+
+	files := parseDiff(diff)
+	if len(files) != 1 {
+		t.Fatalf("parseDiff() returned %d files, want 1", len(files))
+	}
+	got := formatSymbolChanges(goSymbolChanges(files[0], fakeBlobLoader(nil)))
+	if got != "  ~ func helper" {
+		t.Fatalf("goSymbolChanges() =\n%s\nwant a modified helper, not an added file", got)
+	}
+}
+
+func TestGoSymbolChangesIgnoresNonGoFiles(t *testing.T) {
+	f := fileDiff{OldPath: "README.md", NewPath: "README.md", AddedLines: []string{"func main() {}"}}
+	if got := goSymbolChanges(f, nil); got != nil {
+		t.Fatalf("goSymbolChanges() = %v, want nil for non-Go files", got)
+	}
+}
+
+func TestParseDiffReadsFileHeaders(t *testing.T) {
+	diff := "diff --git a/old.go b/new.go\n" +
+		"similarity index 90%\n" +
+		"rename from old.go\n" +
+		"rename to new.go\n" +
+		"index abc1234..def5678 100644\n" +
+		"--- a/old.go\n" +
+		"+++ b/new.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-package old\n" +
+		"+package new\n" +
+		"diff --git a/gone.txt b/gone.txt\n" +
+		"deleted file mode 100644\n" +
+		"index abc1234..0000000\n" +
+		"--- a/gone.txt\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"--- not a header\n"
+
+	files := parseDiff(diff)
+	if len(files) != 2 {
+		t.Fatalf("parseDiff() returned %d files, want 2", len(files))
+	}
+
+	rename := files[0]
+	if !rename.IsRename || rename.Similarity != 90 || rename.OldPath != "old.go" || rename.NewPath != "new.go" {
+		t.Errorf("rename entry = %+v, want old.go -> new.go at 90%%", rename)
+	}
+	if rename.OldBlob != "abc1234" || rename.NewBlob != "def5678" {
+		t.Errorf("rename blobs = %q..%q, want abc1234..def5678", rename.OldBlob, rename.NewBlob)
+	}
+	if rename.Added != 1 || rename.Removed != 1 {
+		t.Errorf("rename line counts = +%d -%d, want +1 -1", rename.Added, rename.Removed)
+	}
+
+	deleted := files[1]
+	if !deleted.IsDeleted || deleted.Path() != "gone.txt" || deleted.NewBlob != "" {
+		t.Errorf("deleted entry = %+v, want deleted gone.txt without new blob", deleted)
+	}
+	if deleted.Removed != 1 || deleted.RemovedLines[0] != "-- not a header" {
+		t.Errorf("deleted removed lines = %q, want hunk content kept verbatim", deleted.RemovedLines)
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/khicago/irr"
+)
+
+// hotspotSchemaVersion 是 hotspots JSON 输出的版本, 何时递增见 insightSchemaVersion
+const hotspotSchemaVersion = 1
+
+const (
+	HotspotSizeLines  = "lines"
+	HotspotSizeIndent = "indent"
+
+	defaultHotspotLimit      = 20
+	defaultHotspotHalfLife   = 90
+	defaultHotspotMinAuthors = 4
+	defaultHotspotMinFixes   = 3
+
+	hotspotFlagManyAuthors = "many-authors"
+	hotspotFlagFixProne    = "fix-prone"
+)
+
+// fixSubjectPattern 匹配以修复类词开头的标题, 如 "Fixed crash"、"hotfix for prod"、"bugfix: login"
+var fixSubjectPattern = regexp.MustCompile(`(?i)^(fix|fixes|fixed|fixing|bugfix|hotfix)\b`)
+
+// hotspotOptions 汇总 hotspots 子命令的输入
+type hotspotOptions struct {
+	// Filter 中只使用 Since、Until、Ref、Paths、Exclude 和 NoMerges
+	Filter insightOptions
+	// Limit 是输出的文件数, 0 表示全部
+	Limit int
+	// Size 是文件大小的度量: lines 或 indent
+	Size string
+	// HalfLife 是变更权重减半所需的天数
+	HalfLife int
+	// MinAuthors 和 MinFixes 是标记文件的阈值
+	MinAuthors int
+	MinFixes   int
+	Format     string
+	Output     string
+}
+
+// hotspot 是一个文件的热点统计
+type hotspot struct {
+	Path string `json:"path"`
+	// Score 是 WeightedChanges × 文件大小
+	Score   float64 `json:"score"`
+	Changes int     `json:"changes"`
+	// WeightedChanges 是按时间衰减的变更次数, 越近的变更权重越高
+	WeightedChanges float64 `json:"weighted_changes"`
+	// Churn 是增加和删除的行数之和, RecentChurn 按时间衰减
+	Churn       int     `json:"churn"`
+	RecentChurn float64 `json:"recent_churn"`
+	Lines       int     `json:"lines"`
+	// Indent 是所有行的缩进层级之和, 是复杂度的近似
+	Indent  int      `json:"indent"`
+	Authors int      `json:"authors"`
+	Fixes   int      `json:"fixes"`
+	Flags   []string `json:"flags"`
+}
+
+// hotspotReport 是 hotspots 子命令的输出, Files 按风险分数从高到低排列
+type hotspotReport struct {
+	SchemaVersion int       `json:"schema_version"`
+	Filters       []string  `json:"filters"`
+	Rev           string    `json:"rev"`
+	Size          string    `json:"size"`
+	HalfLifeDays  int       `json:"half_life_days"`
+	Files         []hotspot `json:"files"`
+}
+
+// blobStat 是文件当前内容的大小
+type blobStat struct {
+	Lines  int
+	Indent int
+	Binary bool
+}
+
+// hotspots 统计仓库中的热点文件并输出
+func hotspots(opts hotspotOptions) error {
+	report, err := collectHotspots("", opts, time.Now())
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err = writeHotspots(&out, opts.Format, report); err != nil {
+		return err
+	}
+	return writeInsightOutput(opts.Output, out.Bytes())
+}
+
+// collectHotspots 在 dir 中统计全部作者的提交, 按衰减后的变更次数 × 当前大小排序
+func collectHotspots(dir string, opts hotspotOptions, now time.Time) (hotspotReport, error) {
+	if opts.Size == "" {
+		opts.Size = HotspotSizeLines
+	}
+	if opts.Size != HotspotSizeLines && opts.Size != HotspotSizeIndent {
+		return hotspotReport{}, irr.Error("unknown size %q, want lines or indent", opts.Size)
+	}
+	if opts.HalfLife <= 0 {
+		return hotspotReport{}, irr.Error("half-life must be a positive number of days, got %d", opts.HalfLife)
+	}
+	if err := checkHotspotFormat(opts.Format); err != nil {
+		return hotspotReport{}, err
+	}
+
+	commits, err := loadInsightCommits(dir, opts.Filter, nil)
+	if err != nil {
+		return hotspotReport{}, err
+	}
+	files := accumulateHotspots(commits, now, opts.HalfLife)
+
+	rev := hotspotRev(opts.Filter.Ref)
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	sizes, err := readBlobStats(dir, rev, paths)
+	if err != nil {
+		return hotspotReport{}, err
+	}
+
+	report := hotspotReport{
+		SchemaVersion: hotspotSchemaVersion,
+		Filters:       opts.Filter.describeFilters(),
+		Rev:           rev,
+		Size:          opts.Size,
+		HalfLifeDays:  opts.HalfLife,
+		Files:         make([]hotspot, 0, len(files)),
+	}
+	if report.Filters == nil {
+		report.Filters = make([]string, 0)
+	}
+	for _, path := range paths {
+		size, ok := sizes[path]
+		// 已删除的文件和二进制文件没有可比较的大小
+		if !ok || size.Binary {
+			continue
+		}
+		h := files[path].hotspot
+		h.Lines, h.Indent = size.Lines, size.Indent
+		measure := h.Lines
+		if opts.Size == HotspotSizeIndent {
+			measure = h.Indent
+		}
+		h.Score = round2(h.WeightedChanges * float64(measure))
+		h.WeightedChanges = round2(h.WeightedChanges)
+		h.RecentChurn = round2(h.RecentChurn)
+		h.Flags = make([]string, 0)
+		if opts.MinAuthors > 0 && h.Authors >= opts.MinAuthors {
+			h.Flags = append(h.Flags, hotspotFlagManyAuthors)
+		}
+		if opts.MinFixes > 0 && h.Fixes >= opts.MinFixes {
+			h.Flags = append(h.Flags, hotspotFlagFixProne)
+		}
+		report.Files = append(report.Files, h)
+	}
+
+	sort.SliceStable(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return a.Path < b.Path
+	})
+	if opts.Limit > 0 && len(report.Files) > opts.Limit {
+		report.Files = report.Files[:opts.Limit]
+	}
+	return report, nil
+}
+
+// hotspotAccumulator 汇总一个文件的变更, authors 用于去重
+type hotspotAccumulator struct {
+	hotspot
+	authors map[string]bool
+}
+
+// accumulateHotspots 按文件汇总变更次数、行数变化、作者和修复提交; 变更的权重每 halfLife 天减半
+func accumulateHotspots(commits []commitRecord, now time.Time, halfLife int) map[string]*hotspotAccumulator {
+	files := make(map[string]*hotspotAccumulator)
+	for _, c := range commits {
+		weight := decayWeight(now.Sub(c.CommitDate), halfLife)
+		fix := isFixCommit(c.Subject)
+		authors := []string{contactKey(c.AuthorName, c.AuthorEmail)}
+		for _, co := range c.CoAuthors {
+			authors = append(authors, contactKey(parseContact(co)))
+		}
+		for _, f := range c.Files {
+			acc := files[f.Path]
+			if acc == nil {
+				acc = &hotspotAccumulator{hotspot: hotspot{Path: f.Path}, authors: make(map[string]bool)}
+				files[f.Path] = acc
+			}
+			churn := f.Added + f.Removed
+			acc.Changes++
+			acc.WeightedChanges += weight
+			acc.Churn += churn
+			acc.RecentChurn += weight * float64(churn)
+			if fix {
+				acc.Fixes++
+			}
+			for _, a := range authors {
+				acc.authors[a] = true
+			}
+			acc.Authors = len(acc.authors)
+		}
+	}
+	return files
+}
+
+// contactKey 以小写邮箱区分作者, 没有邮箱时使用名字
+func contactKey(name, email string) string {
+	if email != "" {
+		return strings.ToLower(email)
+	}
+	return strings.ToLower(name)
+}
+
+// decayWeight 返回 age 之前的变更的权重, 未来的时间按 1 计算
+func decayWeight(age time.Duration, halfLife int) float64 {
+	days := age.Hours() / 24
+	if days <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, days/float64(halfLife))
+}
+
+// isFixCommit 判断提交是否为修复: Conventional Commits 类型为 fix, 或标题以 fix、bugfix、hotfix 等词开头
+//
+// 标题中间出现的 fix、bug (如 "add bug report template") 和 git 生成的合并、回滚标题都不算
+func isFixCommit(subject string) bool {
+	subject = strings.TrimSpace(subject)
+	if isGeneratedSubject(subject) {
+		return false
+	}
+	if cc, ok := parseConventionalSubject(subject); ok && cc.Type == "fix" {
+		return true
+	}
+	return fixSubjectPattern.MatchString(subject)
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// hotspotRev 返回读取文件当前大小的版本: 范围取终点, 为空时使用 HEAD
+func hotspotRev(ref string) string {
+	if i := strings.LastIndex(ref, ".."); i >= 0 {
+		ref = strings.TrimPrefix(ref[i+2:], ".")
+	}
+	if ref == "" {
+		return "HEAD"
+	}
+	return ref
+}
+
+// readBlobStats 用一次 git cat-file --batch 读取 rev 中各文件的行数和缩进, 不存在的文件不在结果中
+func readBlobStats(dir, rev string, paths []string) (map[string]blobStat, error) {
+	stats := make(map[string]blobStat, len(paths))
+	var input strings.Builder
+	queried := make([]string, 0, len(paths))
+	for _, p := range paths {
+		// --batch 按行读取, 含换行的路径无法查询
+		if strings.ContainsAny(p, "\n\r") {
+			continue
+		}
+		queried = append(queried, p)
+		input.WriteString(rev + ":" + p + "\n")
+	}
+	if len(queried) == 0 {
+		return stats, nil
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, irr.Wrap(err, "failed to read git cat-file")
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, irr.Wrap(err, "failed to run git cat-file")
+	}
+
+	r := bufio.NewReader(stdout)
+	readErr := func() error {
+		for _, p := range queried {
+			header, err := r.ReadString('\n')
+			if err != nil {
+				return irr.Wrap(err, "failed to read git cat-file output")
+			}
+			// 找到时是 "<oid> <type> <size>", 否则是 "<rev>:<path> missing"
+			fields := strings.Fields(header)
+			if len(fields) != 3 {
+				continue
+			}
+			size, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+			data := make([]byte, size+1)
+			if _, err = io.ReadFull(r, data); err != nil {
+				return irr.Wrap(err, "failed to read %s from git cat-file", p)
+			}
+			if fields[1] == "blob" {
+				stats[p] = measureBlob(data[:size])
+			}
+		}
+		return nil
+	}()
+	if readErr != nil {
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err = cmd.Wait(); err != nil {
+		return nil, irr.Wrap(err, "git cat-file failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return stats, readErr
+}
+
+// measureBlob 统计行数和缩进层级之和; 一个 tab 或四个空格算一层, 空行不计缩进
+func measureBlob(data []byte) blobStat {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return blobStat{Binary: true}
+	}
+	stat := blobStat{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		stat.Lines++
+		tabs, spaces := 0, 0
+	indent:
+		for _, b := range line {
+			switch b {
+			case '\t':
+				tabs++
+			case ' ':
+				spaces++
+			default:
+				break indent
+			}
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			stat.Indent += tabs + spaces/4
+		}
+	}
+	// 以换行结尾的文件, 最后一个空串不是一行
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		stat.Lines--
+	}
+	return stat
+}
+
+// checkHotspotFormat 校验 hotspots 的输出格式, 为空时使用 text
+func checkHotspotFormat(format string) error {
+	switch format {
+	case "", InsightFormatText, InsightFormatJSON, InsightFormatCSV, InsightFormatMarkdown:
+		return nil
+	}
+	return irr.Error("unknown format %q, want one of csv, json, markdown, text", format)
+}
+
+// writeHotspots 按 format 输出热点报告
+func writeHotspots(w io.Writer, format string, r hotspotReport) error {
+	if err := checkHotspotFormat(format); err != nil {
+		return err
+	}
+	switch format {
+	case InsightFormatJSON:
+		return writeJSON(w, r)
+	case InsightFormatCSV:
+		records := [][]string{{"rank", "path", "score", "changes", "weighted_changes", "churn", "recent_churn", "lines", "indent", "authors", "fixes", "flags"}}
+		for i, h := range r.Files {
+			records = append(records, []string{strconv.Itoa(i + 1), h.Path, formatFloat(h.Score), strconv.Itoa(h.Changes), formatFloat(h.WeightedChanges),
+				strconv.Itoa(h.Churn), formatFloat(h.RecentChurn), strconv.Itoa(h.Lines), strconv.Itoa(h.Indent), strconv.Itoa(h.Authors), strconv.Itoa(h.Fixes), strings.Join(h.Flags, " ")})
+		}
+		return writeCSV(w, records)
+	case InsightFormatMarkdown:
+		sb := strings.Builder{}
+		sb.WriteString("# Hotspots\n\n" + markdownCell(describeHotspots(r)) + "\n\n")
+		sb.WriteString("| # | File | Score | Changes | Churn | Recent churn | Lines | Indent | Authors | Fixes | Flags |\n")
+		sb.WriteString("| ---: | --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | --- |\n")
+		for i, h := range r.Files {
+			sb.WriteString(fmt.Sprintf("| %d | %s | %s | %d | %d | %s | %d | %d | %d | %d | %s |\n", i+1, markdownCell(h.Path), formatFloat(h.Score), h.Changes,
+				h.Churn, formatFloat(h.RecentChurn), h.Lines, h.Indent, h.Authors, h.Fixes, strings.Join(h.Flags, ", ")))
+		}
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("\n# Hotspots\n\n" + describeHotspots(r) + "\n\n")
+	if len(r.Files) == 0 {
+		sb.WriteString("No files changed.\n")
+	} else {
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "#\tFILE\tSCORE\tCHANGES\tCHURN\tRECENT CHURN\tLINES\tINDENT\tAUTHORS\tFIXES\tFLAGS")
+		for i, h := range r.Files {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%s\t%d\t%d\t%d\t%d\t%s\n", i+1, h.Path, formatFloat(h.Score), h.Changes,
+				h.Churn, formatFloat(h.RecentChurn), h.Lines, h.Indent, h.Authors, h.Fixes, strings.Join(h.Flags, ", "))
+		}
+		_ = tw.Flush()
+	}
+	_, err := fmt.Fprintln(w, sb.String())
+	return err
+}
+
+// describeHotspots 说明排序方式和过滤条件
+func describeHotspots(r hotspotReport) string {
+	s := fmt.Sprintf("Ranked by recent changes × %s at %s; a change counts half after %d days.", r.Size, r.Rev, r.HalfLifeDays)
+	if len(r.Filters) > 0 {
+		s += " Filtered by " + strings.Join(r.Filters, "; ") + "."
+	}
+	return s
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
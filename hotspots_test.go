@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectHotspotsRanksRecentLargeFilesAndFlagsRisk(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	ten := strings.Repeat("line\n", 10)
+	nested := "func f() {\n\tif x {\n\t\treturn\n\t}\n}\n"
+	dir := newScriptRepo(t, scriptCommit("Bob <bob@example.com>", now.AddDate(-1, 0, 0), "add big file",
+		scriptFile("big.go", strings.Repeat("line\n", 100))+scriptFile("img.bin", "\x00\x01")+scriptFile("gone.go", "x\n"))+
+		scriptCommit("Ann <ann@example.com>", now.AddDate(0, 0, -3), "fix: crash", scriptFile("hot.go", ten)+"D gone.go\n")+
+		scriptCommit("Bob <bob@example.com>", now.AddDate(0, 0, -2), "fix(api): nil map", scriptFile("hot.go", ten+nested))+
+		scriptCommit("Cid <cid@example.com>", now.AddDate(0, 0, -1), "feat: faster\n\nCo-authored-by: Dee <dee@example.com>", scriptFile("hot.go", ten+nested+"// done\n")))
+
+	report, err := collectHotspots(dir, hotspotOptions{HalfLife: 90, MinAuthors: 4, MinFixes: 2}, now)
+	if err != nil {
+		t.Fatalf("collectHotspots() error = %v", err)
+	}
+	if len(report.Files) != 2 || report.Files[0].Path != "hot.go" || report.Files[1].Path != "big.go" {
+		t.Fatalf("hotspots = %+v, want hot.go ranked above the old big.go, without deleted or binary files", report.Files)
+	}
+	hot := report.Files[0]
+	if hot.Changes != 3 || hot.Lines != 16 || hot.Indent != 4 || hot.Authors != 4 || hot.Fixes != 2 {
+		t.Errorf("hot.go = %+v, want 3 changes, 16 lines, indent 4, 4 authors and 2 fixes", hot)
+	}
+	if strings.Join(hot.Flags, ",") != "many-authors,fix-prone" {
+		t.Errorf("hot.go flags = %v, want many-authors and fix-prone", hot.Flags)
+	}
+	if big := report.Files[1]; big.Flags == nil || len(big.Flags) != 0 || big.WeightedChanges > 0.1 {
+		t.Errorf("big.go = %+v, want a small weight for a year-old change and no flags", big)
+	}
+	if math.Abs(hot.Score-hot.WeightedChanges*16) > 0.1 {
+		t.Errorf("hot.go score = %v, want weighted changes × lines", hot.Score)
+	}
+
+	report, err = collectHotspots(dir, hotspotOptions{HalfLife: 90, Size: HotspotSizeIndent, Limit: 1}, now)
+	if err != nil {
+		t.Fatalf("collectHotspots(indent) error = %v", err)
+	}
+	if len(report.Files) != 1 || report.Files[0].Path != "hot.go" || report.Size != HotspotSizeIndent {
+		t.Errorf("indent hotspots = %+v, want only hot.go, since big.go has no indentation", report.Files)
+	}
+}
+
+func TestCollectHotspotsRejectsBadOptions(t *testing.T) {
+	for _, opts := range []hotspotOptions{{HalfLife: 0}, {HalfLife: 90, Size: "bytes"}, {HalfLife: 90, Format: "html"}} {
+		if _, err := collectHotspots(t.TempDir(), opts, time.Now()); err == nil {
+			t.Errorf("collectHotspots(%+v) error = nil, want error", opts)
+		}
+	}
+}
+
+func TestHotspotHelpers(t *testing.T) {
+	for subject, want := range map[string]bool{
+		"fix: crash": true, "fix(api): nil": true, "Fixes #12": true, "hotfix for prod": true, "bugfix: login": true,
+		"prefix handling": false, "debug logging": false, "feat: add": false, "Bug in parser": false,
+		"add bug report template": false, "docs: explain the fix workflow": false,
+		"Merge pull request #12 from x/fix-login": false, `Revert "fix: crash"`: false,
+	} {
+		if got := isFixCommit(subject); got != want {
+			t.Errorf("isFixCommit(%q) = %v, want %v", subject, got, want)
+		}
+	}
+
+	if got := decayWeight(90*24*time.Hour, 90); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("decayWeight(90 days) = %v, want 0.5", got)
+	}
+	if got := decayWeight(-time.Hour, 90); got != 1 {
+		t.Errorf("decayWeight(future) = %v, want 1", got)
+	}
+
+	for ref, want := range map[string]string{"": "HEAD", "main": "main", "v1.2..v1.3": "v1.3", "v1...main": "main", "v1..": "HEAD"} {
+		if got := hotspotRev(ref); got != want {
+			t.Errorf("hotspotRev(%q) = %q, want %q", ref, got, want)
+		}
+	}
+
+	if got := measureBlob([]byte("a\n\n    b\n\t\tc")); got != (blobStat{Lines: 4, Indent: 3}) {
+		t.Errorf("measureBlob() = %+v, want 4 lines and indent 3", got)
+	}
+	if got := measureBlob([]byte("a\x00b")); !got.Binary {
+		t.Errorf("measureBlob(binary) = %+v, want binary", got)
+	}
+}
+
+func TestWriteHotspotsFormats(t *testing.T) {
+	report := hotspotReport{SchemaVersion: hotspotSchemaVersion, Filters: []string{}, Rev: "HEAD", Size: HotspotSizeLines, HalfLifeDays: 90,
+		Files: []hotspot{{Path: "a|b.go", Score: 12.5, Changes: 2, WeightedChanges: 1.25, Lines: 10, Authors: 4, Flags: []string{hotspotFlagManyAuthors}}}}
+
+	var sb strings.Builder
+	if err := writeHotspots(&sb, InsightFormatJSON, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded hotspotReport
+	if err := json.Unmarshal([]byte(sb.String()), &decoded); err != nil || decoded.Files[0].Score != 12.5 || decoded.HalfLifeDays != 90 {
+		t.Errorf("json = %s, %v", sb.String(), err)
+	}
+
+	sb.Reset()
+	if err := writeHotspots(&sb, InsightFormatCSV, report); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(sb.String())).ReadAll()
+	if err != nil || len(records) != 2 || records[1][1] != "a|b.go" || records[1][11] != "many-authors" {
+		t.Errorf("csv = %q, %v", records, err)
+	}
+
+	sb.Reset()
+	if err := writeHotspots(&sb, InsightFormatMarkdown, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `| 1 | a\|b.go | 12.5 |`) {
+		t.Errorf("markdown = %s", sb.String())
+	}
+
+	sb.Reset()
+	if err := writeHotspots(&sb, "", report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "Ranked by recent changes × lines at HEAD; a change counts half after 90 days.") {
+		t.Errorf("text = %s", sb.String())
+	}
+}
@@ -3,27 +3,158 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"sort"
-	"strconv"
 	"strings"
-	"time"
+
+	"github.com/khicago/irr"
 )
 
 type (
 	gitStatisticGroup map[string]int
+	// fileSetGroup 记录每个分组中出现过的不同文件
+	fileSetGroup map[string]map[string]struct{}
 )
 
+func (g fileSetGroup) add(name, file string) {
+	if g[name] == nil {
+		g[name] = make(map[string]struct{})
+	}
+	g[name][file] = struct{}{}
+}
+
+// insightOptions 汇总 insight 子命令的输入, 过滤条件作用于报告的每个部分
+type insightOptions struct {
+	// Committers 是名字、邮箱、/正则/ 或 identities 中的键, 任一匹配即计入
+	Committers []string
+	// Identities 来自配置文件, 将一个键展开为同一个人的多个身份
+	Identities map[string][]string
+	// ConfigPath 是配置文件路径, 为空时使用默认路径
+	ConfigPath string
+	// Since 和 Until 直接传给 git log, 支持 git 能识别的任意日期格式
+	Since string
+	Until string
+	// Ref 是分支、标签或 v1.2..v1.3 这样的范围, 为空时使用 HEAD
+	Ref string
+	// Paths 是 pathspec, 只统计这些路径
+	Paths []string
+	// NoMerges 排除合并提交
+	NoMerges bool
+	// Branches 扫描全部本地分支和 HEAD, 而不只是 HEAD
+	Branches bool
+	// Exclude 是排除的 glob, * 不匹配 /, ** 匹配任意层目录
+	Exclude []string
+
+	// TimeZone 是活动热力图使用的时区, 为空时使用每个作者自己的时区
+	TimeZone string
+	// WorkHours 是工作日的工作时间, 如 9-18, 用于统计工作时间外的提交
+	WorkHours string
+
+	// Bucket 是提交习惯的时间段粒度: day、week 或 month, 为空时按周
+	Bucket string
+
+	// DirDepth 是目录统计的层数, 至少为 1, 为 1 时只看顶层目录
+	DirDepth int
+	// GoPackages 将 Go 文件按 go.mod 推出的包导入路径分组, 其他文件仍按 DirDepth 分组
+	GoPackages bool
+
+	// ReworkDays 是返工窗口的天数, 为 0 时使用 DefaultReworkDays
+	ReworkDays int
+
+	// Format 是输出格式, 为空时使用 text
+	Format string
+	// Output 是输出文件, 为空时写到标准输出
+	Output string
+
+	// All 为每位作者生成一行团队报告, 不需要 Committers
+	All bool
+	// TeamPath 是团队成员文件, 只为其中的成员生成团队报告
+	TeamPath string
+	// Sort 是团队报告的排序列, 为空时按提交数
+	Sort string
+}
+
+// gitLogArgs 将过滤条件转换为 git log 参数
+func (o insightOptions) gitLogArgs() ([]string, error) {
+	var args []string
+	if o.Since != "" {
+		args = append(args, "--since="+o.Since)
+	}
+	if o.Until != "" {
+		args = append(args, "--until="+o.Until)
+	}
+	if o.NoMerges {
+		args = append(args, "--no-merges")
+	}
+	if o.Branches {
+		args = append(args, "--branches", "HEAD")
+	}
+	if o.Ref != "" {
+		if strings.HasPrefix(o.Ref, "-") {
+			return nil, irr.Error("invalid ref %q", o.Ref)
+		}
+		args = append(args, o.Ref)
+	}
+
+	pathspecs := append([]string(nil), o.Paths...)
+	for _, pattern := range o.Exclude {
+		pathspecs = append(pathspecs, ":(exclude,glob)"+pattern)
+	}
+	if len(pathspecs) > 0 {
+		args = append(append(args, "--"), pathspecs...)
+	}
+	return args, nil
+}
+
+// describeFilters 返回报告中展示的过滤条件, 没有过滤时为空
+func (o insightOptions) describeFilters() []string {
+	var filters []string
+	if o.Ref != "" {
+		filters = append(filters, "ref "+o.Ref)
+	}
+	if o.Since != "" {
+		filters = append(filters, "since "+o.Since)
+	}
+	if o.Until != "" {
+		filters = append(filters, "until "+o.Until)
+	}
+	if len(o.Paths) > 0 {
+		filters = append(filters, "paths "+strings.Join(o.Paths, ", "))
+	}
+	if len(o.Exclude) > 0 {
+		filters = append(filters, "excluding "+strings.Join(o.Exclude, ", "))
+	}
+	if o.NoMerges {
+		filters = append(filters, "no merges")
+	}
+	if o.Branches {
+		filters = append(filters, "all local branches")
+	}
+	return filters
+}
+
+// UserStats 是单个用户的原始聚合, insightReport 由它派生
 type UserStats struct {
 	TotalAdded, TotalRemoved, TotalCommits          int
 	FileTypeChanges, FileTypeAdded, FileTypeRemoved gitStatisticGroup
 	FileChanges, FileAdded, FileRemoved             gitStatisticGroup
 	DirChanges, DirAdded, DirRemoved                gitStatisticGroup
+	// FileTypeFiles 和 DirFiles 是各分组中不同文件的集合, Changes 统计的是文件的变更次数
+	FileTypeFiles, DirFiles fileSetGroup
+	// BinaryChanges 是二进制文件的变更次数, 它们没有行数
+	BinaryChanges int
 }
 
 // executeGitCommand 执行 Git 命令并返回输出
 func executeGitCommand(args ...string) (string, error) {
+	return executeGitCommandIn("", args...)
+}
+
+// executeGitCommandIn 在 dir 中执行 Git 命令, dir 为空时使用当前目录
+func executeGitCommandIn(dir string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	err := cmd.Run()
@@ -33,49 +164,91 @@ func executeGitCommand(args ...string) (string, error) {
 	return out.String(), nil
 }
 
-// getUserCommits 获取指定用户的提交记录
-func getUserCommits(user string) ([]string, error) {
-	logOutput, err := executeGitCommand("log", "--author="+user, "--pretty=format:%H %an %cd %s", "--date=short")
+// getUserCommits 一次读取符合过滤条件的全部提交及其 numstat, 各统计项都由其派生
+//
+// 作者按 .mailmap 映射后精确匹配; Co-authored-by 中的共同作者同样计入
+func getUserCommits(dir string, opts insightOptions) ([]commitRecord, error) {
+	matcher, err := newAuthorMatcher(opts.Committers, opts.Identities)
 	if err != nil {
 		return nil, err
 	}
+	return loadInsightCommits(dir, opts, matcher.matchCommit)
+}
 
-	var userCommits []string
-	commits := strings.Split(logOutput, "\n")
-	for _, commit := range commits {
-		if strings.Contains(commit, user) {
-			userCommits = append(userCommits, commit)
+// loadInsightCommits 读取符合过滤条件且满足 keep 的提交, 共同作者被映射为 .mailmap 中的规范身份
+//
+// keep 为 nil 时保留全部提交. 文件路径跟随重命名, 统计归到文件现在的路径上;
+// 所有提交中的重命名都会被跟随, 包括 keep 之外的作者所做的重命名
+func loadInsightCommits(dir string, opts insightOptions, keep func(commitRecord) bool) ([]commitRecord, error) {
+	args, err := opts.gitLogArgs()
+	if err != nil {
+		return nil, err
+	}
+	if keep == nil {
+		keep = func(commitRecord) bool { return true }
+	}
+
+	// 不满足 keep 但有共同作者的提交暂时保留, 待共同作者经过 .mailmap 映射后再判断
+	var (
+		candidates []commitRecord
+		coAuthors  []string
+		seen       = make(map[string]bool)
+		renames    = make(renameTracker)
+	)
+	err = streamGitLog(dir, args, func(c commitRecord) error {
+		c = renames.follow(c)
+		renames.observe(c)
+		if keep(c) || len(c.CoAuthors) > 0 {
+			candidates = append(candidates, c)
+		}
+		for _, co := range c.CoAuthors {
+			if _, email := parseContact(co); email != "" && !seen[co] {
+				seen[co] = true
+				coAuthors = append(coAuthors, co)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return userCommits, nil
-}
 
-// getFileExtension 获取文件扩展名
-func getFileExtension(file string) string {
-	parts := strings.Split(file, ".")
-	if len(parts) > 1 {
-		return parts[len(parts)-1]
+	mapped := mailmapContacts(dir, coAuthors)
+	commits := candidates[:0]
+	for _, c := range candidates {
+		for i, co := range c.CoAuthors {
+			if m, ok := mapped[co]; ok {
+				c.CoAuthors[i] = m
+			}
+		}
+		if keep(c) {
+			commits = append(commits, c)
+		}
 	}
-	return "unknown"
+	return commits, nil
 }
 
 // getDirectory 获取文件目录
 func getDirectory(file string) string {
+	return getDirectoryAtDepth(file, 1)
+}
+
+// getDirectoryAtDepth 获取文件所在目录的前 depth 层, 文件所在目录更浅时返回该目录; 根目录下的文件返回 root
+func getDirectoryAtDepth(file string, depth int) string {
 	parts := strings.Split(file, "/")
-	if len(parts) > 1 {
-		return parts[0]
+	if len(parts) == 1 || depth < 1 {
+		return "root"
 	}
-	return "root"
+	return strings.Join(parts[:min(depth, len(parts)-1)], "/")
 }
 
-// getUserStats 获取指定用户的代码量统计
-func getUserStats(user string) (UserStats, error) {
-	logOutput, err := executeGitCommand("log", "--author="+user, "--pretty=tformat:", "--numstat")
-	if err != nil {
-		return UserStats{}, err
+// getUserStats 获取指定用户的代码量统计, 文件按语言分类, 按 group 分目录; group 为 nil 时只看顶层目录
+func getUserStats(commits []commitRecord, group directoryGrouper) UserStats {
+	if group == nil {
+		group = getDirectory
 	}
-
 	stats := UserStats{
+		TotalCommits:    len(commits),
 		FileTypeChanges: make(gitStatisticGroup),
 		FileTypeAdded:   make(gitStatisticGroup),
 		FileTypeRemoved: make(gitStatisticGroup),
@@ -85,210 +258,116 @@ func getUserStats(user string) (UserStats, error) {
 		DirChanges:      make(gitStatisticGroup),
 		DirAdded:        make(gitStatisticGroup),
 		DirRemoved:      make(gitStatisticGroup),
+		FileTypeFiles:   make(fileSetGroup),
+		DirFiles:        make(fileSetGroup),
 	}
 
-	lines := strings.Split(logOutput, "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		stats.TotalCommits++
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue
-		}
-		added, _ := strconv.Atoi(fields[0])
-		removed, _ := strconv.Atoi(fields[1])
-		file := fields[2]
-		ext := getFileExtension(file)
-		dir := getDirectory(file)
-
-		stats.TotalAdded += added
-		stats.TotalRemoved += removed
-		stats.FileTypeChanges[ext]++
-		stats.FileTypeAdded[ext] += added
-		stats.FileTypeRemoved[ext] += removed
-		stats.FileChanges[file]++
-		stats.FileAdded[file] += added
-		stats.FileRemoved[file] += removed
-		stats.DirChanges[dir]++
-		stats.DirAdded[dir] += added
-		stats.DirRemoved[dir] += removed
-	}
-	return stats, nil
-}
-
-// getCommitChanges 获取指定提交的变更行数
-func getCommitChanges(commitHash string) (int, int, error) {
-	logOutput, err := executeGitCommand("show", "--numstat", "--pretty=format:", commitHash)
-	if err != nil {
-		return 0, 0, err
-	}
-
-	var added, removed int
-	lines := strings.Split(logOutput, "\n")
-	for _, line := range lines {
-		stats := strings.Fields(line)
-		if len(stats) < 3 {
-			continue
-		}
-		add, _ := strconv.Atoi(stats[0])
-		rem, _ := strconv.Atoi(stats[1])
-		added += add
-		removed += rem
-	}
-	return added, removed, nil
-}
-
-// getUserCommitHabits 获取指定用户的提交习惯
-func getUserCommitHabits(user string) (map[string][]string, error) {
-	logOutput, err := executeGitCommand("log", "--author="+user, "--pretty=format:%H %cd %s", "--date=short")
-	if err != nil {
-		return nil, err
-	}
+	for _, commit := range commits {
+		for _, f := range commit.Files {
+			file := f.Path
+			lang := languageOf(file)
+			dir := group(file)
 
-	commitHabits := make(map[string][]string)
-	lines := strings.Split(logOutput, "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, " ", 3)
-		if len(parts) < 3 {
-			continue
-		}
-		date, err := time.Parse("2006-01-02", parts[1])
-		if err != nil {
-			continue
+			if f.Binary {
+				stats.BinaryChanges++
+			}
+			stats.TotalAdded += f.Added
+			stats.TotalRemoved += f.Removed
+			stats.FileTypeChanges[lang]++
+			stats.FileTypeAdded[lang] += f.Added
+			stats.FileTypeRemoved[lang] += f.Removed
+			stats.FileTypeFiles.add(lang, file)
+			stats.FileChanges[file]++
+			stats.FileAdded[file] += f.Added
+			stats.FileRemoved[file] += f.Removed
+			stats.DirChanges[dir]++
+			stats.DirAdded[dir] += f.Added
+			stats.DirRemoved[dir] += f.Removed
+			stats.DirFiles.add(dir, file)
 		}
-		week := date.Format("2006-W01")
-		commitHabits[week] = append(commitHabits[week], line)
 	}
-	return commitHabits, nil
+	return stats
 }
 
 // getTopCommits 获取指定用户代码量最大的前 10 次提交
-func getTopCommits(user string) ([]string, error) {
-	logOutput, err := executeGitCommand("log", "--author="+user, "--pretty=format:%H %cd %s", "--date=short")
-	if err != nil {
-		return nil, err
-	}
-
-	type commitInfo struct {
-		hash    string
-		date    string
-		message string
-		added   int
-		removed int
-	}
-
-	var commits []commitInfo
-	lines := strings.Split(logOutput, "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, " ", 3)
-		if len(parts) < 3 {
-			continue
-		}
-		hash := parts[0]
-		date := parts[1]
-		message := parts[2]
-		added, removed, err := getCommitChanges(hash)
-		if err != nil {
-			return nil, err
-		}
-		commits = append(commits, commitInfo{hash, date, message, added, removed})
-	}
+func getTopCommits(commits []commitRecord) []commitSummary {
+	sorted := append([]commitRecord(nil), commits...)
 
 	// 按变更行数排序
-	sort.Slice(commits, func(i, j int) bool {
-		return (commits[i].added + commits[i].removed) > (commits[j].added + commits[j].removed)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return (sorted[i].Added() + sorted[i].Removed()) > (sorted[j].Added() + sorted[j].Removed())
 	})
 
 	// 取前 10 个提交
-	topCommits := commits
-	if len(commits) > 10 {
-		topCommits = commits[:10]
+	topCommits := sorted
+	if len(sorted) > 10 {
+		topCommits = sorted[:10]
 	}
 
-	var result []string
+	result := make([]commitSummary, 0, len(topCommits))
 	for _, commit := range topCommits {
-		result = append(result, fmt.Sprintf("%s %s [add:%d rem:%d] %s", commit.hash[:7], commit.date, commit.added, commit.removed, commit.message))
+		result = append(result, summarizeCommit(commit))
 	}
-	return result, nil
+	return result
 }
 
 // getTopFiles 获取变更最多的前 10 个文件
-func getTopFiles(fileChanges, fileAdded, fileRemoved gitStatisticGroup) []string {
-	type fileInfo struct {
-		file    string
-		changes int
-		added   int
-		removed int
-	}
-
-	var files []fileInfo
-	for file, changes := range fileChanges {
-		files = append(files, fileInfo{file, changes, fileAdded[file], fileRemoved[file]})
-	}
-
-	// 按变更次数排序
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].changes > files[j].changes
-	})
-
-	// 取前 10 个文件
-	topFiles := files
-	if len(files) > 10 {
-		topFiles = files[:10]
-	}
-
-	var result []string
-	for _, file := range topFiles {
-		result = append(result, fmt.Sprintf("%s [changes:%d add:%d rem:%d]", file.file, file.changes, file.added, file.removed))
-	}
-	return result
+func getTopFiles(fileChanges, fileAdded, fileRemoved gitStatisticGroup) []statEntry {
+	return topStatEntries(fileChanges, fileAdded, fileRemoved, nil, 10)
 }
 
 // getTopDirectories 获取变更最多的前三个目录
-func getTopDirectories(dirChanges gitStatisticGroup, dirAdded gitStatisticGroup, dirRemoved gitStatisticGroup) []string {
-	type dirInfo struct {
-		dir     string
-		changes int
-		added   int
-		removed int
-	}
+func getTopDirectories(dirChanges gitStatisticGroup, dirAdded gitStatisticGroup, dirRemoved gitStatisticGroup, dirFiles fileSetGroup) []statEntry {
+	return topStatEntries(dirChanges, dirAdded, dirRemoved, dirFiles, 3)
+}
 
-	var dirs []dirInfo
-	for dir, changes := range dirChanges {
-		dirs = append(dirs, dirInfo{dir, changes, dirAdded[dir], dirRemoved[dir]})
+// topStatEntries 按变更次数降序返回前 n 项, n <= 0 时返回全部; 次数相同时按名字排序
+//
+// files 为 nil 时每项本身就是一个文件
+func topStatEntries(changes, added, removed gitStatisticGroup, files fileSetGroup, n int) []statEntry {
+	entries := make([]statEntry, 0, len(changes))
+	for name, count := range changes {
+		entry := statEntry{Name: name, Files: 1, Changes: count, Added: added[name], Removed: removed[name]}
+		if files != nil {
+			entry.Files = len(files[name])
+		}
+		entries = append(entries, entry)
 	}
 
 	// 按变更次数排序
-	sort.Slice(dirs, func(i, j int) bool {
-		return dirs[i].changes > dirs[j].changes
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Changes != entries[j].Changes {
+			return entries[i].Changes > entries[j].Changes
+		}
+		return entries[i].Name < entries[j].Name
 	})
 
-	// 取前三个目录
-	topDirs := dirs
-	if len(dirs) > 3 {
-		topDirs = dirs[:3]
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
 	}
+	return entries
+}
 
-	var result []string
-	for _, dir := range topDirs {
-		result = append(result, fmt.Sprintf("%s [changes:%d add:%d rem:%d]", dir.dir, dir.changes, dir.added, dir.removed))
+// insightDirectoryGrouper 按 --dir-depth 和 --go-packages 决定目录统计的分组方式, Go 模块从扫描的版本中读取
+func insightDirectoryGrouper(dir string, opts insightOptions) (directoryGrouper, error) {
+	if opts.DirDepth < 1 {
+		return nil, irr.Error("dir depth must be at least 1, got %d", opts.DirDepth)
 	}
-	return result
+	var modules []goModule
+	if opts.GoPackages {
+		var err error
+		if modules, err = loadGoModules(dir, hotspotRev(opts.Ref)); err != nil {
+			return nil, err
+		}
+	}
+	return newDirectoryGrouper(opts.DirDepth, modules), nil
 }
 
 // getMainBranchCommits 获取主干分支的提交记录
-func getMainBranchCommits(user string) ([]string, error) {
-	logOutput, err := executeGitCommand("log", "main", "--author="+user, "--pretty=format:%H")
+func getMainBranchCommits(dir string) ([]string, error) {
+	logOutput, err := executeGitCommandIn(dir, "log", "main", "--pretty=format:%H")
 	if err != nil {
-		logOutput, err = executeGitCommand("log", "master", "--author="+user, "--pretty=format:%H")
+		logOutput, err = executeGitCommandIn(dir, "log", "master", "--pretty=format:%H")
 		if err != nil {
 			return nil, err
 		}
@@ -298,103 +377,133 @@ func getMainBranchCommits(user string) ([]string, error) {
 	return commits, nil
 }
 
+// getMainBranchSet 返回主干分支上提交的集合, 无法读取时在 stderr 警告并返回空集合
+func getMainBranchSet(dir string) map[string]struct{} {
+	mainBranchCommits, err := getMainBranchCommits(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: error getting main branch commits: %v\n", err)
+	}
+	mainSet := make(map[string]struct{}, len(mainBranchCommits))
+	for _, commit := range mainBranchCommits {
+		mainSet[commit] = struct{}{}
+	}
+	return mainSet
+}
+
 // insight 显示用户的提交记录和状态信息
-func insight(committer string) error {
-	// 获取用户的提交记录
-	commits, err := getUserCommits(committer)
+func insight(opts insightOptions) error {
+	if opts.All && opts.TeamPath != "" {
+		return irr.Error("--all and --team cannot be used together")
+	}
+	if (opts.All || opts.TeamPath != "") && len(opts.Committers) > 0 {
+		return irr.Error("--committer cannot be combined with --all or --team")
+	}
+	formatter, err := newInsightFormatter(opts.Format, opts.Output == "" && shouldColor(os.Stdout))
 	if err != nil {
 		return err
 	}
 
-	// 获取用户的代码量统计
-	stats, err := getUserStats(committer)
+	conf, err := loadConfig(opts.ConfigPath)
 	if err != nil {
 		return err
 	}
+	opts.Identities = conf.Identities
 
-	// 获取用户的提交习惯
-	commitHabits, err := getUserCommitHabits(committer)
+	var out bytes.Buffer
+	if opts.All || opts.TeamPath != "" {
+		report, err := collectTeamInsight("", opts)
+		if err != nil {
+			return err
+		}
+		err = formatter.writeTeam(&out, report)
+	} else {
+		report, err := collectInsight("", opts)
+		if err != nil {
+			return err
+		}
+		err = formatter.writeUser(&out, report)
+	}
 	if err != nil {
 		return err
 	}
+	return writeInsightOutput(opts.Output, out.Bytes())
+}
 
-	// 获取用户代码量最大的前 10 次提交
-	topCommits, err := getTopCommits(committer)
-	if err != nil {
+// writeInsightOutput 将渲染好的报告写到 path, path 为空时写到标准输出; 渲染失败时不会留下半个文件
+func writeInsightOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
 		return err
 	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return irr.Wrap(err, "failed to write insight report to %s", path)
+	}
+	return nil
+}
 
-	// 获取变更最多的前 10 个文件
-	topFiles := getTopFiles(stats.FileChanges, stats.FileAdded, stats.FileRemoved)
+// collectInsight 在 dir 中统计用户的提交
+func collectInsight(dir string, opts insightOptions) (insightReport, error) {
+	bucket, err := checkBucket(opts.Bucket)
+	if err != nil {
+		return insightReport{}, err
+	}
+	clock, err := newActivityClock(opts.TimeZone, opts.WorkHours)
+	if err != nil {
+		return insightReport{}, err
+	}
 
-	// 获取变更最多的前三个目录
-	topDirs := getTopDirectories(stats.DirChanges, stats.DirAdded, stats.DirRemoved)
+	group, err := insightDirectoryGrouper(dir, opts)
+	if err != nil {
+		return insightReport{}, err
+	}
+	if opts.ReworkDays < 0 {
+		return insightReport{}, irr.Error("rework days must not be negative, got %d", opts.ReworkDays)
+	}
+	if opts.ReworkDays == 0 {
+		opts.ReworkDays = DefaultReworkDays
+	}
 
-	// 获取主干分支的提交记录
-	mainBranchCommits, err := getMainBranchCommits(committer)
+	// 获取用户的提交记录
+	commits, err := getUserCommits(dir, opts)
 	if err != nil {
-		fmt.Printf("Warning: error getting main branch commits: %v\n", err)
-		// return err
+		return insightReport{}, err
 	}
-	mainBranchCommitsSet := make(map[string]struct{})
-	for _, commit := range mainBranchCommits {
-		mainBranchCommitsSet[commit] = struct{}{}
+
+	// 获取用户的代码量统计
+	stats := getUserStats(commits, group)
+
+	// 获取主干分支的提交记录
+	mainSet := getMainBranchSet(dir)
+
+	report := insightReport{
+		SchemaVersion:  insightSchemaVersion,
+		Committers:     opts.Committers,
+		Filters:        opts.describeFilters(),
+		Commits:        len(commits),
+		Added:          stats.TotalAdded,
+		Removed:        stats.TotalRemoved,
+		BinaryChanges:  stats.BinaryChanges,
+		FileTypes:      topStatEntries(stats.FileTypeChanges, stats.FileTypeAdded, stats.FileTypeRemoved, stats.FileTypeFiles, 0),
+		TopFiles:       getTopFiles(stats.FileChanges, stats.FileAdded, stats.FileRemoved),
+		TopDirectories: getTopDirectories(stats.DirChanges, stats.DirAdded, stats.DirRemoved, stats.DirFiles),
+		TopCommits:     getTopCommits(commits),
+		Bucket:         bucket,
+		Timeline:       buildTimeline(commits, bucket),
+		Heatmap:        getActivityHeatmap(commits, clock),
+		Activity:       getActivitySummary(commits, clock),
+		Conventional:   getConventionalSummary(commits, opts.ReworkDays),
+	}
+	if report.Filters == nil {
+		report.Filters = make([]string, 0)
 	}
 
 	// 区分在主干和不在主干的提交
-	var mainCommits, nonMainCommits []string
 	for _, commit := range commits {
-		commitHash := strings.Fields(commit)[0]
-		if _, exists := mainBranchCommitsSet[commitHash]; exists {
-			mainCommits = append(mainCommits, commit)
+		if _, exists := mainSet[commit.Hash]; exists {
+			report.MainCommits++
 		} else {
-			nonMainCommits = append(nonMainCommits, commit)
-		}
-	}
-
-	// 输出统计结果和提交记录
-	sb := strings.Builder{}
-	sb.WriteString("\n\033[1;34m# User status \033[0m\n")
-	sb.WriteString("\n\033[1;34m## Overall \033[0m\n\n")
-	sb.WriteString(fmt.Sprintf("User %s has made \033[1;32m%d\033[0m commits\n\n", committer, len(commits)))
-	sb.WriteString(fmt.Sprintf("- Main branch commits: \033[1;32m%d\033[0m\n", len(mainCommits)))
-	sb.WriteString(fmt.Sprintf("- Non-main branch commits: \033[1;31m%d\033[0m\n", len(nonMainCommits)))
-	sb.WriteString(fmt.Sprintf("- Total lines added: \033[1;32m%d\033[0m\n", stats.TotalAdded))
-	sb.WriteString(fmt.Sprintf("- Total lines removed: \033[1;31m%d\033[0m\n", stats.TotalRemoved))
-	sb.WriteString("\n\033[1;34m## File changes by type \033[0m\n\n")
-	for ext, count := range stats.FileTypeChanges {
-		sb.WriteString(fmt.Sprintf("- *.%s: %d files [add:%d rem:%d]\n", ext, count, stats.FileTypeAdded[ext], stats.FileTypeRemoved[ext]))
-	}
-	sb.WriteString("\n\033[1;34m## Top files \033[0m\n\n")
-	for _, file := range topFiles {
-		sb.WriteString(fmt.Sprintf("- %s\n", file))
-	}
-	sb.WriteString("\n\033[1;34m## Top directories \033[0m\n\n")
-	for _, dir := range topDirs {
-		sb.WriteString(fmt.Sprintf("- %s\n", dir))
-	}
-	sb.WriteString("\n\033[1;34m## Top commits \033[0m\n\n")
-	for _, commit := range topCommits {
-		sb.WriteString(fmt.Sprintf("- %s\n", commit))
-	}
-	sb.WriteString("\n\033[1;34m## Commit habits (week-wise) \033[0m\n\n")
-	for week, commits := range commitHabits {
-		sb.WriteString(fmt.Sprintf("%s: %d commits\n", week, len(commits)))
-		for _, commit := range commits {
-			parts := strings.Fields(commit)
-			if len(parts) < 3 {
-				continue
-			}
-			hash := parts[0][:7]
-			date := parts[1]
-			message := strings.Join(parts[2:], " ")
-			added, removed, err := getCommitChanges(parts[0])
-			if err != nil {
-				return err
-			}
-			sb.WriteString(fmt.Sprintf("\t%s %s [add:%d rem:%d] %s\n", hash, date, added, removed, message))
+			report.NonMainCommits++
 		}
 	}
-	fmt.Println(sb.String())
-	return nil
+	return report, nil
 }
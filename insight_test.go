@@ -0,0 +1,166 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsightFiltersApplyToEveryCommit(t *testing.T) {
+	dir := newFixtureRepo(t, 40)
+
+	tests := []struct {
+		name  string
+		opts  insightOptions
+		want  int
+		check func(commitRecord) bool
+	}{
+		{
+			name: "date range",
+			opts: insightOptions{Committers: []string{"Alice"}, Since: "2026-01-01T12:00:00Z", Until: "2026-01-01T20:00:00Z"},
+			want: 4,
+			check: func(c commitRecord) bool {
+				return !c.CommitDate.Before(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+			},
+		},
+		{
+			name:  "path",
+			opts:  insightOptions{Committers: []string{"Alice"}, Paths: []string{"pkg0"}},
+			want:  4,
+			check: func(c commitRecord) bool { return strings.HasPrefix(c.Files[0].Path, "pkg0/") },
+		},
+		{
+			name:  "exclude glob",
+			opts:  insightOptions{Committers: []string{"Alice"}, Exclude: []string{"pkg2/**"}},
+			want:  16,
+			check: func(c commitRecord) bool { return !strings.HasPrefix(c.Files[0].Path, "pkg2/") },
+		},
+		{
+			name:  "ref range",
+			opts:  insightOptions{Committers: []string{"Bob"}, Ref: "main~10..main"},
+			want:  5,
+			check: func(c commitRecord) bool { return c.AuthorName == "Bob" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits, err := getUserCommits(dir, tt.opts)
+			if err != nil {
+				t.Fatalf("getUserCommits() error = %v", err)
+			}
+			if len(commits) != tt.want {
+				t.Fatalf("getUserCommits() returned %d commits, want %d", len(commits), tt.want)
+			}
+			for _, c := range commits {
+				if len(c.Files) == 0 || !tt.check(c) {
+					t.Errorf("commit %s %s %+v does not match the filter", c.ShortHash(), c.Subject, c.Files)
+				}
+			}
+		})
+	}
+}
+
+func TestInsightOptionsRejectOptionLikeRef(t *testing.T) {
+	if _, err := (insightOptions{Committers: []string{"Alice"}, Ref: "--output=/tmp/x"}).gitLogArgs(); err == nil {
+		t.Fatal("gitLogArgs() error = nil, want invalid ref")
+	}
+}
+
+func TestInsightReportShowsFilters(t *testing.T) {
+	dir := newFixtureRepo(t, 4)
+
+	report, err := collectInsight(dir, insightOptions{Committers: []string{"Alice"}, Paths: []string{"pkg0"}, NoMerges: true, DirDepth: 1})
+	if err != nil {
+		t.Fatalf("collectInsight() error = %v", err)
+	}
+	var sb strings.Builder
+	if err = (textFormatter{}).writeUser(&sb, report); err != nil {
+		t.Fatalf("writeUser() error = %v", err)
+	}
+	if !strings.Contains(sb.String(), "Filtered by paths pkg0; no merges") {
+		t.Fatalf("report does not describe the filters:\n%s", sb.String())
+	}
+}
+
+func TestUserStatsFollowRenamesAndCountBinaries(t *testing.T) {
+	ann := "Ann <ann@example.com>"
+	at := func(n int) time.Time { return time.Date(2026, 1, 1, 9+n, 0, 0, 0, time.UTC) }
+	dir := newScriptRepo(t, scriptCommit(ann, at(0), "change 0", scriptFile("dir/a b.go", "one\ntwo\nthree\n")+scriptFile("img.bin", "\x00\x01\x02"))+
+		scriptCommit(ann, at(1), "change 1", scriptFile("dir/a b.go", "one\ntwo\nthree\nfour\n"))+
+		scriptCommit(ann, at(2), "change 2", `R "dir/a b.go" "dir/c d.go"`+"\n")+
+		scriptCommit(ann, at(3), "change 3", scriptFile("dir/c d.go", "one\ntwo\nthree\nfour\nfive\n")+scriptFile("img.bin", "\x00\x03"))+
+		scriptCommit(ann, at(4), "change 4", scriptFile("dir/a b.go", "new\n")))
+
+	commits, err := getUserCommits(dir, insightOptions{Committers: []string{"Ann"}})
+	if err != nil {
+		t.Fatalf("getUserCommits() error = %v", err)
+	}
+	stats := getUserStats(commits, nil)
+	if got := stats.FileChanges["dir/c d.go"]; got != 4 {
+		t.Errorf("changes to the renamed file = %d, want 4 including those made under its old name", got)
+	}
+	if got := stats.FileAdded["dir/c d.go"]; got != 5 {
+		t.Errorf("lines added to the renamed file = %d, want 5", got)
+	}
+	if got := stats.FileChanges["dir/a b.go"]; got != 1 {
+		t.Errorf("changes to the new file at the old path = %d, want 1", got)
+	}
+	for file := range stats.FileChanges {
+		if strings.Contains(file, "=>") || strings.Contains(file, "{") {
+			t.Errorf("bogus rename path %q in stats", file)
+		}
+	}
+	if stats.BinaryChanges != 2 || stats.FileChanges["img.bin"] != 2 || stats.FileAdded["img.bin"] != 0 {
+		t.Errorf("binary stats = %d changes, file %d, want 2 binary changes without lines", stats.BinaryChanges, stats.FileChanges["img.bin"])
+	}
+}
+
+func TestInsightGroupsFilesByLanguageDepthAndGoPackage(t *testing.T) {
+	ann := "Ann <ann@example.com>"
+	at := func(n int) time.Time { return time.Date(2026, 1, 1, 9+n, 0, 0, 0, time.UTC) }
+	dir := newScriptRepo(t, scriptCommit(ann, at(0), "change 0",
+		scriptFile("go.mod", "module example.com/app\n")+scriptFile("main.go", "package main\n")+
+			scriptFile("svc/api/handler.go", "package api\n")+scriptFile("svc/api/README.md", "# api\n")+
+			scriptFile("tools/gen/go.mod", "module \"example.com/gen\" // generator\n")+scriptFile("tools/gen/cmd/gen.go", "package main\n"))+
+		scriptCommit(ann, at(1), "change 1", scriptFile("svc/api/handler.go", "package api\n\n// x\n")+scriptFile("Makefile", "all:\n")))
+
+	report, err := collectInsight(dir, insightOptions{Committers: []string{"Ann"}, DirDepth: 2})
+	if err != nil {
+		t.Fatalf("collectInsight() error = %v", err)
+	}
+	types := make(map[string]statEntry)
+	for _, e := range report.FileTypes {
+		types[e.Name] = e
+	}
+	if goFiles := types["Go"]; goFiles.Files != 3 || goFiles.Changes != 4 {
+		t.Errorf("Go file type = %+v, want 3 distinct files changed 4 times", goFiles)
+	}
+	if types["Markdown"].Files != 1 || types["Makefile"].Files != 1 || types["Go Module"].Files != 2 {
+		t.Errorf("file types = %+v, want Markdown, Makefile and Go Module", report.FileTypes)
+	}
+	dirs := make(map[string]statEntry)
+	for _, e := range report.TopDirectories {
+		dirs[e.Name] = e
+	}
+	if api := dirs["svc/api"]; api.Files != 2 || api.Changes != 3 {
+		t.Errorf("top directories = %+v, want svc/api with 2 files and 3 changes", report.TopDirectories)
+	}
+
+	report, err = collectInsight(dir, insightOptions{Committers: []string{"Ann"}, DirDepth: 1, GoPackages: true})
+	if err != nil {
+		t.Fatalf("collectInsight(go packages) error = %v", err)
+	}
+	dirs = make(map[string]statEntry)
+	for _, e := range report.TopDirectories {
+		dirs[e.Name] = e
+	}
+	if dirs["example.com/app/svc/api"].Changes != 2 || dirs["example.com/app"].Changes != 1 || dirs["root"].Files != 2 {
+		t.Errorf("top directories = %+v, want Go packages, with go.mod and Makefile left in root", report.TopDirectories)
+	}
+
+	for _, depth := range []int{0, -1} {
+		if _, err = collectInsight(dir, insightOptions{Committers: []string{"Ann"}, DirDepth: depth}); err == nil {
+			t.Errorf("collectInsight(dir depth %d) error = nil, want error", depth)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/khicago/irr"
+)
+
+// insightSchemaVersion 是 insight JSON 输出的版本
+//
+// insight、hotspots、ownership、lint 的 JSON 输出都带 schema_version, 各自计数.
+// 同一版本内 JSON 字段名是对外的稳定格式, 只会新增字段; 字段改名、删除或含义改变时递增对应的版本.
+//
+// 2: weeks 换成了 bucket 和 timeline
+// 3: file_types 从按扩展名改为按语言分组, 条目新增 files
+const insightSchemaVersion = 3
+
+const (
+	InsightFormatText     = "text"
+	InsightFormatJSON     = "json"
+	InsightFormatCSV      = "csv"
+	InsightFormatMarkdown = "markdown"
+	InsightFormatHTML     = "html"
+)
+
+// statEntry 是按文件类型、文件或目录聚合的统计
+type statEntry struct {
+	Name string `json:"name"`
+	// Files 是不同文件的数量, Changes 是这些文件被提交修改的次数
+	Files   int `json:"files"`
+	Changes int `json:"changes"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// commitSummary 是报告中的一个提交
+type commitSummary struct {
+	Hash    string `json:"hash"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// insightReport 是单人报告, 各种格式都由它渲染
+type insightReport struct {
+	SchemaVersion  int             `json:"schema_version"`
+	Committers     []string        `json:"committers"`
+	Filters        []string        `json:"filters"`
+	Commits        int             `json:"commits"`
+	MainCommits    int             `json:"main_commits"`
+	NonMainCommits int             `json:"non_main_commits"`
+	Added          int             `json:"added"`
+	Removed        int             `json:"removed"`
+	BinaryChanges  int             `json:"binary_changes"`
+	FileTypes      []statEntry     `json:"file_types"`
+	TopFiles       []statEntry     `json:"top_files"`
+	TopDirectories []statEntry     `json:"top_directories"`
+	TopCommits     []commitSummary `json:"top_commits"`
+	Bucket         string          `json:"bucket"`
+	Timeline       []periodSummary `json:"timeline"`
+	Heatmap        activityHeatmap `json:"heatmap"`
+	Activity       activitySummary `json:"activity"`
+	// Conventional 是按 Conventional Commits 解析标题得到的统计
+	Conventional conventionalSummary `json:"conventional"`
+}
+
+// teamReport 是团队报告, Total 中每个提交只计一次
+type teamReport struct {
+	SchemaVersion int       `json:"schema_version"`
+	Filters       []string  `json:"filters"`
+	Authors       []teamRow `json:"authors"`
+	Total         teamRow   `json:"total"`
+	// TimeZone 和 WorkHours 说明 after_hours_commits 和 weekend_commits 的口径
+	TimeZone  string `json:"time_zone"`
+	WorkHours string `json:"work_hours"`
+}
+
+func summarizeCommit(commit commitRecord) commitSummary {
+	return commitSummary{
+		Hash:    commit.ShortHash(),
+		Date:    commit.AuthorDate.Format("2006-01-02"),
+		Subject: commit.Subject,
+		Added:   commit.Added(),
+		Removed: commit.Removed(),
+	}
+}
+
+// formatCommitLine 格式化为 "短哈希 日期 [add:N rem:N] 标题"
+func formatCommitLine(c commitSummary) string {
+	return fmt.Sprintf("%s %s [add:%d rem:%d] %s", c.Hash, c.Date, c.Added, c.Removed, c.Subject)
+}
+
+// insightFormatter 将报告渲染为一种输出格式
+type insightFormatter interface {
+	writeUser(w io.Writer, r insightReport) error
+	writeTeam(w io.Writer, r teamReport) error
+}
+
+// insightFormats 是 --format 可用的格式; color 只对 text 生效
+var insightFormats = map[string]func(color bool) insightFormatter{
+	InsightFormatText:     func(color bool) insightFormatter { return textFormatter{color: color} },
+	InsightFormatJSON:     func(bool) insightFormatter { return jsonFormatter{} },
+	InsightFormatCSV:      func(bool) insightFormatter { return csvFormatter{} },
+	InsightFormatMarkdown: func(bool) insightFormatter { return markdownFormatter{} },
+	InsightFormatHTML:     func(bool) insightFormatter { return htmlFormatter{} },
+}
+
+// newInsightFormatter 返回 format 对应的格式, 为空时使用 text
+func newInsightFormatter(format string, color bool) (insightFormatter, error) {
+	if format == "" {
+		format = InsightFormatText
+	}
+	newFormatter, ok := insightFormats[format]
+	if !ok {
+		names := make([]string, 0, len(insightFormats))
+		for name := range insightFormats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, irr.Error("unknown format %q, want one of %s", format, strings.Join(names, ", "))
+	}
+	return newFormatter(color), nil
+}
+
+// shouldColor 判断是否输出 ANSI 颜色: 设置了 NO_COLOR、TERM=dumb 或 out 不是终端时不输出
+func shouldColor(out *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := out.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// textFormatter 是面向终端的默认格式
+type textFormatter struct {
+	color bool
+}
+
+func (f textFormatter) paint(code, s string) string {
+	if !f.color {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
+
+func (f textFormatter) heading(s string) string {
+	if !f.color {
+		return s
+	}
+	return f.paint("1;34", s+" ")
+}
+func (f textFormatter) good(n int) string { return f.paint("1;32", strconv.Itoa(n)) }
+func (f textFormatter) bad(n int) string  { return f.paint("1;31", strconv.Itoa(n)) }
+
+func (f textFormatter) writeUser(w io.Writer, r insightReport) error {
+	sb := strings.Builder{}
+	sb.WriteString("\n" + f.heading("# User status") + "\n")
+	sb.WriteString("\n" + f.heading("## Overall") + "\n\n")
+	sb.WriteString(fmt.Sprintf("User %s has made %s commits\n\n", strings.Join(r.Committers, ", "), f.good(r.Commits)))
+	if len(r.Filters) > 0 {
+		sb.WriteString(fmt.Sprintf("Filtered by %s\n\n", strings.Join(r.Filters, "; ")))
+	}
+	sb.WriteString(fmt.Sprintf("- Main branch commits: %s\n", f.good(r.MainCommits)))
+	sb.WriteString(fmt.Sprintf("- Non-main branch commits: %s\n", f.bad(r.NonMainCommits)))
+	sb.WriteString(fmt.Sprintf("- Total lines added: %s\n", f.good(r.Added)))
+	sb.WriteString(fmt.Sprintf("- Total lines removed: %s\n", f.bad(r.Removed)))
+	sb.WriteString(fmt.Sprintf("- Binary file changes (no line counts): %d\n", r.BinaryChanges))
+	sb.WriteString("\n" + f.heading("## File changes by type") + "\n\n")
+	for _, t := range r.FileTypes {
+		sb.WriteString(fmt.Sprintf("- %s: %d files [changes:%d add:%d rem:%d]\n", t.Name, t.Files, t.Changes, t.Added, t.Removed))
+	}
+	sb.WriteString("\n" + f.heading("## Top files") + "\n\n")
+	for _, file := range r.TopFiles {
+		sb.WriteString(fmt.Sprintf("- %s [changes:%d add:%d rem:%d]\n", file.Name, file.Changes, file.Added, file.Removed))
+	}
+	sb.WriteString("\n" + f.heading("## Top directories") + "\n\n")
+	for _, dir := range r.TopDirectories {
+		sb.WriteString(fmt.Sprintf("- %s [files:%d changes:%d add:%d rem:%d]\n", dir.Name, dir.Files, dir.Changes, dir.Added, dir.Removed))
+	}
+	sb.WriteString("\n" + f.heading("## Top commits") + "\n\n")
+	for _, commit := range r.TopCommits {
+		sb.WriteString(fmt.Sprintf("- %s\n", formatCommitLine(commit)))
+	}
+	sb.WriteString("\n" + f.heading(fmt.Sprintf("## Activity (%s time)", r.Activity.TimeZone)) + "\n\n")
+	sb.WriteString(fmt.Sprintf("- After-hours commits (weekdays outside %s): %s (%s)\n", r.Activity.WorkHours, f.bad(r.Activity.AfterHours), formatShare(r.Activity.AfterHoursShare)))
+	sb.WriteString(fmt.Sprintf("- Weekend commits: %s (%s)\n\n", f.bad(r.Activity.Weekend), formatShare(r.Activity.WeekendShare)))
+	writeHeatmapText(&sb, r.Heatmap)
+	sb.WriteString("\n" + f.heading("## Conventional commits") + "\n\n")
+	writeConventionalText(&sb, r.Conventional)
+	sb.WriteString("\n" + f.heading(fmt.Sprintf("## Commit habits (by %s)", r.Bucket)) + "\n\n")
+	writeTimelineText(&sb, r.Timeline)
+	_, err := fmt.Fprintln(w, sb.String())
+	return err
+}
+
+// writeConventionalText 输出规范率、不兼容变更、返工率以及类型和 scope 的分布
+func writeConventionalText(sb *strings.Builder, c conventionalSummary) {
+	considered := c.Conforming + c.NonConforming
+	sb.WriteString(fmt.Sprintf("- Conforming: %d of %d (%s non-conforming); %d merge or revert commits ignored\n",
+		c.Conforming, considered, formatShare(c.NonConformingShare), c.Ignored))
+	sb.WriteString(fmt.Sprintf("- Breaking changes: %d\n", c.Breaking))
+	sb.WriteString(fmt.Sprintf("- Rework: %d of %d feat commits (%s) had a fix touching the same files within %d days (%d of %d fix commits)\n",
+		c.Rework.ReworkedFeats, c.Rework.Feats, formatShare(c.Rework.Rate), c.Rework.ReworkDays, c.Rework.ReworkFixes, c.Rework.Fixes))
+	for _, t := range c.Types {
+		sb.WriteString(fmt.Sprintf("- type %s: %d (%s)\n", t.Name, t.Commits, formatShare(t.Share)))
+	}
+	for _, s := range c.Scopes {
+		sb.WriteString(fmt.Sprintf("- scope %s: %d (%s)\n", s.Name, s.Commits, formatShare(s.Share)))
+	}
+}
+
+// formatShare 将占比格式化为百分数
+func formatShare(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
+
+// writeTimelineText 先输出整体的 sparkline, 再逐个时间段输出柱状图和提交
+func writeTimelineText(sb *strings.Builder, timeline []periodSummary) {
+	if len(timeline) == 0 {
+		return
+	}
+	counts := make([]int, len(timeline))
+	maxCount, labelWidth := 0, 0
+	for i, period := range timeline {
+		counts[i] = len(period.Commits)
+		maxCount = max(maxCount, counts[i])
+		labelWidth = max(labelWidth, len(period.Period))
+	}
+	sb.WriteString(fmt.Sprintf("%s .. %s  %s\n\n", timeline[0].Period, timeline[len(timeline)-1].Period, sparkline(counts)))
+	for _, period := range timeline {
+		sb.WriteString(fmt.Sprintf("%-*s %-*s %d commits", labelWidth, period.Period, timelineBarWidth, asciiBar(len(period.Commits), maxCount, timelineBarWidth), len(period.Commits)))
+		if len(period.Commits) > 0 {
+			sb.WriteString(fmt.Sprintf(" [add:%d rem:%d]", period.Added, period.Removed))
+		}
+		sb.WriteString("\n")
+		for _, commit := range period.Commits {
+			sb.WriteString(fmt.Sprintf("\t%s\n", formatCommitLine(commit)))
+		}
+	}
+}
+
+func (f textFormatter) writeTeam(w io.Writer, r teamReport) error {
+	sb := strings.Builder{}
+	sb.WriteString("\n" + f.heading("# Team status") + "\n\n")
+	if len(r.Filters) > 0 {
+		sb.WriteString(fmt.Sprintf("Filtered by %s\n\n", strings.Join(r.Filters, "; ")))
+	}
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AUTHOR\tEMAIL\tCOMMITS\tADDED\tREMOVED\tFILES\tACTIVE DAYS\tMAIN\tNON-MAIN\tAFTER-HOURS\tWEEKEND")
+	rows := append(append([]teamRow(nil), r.Authors...), r.Total)
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", row.Name, row.Email, row.Commits, row.Added, row.Removed, row.Files, row.ActiveDays, row.Main, row.NonMain, row.AfterHours, row.Weekend)
+	}
+	_ = tw.Flush()
+	sb.WriteString("\nCo-authored commits count for every author, and once in the total.\n")
+	sb.WriteString(fmt.Sprintf("After-hours means weekdays outside %s, %s time; weekend commits are counted separately.\n", r.WorkHours, r.TimeZone))
+	_, err := fmt.Fprintln(w, sb.String())
+	return err
+}
+
+// jsonFormatter 输出带 schema_version 的缩进 JSON
+type jsonFormatter struct{}
+
+func (jsonFormatter) writeUser(w io.Writer, r insightReport) error { return writeJSON(w, r) }
+func (jsonFormatter) writeTeam(w io.Writer, r teamReport) error    { return writeJSON(w, r) }
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return irr.Wrap(err, "failed to encode report")
+	}
+	return nil
+}
+
+// csvFormatter 将单人报告展开为 section 列区分的长表, 团队报告每位作者一行
+type csvFormatter struct{}
+
+func (csvFormatter) writeUser(w io.Writer, r insightReport) error {
+	records := [][]string{{"section", "name", "count", "added", "removed", "date", "subject", "files"}}
+	summary := func(name string, count int) {
+		records = append(records, []string{"summary", name, strconv.Itoa(count), "", "", "", "", ""})
+	}
+	summary("commits", r.Commits)
+	summary("main_commits", r.MainCommits)
+	summary("non_main_commits", r.NonMainCommits)
+	summary("added", r.Added)
+	summary("removed", r.Removed)
+	summary("binary_changes", r.BinaryChanges)
+	summary("after_hours_commits", r.Activity.AfterHours)
+	summary("weekend_commits", r.Activity.Weekend)
+	summary("conforming_commits", r.Conventional.Conforming)
+	summary("non_conforming_commits", r.Conventional.NonConforming)
+	summary("breaking_changes", r.Conventional.Breaking)
+	summary("reworked_feats", r.Conventional.Rework.ReworkedFeats)
+	summary("rework_fixes", r.Conventional.Rework.ReworkFixes)
+	stats := func(section string, entries []statEntry) {
+		for _, e := range entries {
+			records = append(records, []string{section, e.Name, strconv.Itoa(e.Changes), strconv.Itoa(e.Added), strconv.Itoa(e.Removed), "", "", strconv.Itoa(e.Files)})
+		}
+	}
+	stats("file_type", r.FileTypes)
+	stats("file", r.TopFiles)
+	stats("directory", r.TopDirectories)
+	counts := func(section string, entries []countEntry) {
+		for _, e := range entries {
+			records = append(records, []string{section, e.Name, strconv.Itoa(e.Commits), "", "", "", "", ""})
+		}
+	}
+	counts("commit_type", r.Conventional.Types)
+	counts("commit_scope", r.Conventional.Scopes)
+	for _, c := range r.TopCommits {
+		records = append(records, []string{"top_commit", c.Hash, "1", strconv.Itoa(c.Added), strconv.Itoa(c.Removed), c.Date, c.Subject, ""})
+	}
+	for day, hours := range r.Heatmap {
+		for hour, n := range hours {
+			records = append(records, []string{"heatmap", fmt.Sprintf("%s %02d", heatmapWeekdays[day], hour), strconv.Itoa(n), "", "", "", "", ""})
+		}
+	}
+	for _, period := range r.Timeline {
+		records = append(records, []string{r.Bucket, period.Period, strconv.Itoa(len(period.Commits)), strconv.Itoa(period.Added), strconv.Itoa(period.Removed), period.Start, "", ""})
+	}
+	return writeCSV(w, records)
+}
+
+func (csvFormatter) writeTeam(w io.Writer, r teamReport) error {
+	records := [][]string{{"row", "author", "email", "commits", "added", "removed", "files", "active_days", "main_commits", "non_main_commits", "after_hours_commits", "weekend_commits"}}
+	add := func(kind string, row teamRow) {
+		records = append(records, []string{kind, row.Name, row.Email, strconv.Itoa(row.Commits), strconv.Itoa(row.Added), strconv.Itoa(row.Removed),
+			strconv.Itoa(row.Files), strconv.Itoa(row.ActiveDays), strconv.Itoa(row.Main), strconv.Itoa(row.NonMain),
+			strconv.Itoa(row.AfterHours), strconv.Itoa(row.Weekend)})
+	}
+	for _, row := range r.Authors {
+		add("author", row)
+	}
+	add("total", r.Total)
+	return writeCSV(w, records)
+}
+
+func writeCSV(w io.Writer, records [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return irr.Wrap(err, "failed to write csv")
+	}
+	return nil
+}
+
+// markdownFormatter 输出可以直接粘贴到文档或 PR 中的表格
+type markdownFormatter struct{}
+
+func (markdownFormatter) writeUser(w io.Writer, r insightReport) error {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("# User status: %s\n\n", markdownCell(strings.Join(r.Committers, ", "))))
+	if len(r.Filters) > 0 {
+		sb.WriteString(fmt.Sprintf("Filtered by %s\n\n", markdownCell(strings.Join(r.Filters, "; "))))
+	}
+	sb.WriteString("| Metric | Value |\n| --- | ---: |\n")
+	sb.WriteString(fmt.Sprintf("| Commits | %d |\n| Main branch commits | %d |\n| Non-main branch commits | %d |\n| Lines added | %d |\n| Lines removed | %d |\n| Binary file changes | %d |\n",
+		r.Commits, r.MainCommits, r.NonMainCommits, r.Added, r.Removed, r.BinaryChanges))
+	writeMarkdownStats(&sb, "File changes by type", "Type", r.FileTypes)
+	writeMarkdownStats(&sb, "Top files", "File", r.TopFiles)
+	writeMarkdownStats(&sb, "Top directories", "Directory", r.TopDirectories)
+	sb.WriteString("\n## Top commits\n\n| Commit | Date | Added | Removed | Subject |\n| --- | --- | ---: | ---: | --- |\n")
+	for _, c := range r.TopCommits {
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %d | %d | %s |\n", c.Hash, c.Date, c.Added, c.Removed, markdownCell(c.Subject)))
+	}
+	sb.WriteString(fmt.Sprintf("\n## Activity (%s time)\n\n", r.Activity.TimeZone))
+	sb.WriteString(fmt.Sprintf("- After-hours commits (weekdays outside %s): %d (%s)\n", r.Activity.WorkHours, r.Activity.AfterHours, formatShare(r.Activity.AfterHoursShare)))
+	sb.WriteString(fmt.Sprintf("- Weekend commits: %d (%s)\n\n```text\n", r.Activity.Weekend, formatShare(r.Activity.WeekendShare)))
+	writeHeatmapText(&sb, r.Heatmap)
+	sb.WriteString("```\n")
+	c := r.Conventional
+	sb.WriteString(fmt.Sprintf("\n## Conventional commits\n\n| Metric | Value |\n| --- | ---: |\n| Conforming | %d |\n| Non-conforming | %d (%s) |\n| Merge or revert (ignored) | %d |\n| Breaking changes | %d |\n",
+		c.Conforming, c.NonConforming, formatShare(c.NonConformingShare), c.Ignored, c.Breaking))
+	sb.WriteString(fmt.Sprintf("| Feat commits reworked within %d days | %d of %d (%s) |\n| Rework fixes | %d of %d |\n",
+		c.Rework.ReworkDays, c.Rework.ReworkedFeats, c.Rework.Feats, formatShare(c.Rework.Rate), c.Rework.ReworkFixes, c.Rework.Fixes))
+	writeMarkdownCounts(&sb, "Commit types", "Type", c.Types)
+	writeMarkdownCounts(&sb, "Commit scopes", "Scope", c.Scopes)
+	sb.WriteString(fmt.Sprintf("\n## Commit habits (by %s)\n\n| Period | Commits | Added | Removed |\n| --- | ---: | ---: | ---: |\n", r.Bucket))
+	for _, period := range r.Timeline {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n", period.Period, len(period.Commits), period.Added, period.Removed))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (markdownFormatter) writeTeam(w io.Writer, r teamReport) error {
+	sb := strings.Builder{}
+	sb.WriteString("# Team status\n\n")
+	if len(r.Filters) > 0 {
+		sb.WriteString(fmt.Sprintf("Filtered by %s\n\n", markdownCell(strings.Join(r.Filters, "; "))))
+	}
+	sb.WriteString("| Author | Email | Commits | Added | Removed | Files | Active days | Main | Non-main | After-hours | Weekend |\n")
+	sb.WriteString("| --- | --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, row := range r.Authors {
+		sb.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %d | %d | %d | %d | %d | %d |\n", markdownCell(row.Name), markdownCell(row.Email), row.Commits, row.Added, row.Removed, row.Files, row.ActiveDays, row.Main, row.NonMain, row.AfterHours, row.Weekend))
+	}
+	t := r.Total
+	sb.WriteString(fmt.Sprintf("| **Total** | | **%d** | **%d** | **%d** | **%d** | **%d** | **%d** | **%d** | **%d** | **%d** |\n", t.Commits, t.Added, t.Removed, t.Files, t.ActiveDays, t.Main, t.NonMain, t.AfterHours, t.Weekend))
+	sb.WriteString(fmt.Sprintf("\nAfter-hours means weekdays outside %s, %s time.\n", r.WorkHours, r.TimeZone))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdownStats(sb *strings.Builder, title, column string, entries []statEntry) {
+	sb.WriteString(fmt.Sprintf("\n## %s\n\n| %s | Files | Changes | Added | Removed |\n| --- | ---: | ---: | ---: | ---: |\n", title, column))
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d |\n", markdownCell(e.Name), e.Files, e.Changes, e.Added, e.Removed))
+	}
+}
+
+func writeMarkdownCounts(sb *strings.Builder, title, column string, entries []countEntry) {
+	sb.WriteString(fmt.Sprintf("\n### %s\n\n| %s | Commits | Share |\n| --- | ---: | ---: |\n", title, column))
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s |\n", markdownCell(e.Name), e.Commits, formatShare(e.Share)))
+	}
+}
+
+// markdownCell 转义表格单元格中的竖线和换行
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var formatTestReport = insightReport{
+	SchemaVersion:  insightSchemaVersion,
+	Committers:     []string{"Ann"},
+	Filters:        []string{"since 2026-01-01"},
+	Commits:        2,
+	MainCommits:    1,
+	NonMainCommits: 1,
+	Added:          12,
+	Removed:        3,
+	FileTypes:      []statEntry{{Name: "Go", Files: 1, Changes: 2, Added: 12, Removed: 3}},
+	TopFiles:       []statEntry{{Name: "cmd/main.go", Files: 1, Changes: 2, Added: 12, Removed: 3}},
+	TopDirectories: []statEntry{{Name: "cmd", Files: 1, Changes: 2, Added: 12, Removed: 3}},
+	TopCommits:     []commitSummary{{Hash: "abc1234", Date: "2026-01-02", Subject: "fix: a | b <script>", Added: 10, Removed: 1}},
+	Bucket:         BucketWeek,
+	Timeline:       []periodSummary{{Period: "2026-W01", Start: "2025-12-29", Added: 10, Removed: 1, Commits: []commitSummary{{Hash: "abc1234", Date: "2026-01-02", Subject: "fix", Added: 10, Removed: 1}}}},
+}
+
+func renderUser(t *testing.T, format string, color bool, r insightReport) string {
+	t.Helper()
+
+	f, err := newInsightFormatter(format, color)
+	if err != nil {
+		t.Fatalf("newInsightFormatter(%q) error = %v", format, err)
+	}
+	var sb strings.Builder
+	if err = f.writeUser(&sb, r); err != nil {
+		t.Fatalf("writeUser(%q) error = %v", format, err)
+	}
+	return sb.String()
+}
+
+func TestInsightJSONSchemaIsStable(t *testing.T) {
+	var got map[string]any
+	if err := json.Unmarshal([]byte(renderUser(t, InsightFormatJSON, true, formatTestReport)), &got); err != nil {
+		t.Fatalf("json output does not parse: %v", err)
+	}
+	keys := make([]string, 0, len(got))
+	for k := range got {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	want := "activity,added,binary_changes,bucket,commits,committers,conventional,file_types,filters,heatmap,main_commits,non_main_commits,removed,schema_version,timeline,top_commits,top_directories,top_files"
+	if strings.Join(keys, ",") != want {
+		t.Fatalf("json keys = %s, want %s", strings.Join(keys, ","), want)
+	}
+	if got["schema_version"] != float64(3) {
+		t.Errorf("schema_version = %v, want 3", got["schema_version"])
+	}
+
+	empty := insightReport{SchemaVersion: insightSchemaVersion, Committers: []string{"Ann"}, Filters: []string{},
+		FileTypes: []statEntry{}, TopFiles: []statEntry{}, TopDirectories: []statEntry{}, TopCommits: []commitSummary{}, Timeline: []periodSummary{},
+		Conventional: getConventionalSummary(nil, DefaultReworkDays)}
+	if out := renderUser(t, InsightFormatJSON, false, empty); strings.Contains(out, "null") {
+		t.Errorf("empty report json contains null, want empty arrays:\n%s", out)
+	}
+}
+
+func TestInsightCSVAndMarkdownAndHTML(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(renderUser(t, InsightFormatCSV, false, formatTestReport))).ReadAll()
+	if err != nil {
+		t.Fatalf("csv output does not parse: %v", err)
+	}
+	if strings.Join(records[0], ",") != "section,name,count,added,removed,date,subject,files" {
+		t.Errorf("csv header = %v", records[0])
+	}
+	found := false
+	for _, r := range records {
+		if r[0] == "top_commit" && r[6] == "fix: a | b <script>" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("csv is missing the top commit row: %v", records)
+	}
+
+	md := renderUser(t, InsightFormatMarkdown, false, formatTestReport)
+	if !strings.Contains(md, `fix: a \| b <script>`) || !strings.Contains(md, "| cmd/main.go | 1 | 2 | 12 | 3 |") {
+		t.Errorf("markdown output does not escape or tabulate correctly:\n%s", md)
+	}
+
+	page := renderUser(t, InsightFormatHTML, false, formatTestReport)
+	if strings.Contains(page, "<script>") || !strings.Contains(page, "&lt;script&gt;") || !strings.Contains(page, "<td>cmd/main.go</td>") {
+		t.Errorf("html output does not escape subjects or list files:\n%s", page)
+	}
+}
+
+func TestInsightTextColourIsOptional(t *testing.T) {
+	plain := renderUser(t, InsightFormatText, false, formatTestReport)
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("text without colour contains ANSI escapes:\n%q", plain)
+	}
+	if !strings.Contains(plain, "- Main branch commits: 1\n") {
+		t.Errorf("text output missing main branch count:\n%s", plain)
+	}
+	if coloured := renderUser(t, InsightFormatText, true, formatTestReport); !strings.Contains(coloured, "\033[1;32m1\033[0m") {
+		t.Errorf("text with colour missing ANSI escapes:\n%q", coloured)
+	}
+
+	if _, err := newInsightFormatter("yaml", false); err == nil {
+		t.Error("newInsightFormatter(yaml) error = nil, want error")
+	}
+}
+
+func TestShouldColorRespectsNoColorAndNonTTY(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+	if shouldColor(f) {
+		t.Error("shouldColor(regular file) = true, want false")
+	}
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		if !shouldColor(tty) {
+			t.Error("shouldColor(tty) = false, want true")
+		}
+		t.Setenv("NO_COLOR", "1")
+		if shouldColor(tty) {
+			t.Error("shouldColor(tty) with NO_COLOR = true, want false")
+		}
+	}
+}
+
+func TestInsightTeamCSV(t *testing.T) {
+	report := teamReport{
+		SchemaVersion: insightSchemaVersion,
+		Filters:       []string{},
+		Authors:       []teamRow{{Name: "Ann", Email: "ann@example.com", Commits: 3, Added: 8}},
+		Total:         teamRow{Name: "Total", Commits: 3, Added: 8},
+	}
+	var sb strings.Builder
+	if err := (csvFormatter{}).writeTeam(&sb, report); err != nil {
+		t.Fatalf("writeTeam() error = %v", err)
+	}
+	want := "row,author,email,commits,added,removed,files,active_days,main_commits,non_main_commits,after_hours_commits,weekend_commits\n" +
+		"author,Ann,ann@example.com,3,8,0,0,0,0,0,0,0\n" +
+		"total,Total,,3,8,0,0,0,0,0,0,0\n"
+	if sb.String() != want {
+		t.Fatalf("team csv = %q, want %q", sb.String(), want)
+	}
+}
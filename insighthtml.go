@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/khicago/irr"
+)
+
+// htmlFormatter 输出可离线打开的单个 HTML 文件, 图表是内联 SVG, 不引用外部资源也不包含脚本
+type htmlFormatter struct{}
+
+var insightHTMLTemplate = template.Must(template.New("insight").Funcs(template.FuncMap{
+	"stats": func(title, column string, entries []statEntry) htmlStatsTable {
+		return htmlStatsTable{Title: title, Column: column, Entries: entries}
+	},
+	"counts": func(title, column string, entries []countEntry) htmlCountsTable {
+		return htmlCountsTable{Title: title, Column: column, Entries: entries}
+	},
+	"timelineCommitsChart": timelineCommitsChart,
+	"timelineLinesChart":   timelineLinesChart,
+	"statBarsChart":        statBarsChart,
+	"heatmapChart":         heatmapChart,
+	"teamCommitsChart":     teamCommitsChart,
+	"share":                formatShare,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #1f2328; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #d0d7de; padding: 0.3rem 0.6rem; text-align: left; }
+td.n { text-align: right; font-variant-numeric: tabular-nums; }
+.cards { display: flex; flex-wrap: wrap; gap: 0.75rem; margin-bottom: 1.5rem; }
+.card { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.6rem 1rem; min-width: 8rem; }
+.card b { display: block; font-size: 1.5rem; font-variant-numeric: tabular-nums; }
+.grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(26rem, 1fr)); gap: 0 2rem; }
+svg { width: 100%; height: auto; margin-bottom: 1.5rem; font-size: 11px; }
+svg text { fill: #57606a; }
+.added { fill: #2da44e; }
+.removed { fill: #cf222e; }
+.bar { fill: #0969da; }
+.empty { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Filters}}<p>Filtered by {{range $i, $f := .}}{{if $i}}; {{end}}{{$f}}{{end}}</p>{{end}}
+{{with .User}}
+<div class="cards">
+<div class="card"><b>{{.Commits}}</b>commits</div>
+<div class="card"><b>{{.MainCommits}}</b>on the main branch</div>
+<div class="card"><b>{{.NonMainCommits}}</b>off the main branch</div>
+<div class="card"><b class="added">+{{.Added}}</b>lines added</div>
+<div class="card"><b class="removed">-{{.Removed}}</b>lines removed</div>
+<div class="card"><b>{{.BinaryChanges}}</b>binary file changes</div>
+<div class="card"><b>{{share .Activity.AfterHoursShare}}</b>after hours</div>
+<div class="card"><b>{{share .Activity.WeekendShare}}</b>on weekends</div>
+<div class="card"><b>{{share .Conventional.NonConformingShare}}</b>non-conforming messages</div>
+<div class="card"><b>{{.Conventional.Breaking}}</b>breaking changes</div>
+<div class="card"><b>{{share .Conventional.Rework.Rate}}</b>feats reworked</div>
+</div>
+<h2>Commits per {{.Bucket}}</h2>
+{{timelineCommitsChart .Timeline}}
+<h2>Lines added and removed per {{.Bucket}}</h2>
+{{timelineLinesChart .Timeline}}
+<h2>Activity by weekday and hour ({{.Activity.TimeZone}} time)</h2>
+{{heatmapChart .Heatmap}}
+<p>{{.Activity.AfterHours}} commits on weekdays outside {{.Activity.WorkHours}}, {{.Activity.Weekend}} on weekends.</p>
+<div class="grid">
+<div>
+<h2>File changes by type</h2>
+{{statBarsChart .FileTypes}}
+</div>
+<div>
+<h2>Top directories</h2>
+{{statBarsChart .TopDirectories}}
+</div>
+</div>
+<h2>Top commits</h2>
+<table>
+<tr><th>Commit</th><th>Date</th><th>Added</th><th>Removed</th><th>Subject</th></tr>
+{{range .TopCommits}}<tr><td><code>{{.Hash}}</code></td><td>{{.Date}}</td><td class="n">{{.Added}}</td><td class="n">{{.Removed}}</td><td>{{.Subject}}</td></tr>
+{{end}}</table>
+{{with .Conventional}}
+<h2>Conventional commits</h2>
+<p>{{.Conforming}} conforming and {{.NonConforming}} non-conforming commit messages; {{.Ignored}} merge or revert commits ignored.
+{{.Rework.ReworkedFeats}} of {{.Rework.Feats}} feat commits had a fix touching the same files within {{.Rework.ReworkDays}} days ({{.Rework.ReworkFixes}} of {{.Rework.Fixes}} fix commits).</p>
+<div class="grid">
+<div>{{template "counts" (counts "Commit types" "Type" .Types)}}</div>
+<div>{{template "counts" (counts "Commit scopes" "Scope" .Scopes)}}</div>
+</div>
+{{end}}
+{{template "stats" (stats "File changes by type" "Type" .FileTypes)}}
+{{template "stats" (stats "Top files" "File" .TopFiles)}}
+{{template "stats" (stats "Top directories" "Directory" .TopDirectories)}}
+{{end}}
+{{with .Team}}
+<h2>Commits per author</h2>
+{{teamCommitsChart .Authors}}
+<table>
+<tr><th>Author</th><th>Email</th><th>Commits</th><th>Added</th><th>Removed</th><th>Files</th><th>Active days</th><th>Main</th><th>Non-main</th><th>After-hours</th><th>Weekend</th></tr>
+{{range .Authors}}<tr><td>{{.Name}}</td><td>{{.Email}}</td><td class="n">{{.Commits}}</td><td class="n">{{.Added}}</td><td class="n">{{.Removed}}</td><td class="n">{{.Files}}</td><td class="n">{{.ActiveDays}}</td><td class="n">{{.Main}}</td><td class="n">{{.NonMain}}</td><td class="n">{{.AfterHours}}</td><td class="n">{{.Weekend}}</td></tr>
+{{end}}{{with .Total}}<tr><th>Total</th><th></th><th class="n">{{.Commits}}</th><th class="n">{{.Added}}</th><th class="n">{{.Removed}}</th><th class="n">{{.Files}}</th><th class="n">{{.ActiveDays}}</th><th class="n">{{.Main}}</th><th class="n">{{.NonMain}}</th><th class="n">{{.AfterHours}}</th><th class="n">{{.Weekend}}</th></tr>{{end}}
+</table>
+<p>Co-authored commits count for every author, and once in the total. After-hours means weekdays outside {{.WorkHours}}, {{.TimeZone}} time.</p>
+{{end}}
+</body>
+</html>
+{{define "stats"}}<h2>{{.Title}}</h2>
+<table>
+<tr><th>{{.Column}}</th><th>Files</th><th>Changes</th><th>Added</th><th>Removed</th></tr>
+{{range .Entries}}<tr><td>{{.Name}}</td><td class="n">{{.Files}}</td><td class="n">{{.Changes}}</td><td class="n">{{.Added}}</td><td class="n">{{.Removed}}</td></tr>
+{{end}}</table>
+{{end}}
+{{define "counts"}}<h3>{{.Title}}</h3>
+<table>
+<tr><th>{{.Column}}</th><th>Commits</th><th>Share</th></tr>
+{{range .Entries}}<tr><td>{{.Name}}</td><td class="n">{{.Commits}}</td><td class="n">{{share .Share}}</td></tr>
+{{end}}</table>
+{{end}}`))
+
+type htmlStatsTable struct {
+	Title, Column string
+	Entries       []statEntry
+}
+
+type htmlCountsTable struct {
+	Title, Column string
+	Entries       []countEntry
+}
+
+type htmlPage struct {
+	Title   string
+	Filters []string
+	User    *insightReport
+	Team    *teamReport
+}
+
+func (htmlFormatter) writeUser(w io.Writer, r insightReport) error {
+	return writeHTML(w, htmlPage{Title: "User status: " + strings.Join(r.Committers, ", "), Filters: r.Filters, User: &r})
+}
+
+func (htmlFormatter) writeTeam(w io.Writer, r teamReport) error {
+	return writeHTML(w, htmlPage{Title: "Team status", Filters: r.Filters, Team: &r})
+}
+
+func writeHTML(w io.Writer, page htmlPage) error {
+	if err := insightHTMLTemplate.Execute(w, page); err != nil {
+		return irr.Wrap(err, "failed to render html report")
+	}
+	return nil
+}
+
+const (
+	chartWidth      = 720
+	chartHeight     = 180
+	chartLabelWidth = 200
+	chartRowHeight  = 22
+	chartMaxLabel   = 30
+)
+
+// chartBar 是图表中的一个柱子, Title 是鼠标悬停时的提示
+type chartBar struct {
+	Label string
+	Value int
+	Title string
+}
+
+var noChartData = template.HTML(`<p class="empty">No commits.</p>`)
+
+// svgText 转义 SVG 中的文本
+func svgText(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+// shortenLabel 截断过长的标签, 保留末尾, 路径的末尾最有辨识度
+func shortenLabel(s string) string {
+	runes := []rune(s)
+	if len(runes) <= chartMaxLabel {
+		return s
+	}
+	return "…" + string(runes[len(runes)-chartMaxLabel+1:])
+}
+
+// columnChart 画纵向柱状图, 只标注第一个和最后一个标签
+func columnChart(name string, bars []chartBar) template.HTML {
+	if len(bars) == 0 {
+		return noChartData
+	}
+	maxValue := 1
+	for _, b := range bars {
+		maxValue = max(maxValue, b.Value)
+	}
+	plotHeight := float64(chartHeight - 20)
+	width := float64(chartWidth) / float64(len(bars))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, chartHeight, svgText(name))
+	for i, b := range bars {
+		h := plotHeight * float64(b.Value) / float64(maxValue)
+		fmt.Fprintf(&sb, `<rect class="bar" x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s</title></rect>`,
+			float64(i)*width+width*0.1, plotHeight-h, width*0.8, h, svgText(b.Title))
+	}
+	fmt.Fprintf(&sb, `<text x="0" y="%d">%s</text>`, chartHeight-4, svgText(bars[0].Label))
+	if len(bars) > 1 {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartWidth, chartHeight-4, svgText(bars[len(bars)-1].Label))
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// barsChart 画横向柱状图, 每行一个标签
+func barsChart(name string, bars []chartBar) template.HTML {
+	if len(bars) == 0 {
+		return noChartData
+	}
+	maxValue := 1
+	for _, b := range bars {
+		maxValue = max(maxValue, b.Value)
+	}
+	plotWidth := float64(chartWidth - chartLabelWidth - 60)
+	height := len(bars) * chartRowHeight
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, height, svgText(name))
+	for i, b := range bars {
+		y := i * chartRowHeight
+		w := plotWidth * float64(b.Value) / float64(maxValue)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartLabelWidth-8, y+15, svgText(shortenLabel(b.Label)))
+		fmt.Fprintf(&sb, `<rect class="bar" x="%d" y="%d" width="%.1f" height="%d"><title>%s</title></rect>`,
+			chartLabelWidth, y+3, w, chartRowHeight-6, svgText(b.Title))
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d">%d</text>`, float64(chartLabelWidth)+w+6, y+15, b.Value)
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// timelineCommitsChart 画每个时间段的提交数
+func timelineCommitsChart(timeline []periodSummary) template.HTML {
+	bars := make([]chartBar, 0, len(timeline))
+	for _, period := range timeline {
+		bars = append(bars, chartBar{Label: period.Period, Value: len(period.Commits), Title: fmt.Sprintf("%s: %d commits", period.Period, len(period.Commits))})
+	}
+	return columnChart("Commits per period", bars)
+}
+
+// timelineLinesChart 画每个时间段增加 (向上) 和删除 (向下) 的行数
+func timelineLinesChart(timeline []periodSummary) template.HTML {
+	if len(timeline) == 0 {
+		return noChartData
+	}
+	maxValue := 1
+	for _, period := range timeline {
+		maxValue = max(maxValue, period.Added, period.Removed)
+	}
+	half := float64(chartHeight-20) / 2
+	width := float64(chartWidth) / float64(len(timeline))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img" aria-label="Lines added and removed per period">`, chartWidth, chartHeight)
+	for i, period := range timeline {
+		x := float64(i)*width + width*0.1
+		up := half * float64(period.Added) / float64(maxValue)
+		down := half * float64(period.Removed) / float64(maxValue)
+		fmt.Fprintf(&sb, `<rect class="added" x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s: +%d</title></rect>`,
+			x, half-up, width*0.8, up, svgText(period.Period), period.Added)
+		fmt.Fprintf(&sb, `<rect class="removed" x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s: -%d</title></rect>`,
+			x, half, width*0.8, down, svgText(period.Period), period.Removed)
+	}
+	fmt.Fprintf(&sb, `<line x1="0" y1="%.1f" x2="%d" y2="%.1f" stroke="#d0d7de"/>`, half, chartWidth, half)
+	fmt.Fprintf(&sb, `<text x="0" y="%d">%s</text>`, chartHeight-4, svgText(timeline[0].Period))
+	if len(timeline) > 1 {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartWidth, chartHeight-4, svgText(timeline[len(timeline)-1].Period))
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// statBarsChart 按变更次数画文件类型或目录
+func statBarsChart(entries []statEntry) template.HTML {
+	bars := make([]chartBar, 0, len(entries))
+	for _, e := range entries {
+		bars = append(bars, chartBar{Label: e.Name, Value: e.Changes,
+			Title: fmt.Sprintf("%s: %d changes, +%d -%d", e.Name, e.Changes, e.Added, e.Removed)})
+	}
+	return barsChart("File changes", bars)
+}
+
+// teamCommitsChart 画团队中每位作者的提交数
+func teamCommitsChart(rows []teamRow) template.HTML {
+	bars := make([]chartBar, 0, len(rows))
+	for _, r := range rows {
+		bars = append(bars, chartBar{Label: r.Name, Value: r.Commits,
+			Title: fmt.Sprintf("%s: %d commits, +%d -%d", r.Name, r.Commits, r.Added, r.Removed)})
+	}
+	return barsChart("Commits per author", bars)
+}
+
+// heatmapChart 画 7×24 的提交热力图, 颜色深浅与提交数成正比
+func heatmapChart(heatmap activityHeatmap) template.HTML {
+	maxValue := 0
+	for _, hours := range heatmap {
+		for _, n := range hours {
+			maxValue = max(maxValue, n)
+		}
+	}
+	if maxValue == 0 {
+		return noChartData
+	}
+	const left, top, cell = 40, 16, 26
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img" aria-label="Activity by weekday and hour">`, left+24*cell, top+7*cell)
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Fprintf(&sb, `<text x="%d" y="12">%02d</text>`, left+hour*cell, hour)
+	}
+	for day, hours := range heatmap {
+		fmt.Fprintf(&sb, `<text x="0" y="%d">%s</text>`, top+day*cell+17, heatmapWeekdays[day])
+		for hour, n := range hours {
+			fill := `fill="#ebedf0"`
+			if n > 0 {
+				fill = fmt.Sprintf(`fill="#216e39" fill-opacity="%.2f"`, 0.2+0.8*float64(n)/float64(maxValue))
+			}
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" rx="3" %s><title>%s %02d:00: %d commits</title></rect>`,
+				left+hour*cell, top+day*cell, cell-3, cell-3, fill, heatmapWeekdays[day], hour, n)
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInsightHTMLDashboardIsSelfContained(t *testing.T) {
+	r := formatTestReport
+	r.Heatmap[4][10] = 2
+	r.TopDirectories = []statEntry{{Name: "a/very/long/directory/name/that/needs/to/be/shortened", Changes: 1}, {Name: "<b>", Changes: 3}}
+	page := renderUser(t, InsightFormatHTML, false, r)
+
+	for _, banned := range []string{"<script", "<link", "http://", "https://", "src="} {
+		if strings.Contains(page, banned) {
+			t.Errorf("html dashboard contains %q, want no external resources or scripts", banned)
+		}
+	}
+	if n := strings.Count(page, "<svg "); n != 5 {
+		t.Errorf("html dashboard has %d charts, want 5", n)
+	}
+	for _, want := range []string{
+		"<title>2026-W01: 1 commits</title>",
+		"<title>2026-W01: +10</title>",
+		"<title>Fri 10:00: 2 commits</title>",
+		"…me/that/needs/to/be/shortened</text>",
+		"&lt;b&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html dashboard is missing %q", want)
+		}
+	}
+}
+
+func TestInsightHTMLChartsHandleEmptyReports(t *testing.T) {
+	empty := insightReport{Committers: []string{"Ann"}}
+	page := renderUser(t, InsightFormatHTML, false, empty)
+	if strings.Contains(page, "<svg ") || !strings.Contains(page, "No commits.") {
+		t.Errorf("empty html dashboard should show placeholders instead of charts:\n%s", page)
+	}
+}
+
+func TestWriteInsightOutputWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := writeInsightOutput(path, []byte("<html></html>")); err != nil {
+		t.Fatalf("writeInsightOutput() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "<html></html>" {
+		t.Fatalf("report file = %q, %v", data, err)
+	}
+	if err := writeInsightOutput(filepath.Join(path, "nested"), nil); err == nil {
+		t.Fatal("writeInsightOutput(bad path) error = nil, want error")
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// languageOther 是无法识别的无扩展名文件的分类
+const languageOther = "Other"
+
+// languageByName 按文件名识别没有扩展名或扩展名有歧义的常见文件, 键为小写
+var languageByName = map[string]string{
+	"makefile":            "Makefile",
+	"gnumakefile":         "Makefile",
+	"dockerfile":          "Dockerfile",
+	"containerfile":       "Dockerfile",
+	"go.mod":              "Go Module",
+	"go.sum":              "Go Module",
+	"go.work":             "Go Module",
+	"cmakelists.txt":      "CMake",
+	"jenkinsfile":         "Groovy",
+	"vagrantfile":         "Ruby",
+	"gemfile":             "Ruby",
+	"rakefile":            "Ruby",
+	"procfile":            "Procfile",
+	"license":             "Text",
+	"copying":             "Text",
+	"notice":              "Text",
+	"authors":             "Text",
+	"readme":              "Text",
+	"changelog":           "Text",
+	"codeowners":          "CODEOWNERS",
+	".gitignore":          "Git Config",
+	".gitattributes":      "Git Config",
+	".gitmodules":         "Git Config",
+	".mailmap":            "Git Config",
+	".dockerignore":       "Dockerfile",
+	".editorconfig":       "EditorConfig",
+	"package.json":        "npm",
+	"package-lock.json":   "npm",
+	"yarn.lock":           "npm",
+	"pnpm-lock.yaml":      "npm",
+	"cargo.lock":          "Cargo",
+	"cargo.toml":          "Cargo",
+	"pyproject.toml":      "Python",
+	"requirements.txt":    "Python",
+	"build.gradle":        "Gradle",
+	"settings.gradle":     "Gradle",
+	"build.gradle.kts":    "Gradle",
+	"settings.gradle.kts": "Gradle",
+	"pom.xml":             "Maven",
+}
+
+// languageByExtension 将小写扩展名映射到语言
+var languageByExtension = map[string]string{
+	"go":         "Go",
+	"md":         "Markdown",
+	"markdown":   "Markdown",
+	"mdx":        "Markdown",
+	"rst":        "reStructuredText",
+	"adoc":       "AsciiDoc",
+	"txt":        "Text",
+	"yml":        "YAML",
+	"yaml":       "YAML",
+	"json":       "JSON",
+	"toml":       "TOML",
+	"ini":        "INI",
+	"xml":        "XML",
+	"csv":        "CSV",
+	"proto":      "Protocol Buffers",
+	"sql":        "SQL",
+	"graphql":    "GraphQL",
+	"gql":        "GraphQL",
+	"js":         "JavaScript",
+	"mjs":        "JavaScript",
+	"cjs":        "JavaScript",
+	"jsx":        "JavaScript",
+	"ts":         "TypeScript",
+	"mts":        "TypeScript",
+	"cts":        "TypeScript",
+	"tsx":        "TypeScript",
+	"vue":        "Vue",
+	"svelte":     "Svelte",
+	"html":       "HTML",
+	"htm":        "HTML",
+	"tmpl":       "Template",
+	"gotmpl":     "Template",
+	"css":        "CSS",
+	"scss":       "SCSS",
+	"sass":       "SCSS",
+	"less":       "Less",
+	"py":         "Python",
+	"pyi":        "Python",
+	"ipynb":      "Jupyter Notebook",
+	"rb":         "Ruby",
+	"rs":         "Rust",
+	"java":       "Java",
+	"kt":         "Kotlin",
+	"kts":        "Kotlin",
+	"scala":      "Scala",
+	"groovy":     "Groovy",
+	"gradle":     "Gradle",
+	"c":          "C",
+	"h":          "C",
+	"cc":         "C++",
+	"cpp":        "C++",
+	"cxx":        "C++",
+	"hh":         "C++",
+	"hpp":        "C++",
+	"hxx":        "C++",
+	"m":          "Objective-C",
+	"mm":         "Objective-C",
+	"cs":         "C#",
+	"fs":         "F#",
+	"swift":      "Swift",
+	"dart":       "Dart",
+	"php":        "PHP",
+	"pl":         "Perl",
+	"lua":        "Lua",
+	"r":          "R",
+	"jl":         "Julia",
+	"ex":         "Elixir",
+	"exs":        "Elixir",
+	"erl":        "Erlang",
+	"hs":         "Haskell",
+	"clj":        "Clojure",
+	"zig":        "Zig",
+	"nim":        "Nim",
+	"sh":         "Shell",
+	"bash":       "Shell",
+	"zsh":        "Shell",
+	"fish":       "Shell",
+	"ps1":        "PowerShell",
+	"bat":        "Batch",
+	"cmd":        "Batch",
+	"tf":         "Terraform",
+	"tfvars":     "Terraform",
+	"hcl":        "HCL",
+	"nix":        "Nix",
+	"mk":         "Makefile",
+	"cmake":      "CMake",
+	"dockerfile": "Dockerfile",
+	"png":        "Image",
+	"jpg":        "Image",
+	"jpeg":       "Image",
+	"gif":        "Image",
+	"webp":       "Image",
+	"ico":        "Image",
+	"bmp":        "Image",
+	"svg":        "SVG",
+	"pdf":        "PDF",
+	"woff":       "Font",
+	"woff2":      "Font",
+	"ttf":        "Font",
+	"otf":        "Font",
+	"zip":        "Archive",
+	"gz":         "Archive",
+	"tgz":        "Archive",
+	"tar":        "Archive",
+	"lock":       "Lockfile",
+}
+
+// getFileExtension 获取文件名的扩展名, 不含点; 目录名中的点和隐藏文件开头的点不算扩展名, 没有扩展名时返回 unknown
+func getFileExtension(file string) string {
+	base := strings.TrimPrefix(path.Base(file), ".")
+	if i := strings.LastIndex(base, "."); i >= 0 && i < len(base)-1 {
+		return base[i+1:]
+	}
+	return "unknown"
+}
+
+// languageOf 按文件名和扩展名判断文件的语言, 未知扩展名返回 *.ext, 没有扩展名时返回 Other
+func languageOf(file string) string {
+	base := strings.ToLower(path.Base(file))
+	if lang, ok := languageByName[base]; ok {
+		return lang
+	}
+	// Dockerfile.dev、Makefile.linux 这样的变体
+	if name, _, ok := strings.Cut(base, "."); ok && name != "" {
+		if lang, ok := languageByName[name]; ok && (lang == "Makefile" || lang == "Dockerfile") {
+			return lang
+		}
+	}
+	ext := getFileExtension(file)
+	if ext == "unknown" {
+		return languageOther
+	}
+	if lang, ok := languageByExtension[strings.ToLower(ext)]; ok {
+		return lang
+	}
+	return "*." + ext
+}
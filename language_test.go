@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestLanguageOf(t *testing.T) {
+	for file, want := range map[string]string{
+		"main.go":               "Go",
+		"docs/guide.md":         "Markdown",
+		"docs/guide.markdown":   "Markdown",
+		"Makefile":              "Makefile",
+		"build/Makefile.linux":  "Makefile",
+		"deploy/Dockerfile.dev": "Dockerfile",
+		"go.sum":                "Go Module",
+		"web/App.TSX":           "TypeScript",
+		".github/ci.yml":        "YAML",
+		".gitignore":            "Git Config",
+		"v1.2/LICENSE":          "Text",
+		"v1.2/run":              "Other",
+		"data/dump.xyz":         "*.xyz",
+		".eslintrc.json":        "JSON",
+	} {
+		if got := languageOf(file); got != want {
+			t.Errorf("languageOf(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestGetFileExtensionIgnoresDottedDirectories(t *testing.T) {
+	for file, want := range map[string]string{"a.b/c": "unknown", "a.b/c.go": "go", ".bashrc": "unknown", "x.tar.gz": "gz", "trailing.": "unknown"} {
+		if got := getFileExtension(file); got != want {
+			t.Errorf("getFileExtension(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
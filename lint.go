@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/khicago/irr"
+)
+
+// lintSchemaVersion 是 lint --format json 输出的版本
+const lintSchemaVersion = 1
+
+const (
+	LintStyleConventional = "conventional"
+	LintStyleFree         = "free"
+
+	defaultLintMaxSubject = 72
+	defaultLintBodyLines  = 100
+	// defaultLintRange 是未指定 --range 时检查的提交: 尚未推送到上游的提交
+	defaultLintRange = "@{upstream}..HEAD"
+)
+
+// 规则名和扣分, 每个提交从 100 分开始扣
+const (
+	LintRuleSubjectEmpty  = "subject-empty"
+	LintRuleSubjectLength = "subject-length"
+	LintRuleImperative    = "imperative"
+	LintRuleStyle         = "style"
+	LintRuleType          = "type"
+	LintRuleBody          = "body"
+	LintRuleVague         = "vague"
+	LintRuleJudge         = "judge"
+
+	lintPenaltySubjectEmpty  = 100
+	lintPenaltySubjectLength = 10
+	lintPenaltyImperative    = 15
+	lintPenaltyStyle         = 20
+	lintPenaltyType          = 10
+	lintPenaltyBody          = 15
+	lintPenaltyVague         = 25
+	// lintPenaltyJudgePoint 是模型每少给一分 (满分 5, 4 分及以上不扣分) 扣的分数
+	lintPenaltyJudgePoint = 10
+)
+
+// defaultLintTypes 是 conventional 风格允许的 type, 与 comment 的默认提示词一致
+var defaultLintTypes = []string{"feat", "fix", "refactor", "perf", "test", "docs", "style", "build", "ci", "chore", "revert"}
+
+// lintConfig 是配置文件中 lint 的部分, 描述仓库约定的提交信息风格
+type lintConfig struct {
+	// Style 取值 conventional (默认) 或 free, free 不检查标题格式
+	Style string `yaml:"style,omitempty"`
+	// Types 是 conventional 风格允许的 type, 为空时使用 defaultLintTypes
+	Types []string `yaml:"types,omitempty"`
+	// MaxSubject 是标题的最大字符数, 默认 72
+	MaxSubject int `yaml:"max_subject,omitempty"`
+	// BodyLines 是需要正文的变更行数 (新增加删除), 默认 100
+	BodyLines int `yaml:"body_lines,omitempty"`
+	// Threshold 是最低分数, 有提交低于它时 lint 以非零状态退出; 0 表示只报告
+	Threshold int `yaml:"threshold,omitempty"`
+}
+
+// withDefaults 补全未配置的项并检查取值
+func (c lintConfig) withDefaults() (lintConfig, error) {
+	if c.Style == "" {
+		c.Style = LintStyleConventional
+	}
+	if c.Style != LintStyleConventional && c.Style != LintStyleFree {
+		return c, irr.Error("unknown lint style %q, want %s or %s", c.Style, LintStyleConventional, LintStyleFree)
+	}
+	if len(c.Types) == 0 {
+		c.Types = defaultLintTypes
+	}
+	if c.MaxSubject <= 0 {
+		c.MaxSubject = defaultLintMaxSubject
+	}
+	if c.BodyLines <= 0 {
+		c.BodyLines = defaultLintBodyLines
+	}
+	if c.Threshold < 0 || c.Threshold > 100 {
+		return c, irr.Error("lint threshold must be between 0 and 100, got %d", c.Threshold)
+	}
+	return c, nil
+}
+
+// lintOptions 汇总 lint 子命令的输入
+type lintOptions struct {
+	// Range 是 git log 能识别的版本范围, 为空时检查尚未推送的提交
+	Range string
+	// Threshold 覆盖配置中的 lint.threshold, 为 0 时使用配置
+	Threshold int
+	// Judge 请模型对照 diff 评判每条提交信息
+	Judge      bool
+	Format     string
+	Output     string
+	ConfigPath string
+	Retry      retryPolicy
+	// Audit 和 Usage 与 comment 相同, 记录 Judge 的模型调用
+	Audit *auditLog
+	Usage *usageLedger
+}
+
+// lintFinding 是提交信息的一个问题
+type lintFinding struct {
+	Rule    string `json:"rule"`
+	Penalty int    `json:"penalty"`
+	Message string `json:"message"`
+}
+
+// lintResult 是一个提交的得分和问题
+type lintResult struct {
+	Hash     string        `json:"hash"`
+	Author   string        `json:"author"`
+	Date     string        `json:"date"`
+	Subject  string        `json:"subject"`
+	Added    int           `json:"added"`
+	Removed  int           `json:"removed"`
+	Score    int           `json:"score"`
+	Findings []lintFinding `json:"findings"`
+}
+
+// lintReport 是 lint 子命令的输出, Results 按提交从新到旧排列
+type lintReport struct {
+	SchemaVersion  int          `json:"schema_version"`
+	Range          string       `json:"range"`
+	Style          string       `json:"style"`
+	Threshold      int          `json:"threshold"`
+	Judge          bool         `json:"judge"`
+	Commits        int          `json:"commits"`
+	AverageScore   float64      `json:"average_score"`
+	BelowThreshold int          `json:"below_threshold"`
+	Rules          []countEntry `json:"rules"`
+	Results        []lintResult `json:"results"`
+}
+
+var (
+	// lintTrailerPattern 匹配 Signed-off-by: 这样的 trailer 行, 只有 trailer 的正文不算正文
+	lintTrailerPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z-]*: `)
+
+	// lintVagueSubjects 是没有说明改了什么的标题
+	lintVagueSubjects = map[string]bool{
+		"fix": true, "fixes": true, "fixed": true, "fix bug": true, "fix bugs": true, "bug fix": true, "bugfix": true,
+		"update": true, "updates": true, "updated": true, "change": true, "changes": true, "some changes": true,
+		"wip": true, "tmp": true, "temp": true, "test": true, "tests": true, "misc": true, "stuff": true, "minor": true,
+		"minor changes": true, "minor fix": true, "small fix": true, "cleanup": true, "clean up": true, "refactor": true,
+		"refactoring": true, "improvements": true, "save": true, "commit": true, "done": true, "more": true, "again": true,
+	}
+
+	// lintCommonVerbs 是提交标题里常见的动词原形, 只有能还原到其中某个词的 -ed、-ing、-s 形式才算非祈使语气;
+	// log、test 这类常作名词的词不在其中, 以免 logging、tests 被误判
+	lintCommonVerbs = map[string]bool{
+		"add": true, "fix": true, "update": true, "remove": true, "refactor": true, "change": true, "improve": true,
+		"implement": true, "support": true, "allow": true, "make": true, "use": true, "move": true, "rename": true,
+		"bump": true, "handle": true, "drop": true, "clean": true, "create": true, "delete": true, "introduce": true,
+		"replace": true, "set": true, "show": true, "document": true, "enable": true, "disable": true, "extract": true,
+		"merge": true, "prevent": true, "reduce": true, "simplify": true, "split": true, "avoid": true, "ensure": true,
+		"expose": true, "return": true, "skip": true, "switch": true, "upgrade": true, "validate": true, "correct": true,
+		"adjust": true, "convert": true, "ignore": true, "increase": true, "optimize": true, "print": true, "read": true,
+		"write": true, "pass": true, "revert": true, "release": true, "format": true,
+	}
+
+	// lintPastIrregular 是常见的不规则过去式
+	lintPastIrregular = map[string]bool{
+		"made": true, "wrote": true, "built": true, "ran": true, "did": true, "took": true, "gave": true, "got": true,
+		"rewrote": true, "brought": true, "kept": true, "left": true,
+	}
+)
+
+// lint 检查范围内提交信息的质量并输出, 有提交低于阈值时在输出之后返回错误
+func lint(opts lintOptions) error {
+	conf, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if opts.Judge {
+		if opts.Audit, err = newAuditLog(conf.Audit); err != nil {
+			return err
+		}
+		if opts.Usage, err = newUsageLedger(conf.Usage); err != nil {
+			return err
+		}
+	}
+	report, err := runLint(context.Background(), "", opts, conf, SimpleQuestion)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err = writeLint(&out, opts.Format, report); err != nil {
+		return err
+	}
+	if err = writeInsightOutput(opts.Output, out.Bytes()); err != nil {
+		return err
+	}
+	if report.BelowThreshold > 0 {
+		return irr.Error("%d of %d commits scored below %d", report.BelowThreshold, report.Commits, report.Threshold)
+	}
+	return nil
+}
+
+// runLint 在 dir 中为范围内的每个非合并提交打分; doubaoAsk 是 Judge 时 doubao 后端实际发起请求的函数
+func runLint(ctx context.Context, dir string, opts lintOptions, conf appConfig, doubaoAsk askQuestionFunc) (lintReport, error) {
+	if opts.Format == "" {
+		opts.Format = InsightFormatText
+	}
+	if opts.Format != InsightFormatText && opts.Format != InsightFormatJSON {
+		return lintReport{}, irr.Error("unknown lint format %q, want %s or %s", opts.Format, InsightFormatText, InsightFormatJSON)
+	}
+	rules := conf.Lint
+	if opts.Threshold != 0 {
+		rules.Threshold = opts.Threshold
+	}
+	rules, err := rules.withDefaults()
+	if err != nil {
+		return lintReport{}, err
+	}
+
+	rng := opts.Range
+	if rng == "" {
+		if _, err = executeGitCommandIn(dir, "rev-parse", "--verify", "-q", "@{upstream}"); err != nil {
+			return lintReport{}, irr.Error("the current branch has no upstream, pass --range such as origin/main..HEAD")
+		}
+		rng = defaultLintRange
+	}
+	if strings.HasPrefix(rng, "-") {
+		return lintReport{}, irr.Error("invalid range %q", rng)
+	}
+
+	var judge *lintJudge
+	if opts.Judge {
+		if judge, err = newLintJudge(opts, conf.Providers, doubaoAsk); err != nil {
+			return lintReport{}, err
+		}
+	}
+
+	commits, err := loadCommits(dir, "--no-merges", rng, "--")
+	if err != nil {
+		return lintReport{}, err
+	}
+
+	report := lintReport{
+		SchemaVersion: lintSchemaVersion,
+		Range:         rng,
+		Style:         rules.Style,
+		Threshold:     rules.Threshold,
+		Judge:         opts.Judge,
+		Commits:       len(commits),
+		Results:       make([]lintResult, 0, len(commits)),
+	}
+	counts := make(map[string]int)
+	total := 0
+	for _, c := range commits {
+		findings := lintCommitMessage(c, rules)
+		if judge != nil {
+			finding, ok, err := judge.judge(ctx, dir, c)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "commitron: judge skipped %s: %v\n", c.ShortHash(), err)
+			} else if ok {
+				findings = append(findings, finding)
+			}
+		}
+		result := lintResult{
+			Hash:     c.ShortHash(),
+			Author:   c.AuthorName,
+			Date:     c.AuthorDate.Format("2006-01-02"),
+			Subject:  c.Subject,
+			Added:    c.Added(),
+			Removed:  c.Removed(),
+			Score:    100,
+			Findings: findings,
+		}
+		for _, f := range findings {
+			result.Score -= f.Penalty
+			counts[f.Rule]++
+		}
+		result.Score = max(result.Score, 0)
+		if result.Score < rules.Threshold {
+			report.BelowThreshold++
+		}
+		total += result.Score
+		report.Results = append(report.Results, result)
+	}
+	if len(commits) > 0 {
+		report.AverageScore = round2(float64(total) / float64(len(commits)))
+	}
+	report.Rules = countEntries(counts, len(commits), 0)
+	return report, nil
+}
+
+// lintCommitMessage 按规则检查一条提交信息, 不需要模型
+func lintCommitMessage(c commitRecord, rules lintConfig) []lintFinding {
+	findings := make([]lintFinding, 0)
+	subject := strings.TrimSpace(c.Subject)
+	if subject == "" {
+		return append(findings, lintFinding{Rule: LintRuleSubjectEmpty, Penalty: lintPenaltySubjectEmpty, Message: "the commit message is empty"})
+	}
+	if n := utf8.RuneCountInString(subject); n > rules.MaxSubject {
+		findings = append(findings, lintFinding{Rule: LintRuleSubjectLength, Penalty: lintPenaltySubjectLength,
+			Message: fmt.Sprintf("subject is %d characters, keep it within %d", n, rules.MaxSubject)})
+	}
+
+	// git revert 生成的标题不检查风格和语气
+	generated := isGeneratedSubject(subject)
+	description := subject
+	if cc, ok := parseConventionalSubject(subject); ok {
+		_, description, _ = strings.Cut(subject, ":")
+		description = strings.TrimSpace(description)
+		if rules.Style == LintStyleConventional && !containsString(rules.Types, cc.Type) {
+			findings = append(findings, lintFinding{Rule: LintRuleType, Penalty: lintPenaltyType,
+				Message: fmt.Sprintf("type %q is not one of %s", cc.Type, strings.Join(rules.Types, ", "))})
+		}
+	} else if rules.Style == LintStyleConventional && !generated {
+		findings = append(findings, lintFinding{Rule: LintRuleStyle, Penalty: lintPenaltyStyle,
+			Message: "subject does not follow Conventional Commits, e.g. \"feat(api): add pagination\""})
+	}
+
+	if !generated {
+		if word, ok := nonImperativeWord(description); ok {
+			findings = append(findings, lintFinding{Rule: LintRuleImperative, Penalty: lintPenaltyImperative,
+				Message: fmt.Sprintf("subject starts with %q; use the imperative mood, as in \"add\" rather than \"added\" or \"adds\"", word)})
+		}
+		if isVagueDescription(description) {
+			findings = append(findings, lintFinding{Rule: LintRuleVague, Penalty: lintPenaltyVague,
+				Message: fmt.Sprintf("subject %q does not say what changed", description)})
+		}
+	}
+
+	if size := c.Added() + c.Removed(); size > rules.BodyLines && !hasMessageBody(c.Body) {
+		findings = append(findings, lintFinding{Rule: LintRuleBody, Penalty: lintPenaltyBody,
+			Message: fmt.Sprintf("%d changed lines without a body explaining why (over %d needs one)", size, rules.BodyLines)})
+	}
+	return findings
+}
+
+// nonImperativeWord 判断描述的第一个词是否为常见动词的过去式、进行时或第三人称单数, 返回这个词
+func nonImperativeWord(description string) (string, bool) {
+	fields := strings.Fields(description)
+	if len(fields) == 0 {
+		return "", false
+	}
+	word := strings.ToLower(strings.Trim(fields[0], ".,:;!\"'`"))
+	if lintPastIrregular[word] {
+		return fields[0], true
+	}
+	for _, stem := range verbStems(word) {
+		if lintCommonVerbs[stem] {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
+
+// verbStems 返回 word 去掉 -ed、-ing、-es、-s 后可能的原形, 如 removed→remove、dropping→drop、simplifies→simplify
+func verbStems(word string) []string {
+	var stems []string
+	for _, suffix := range []string{"ed", "ing", "es", "s"} {
+		stem, ok := strings.CutSuffix(word, suffix)
+		if !ok || stem == "" {
+			continue
+		}
+		stems = append(stems, stem)
+		if n := len(stem); suffix == "ed" || suffix == "ing" {
+			stems = append(stems, stem+"e")
+			if n >= 2 && stem[n-1] == stem[n-2] {
+				stems = append(stems, stem[:n-1])
+			}
+		}
+		if suffix == "ed" || suffix == "es" {
+			if base, ok := strings.CutSuffix(stem, "i"); ok {
+				stems = append(stems, base+"y")
+			}
+		}
+	}
+	return stems
+}
+
+// isVagueDescription 判断描述是否为 lintVagueSubjects 中的笼统标题, 如 update、wip
+func isVagueDescription(description string) bool {
+	normalized := strings.ToLower(strings.Trim(strings.TrimSpace(description), ".!"))
+	return lintVagueSubjects[normalized]
+}
+
+// hasMessageBody 判断正文中是否有 trailer 之外的内容
+func hasMessageBody(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !lintTrailerPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// lintJudgePrompt 请模型对照 diff 给提交信息打 1-5 分
+const lintJudgePrompt = `You review git commit messages. Compare the commit message with the diff and rate how accurately and usefully the message describes the change, from 1 (wrong or meaningless) to 5 (accurate, specific, explains why when it matters).
+
+Reply with exactly two lines and nothing else:
+SCORE: <1-5>
+REASON: <one sentence>`
+
+var (
+	lintJudgeScorePattern  = regexp.MustCompile(`(?i)score\s*[:=]\s*([1-5])`)
+	lintJudgeReasonPattern = regexp.MustCompile(`(?i)reason\s*[:=]\s*(.+)`)
+)
+
+// lintJudge 用 fallback 链中的模型后端评判提交信息
+type lintJudge struct {
+	providers []resolvedProvider
+	opts      lintOptions
+}
+
+// newLintJudge 解析 fallback 链, offline 后端不能评判所以跳过; 没有可用后端时返回错误
+func newLintJudge(opts lintOptions, chain []providerConfig, doubaoAsk askQuestionFunc) (*lintJudge, error) {
+	if len(chain) == 0 {
+		chain = defaultProviders
+	}
+	judge := &lintJudge{opts: opts}
+	var failures []string
+	for _, conf := range chain {
+		if conf.Type == ProviderTypeOffline {
+			continue
+		}
+		p, err := resolveProvider(conf, commentOptions{}, doubaoAsk)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", conf.displayName(), err))
+			continue
+		}
+		judge.providers = append(judge.providers, p)
+	}
+	if len(judge.providers) == 0 {
+		return nil, irr.Error("--judge needs a model provider, none is available: %s", strings.Join(failures, "; "))
+	}
+	return judge, nil
+}
+
+// judge 请模型评判提交信息, 低于 4 分时返回一个问题; 后端依次尝试, 都失败时返回错误
+func (j *lintJudge) judge(ctx context.Context, dir string, c commitRecord) (lintFinding, bool, error) {
+	diff, err := executeGitCommandIn(dir, "show", "--format=", "--patch", "--no-color", "--no-ext-diff", c.Hash)
+	if err != nil {
+		return lintFinding{}, false, irr.Wrap(err, "failed to read the diff of %s", c.ShortHash())
+	}
+	message := c.Subject
+	if c.Body != "" {
+		message += "\n\n" + c.Body
+	}
+	req := modelRequest{Prompt: lintJudgePrompt}
+	req.Question, req.Compaction = compactQuestion(diff, readGitBlob)
+	req.Question = "Commit message:\n" + message + "\n\n" + req.Question
+	req = redactRequest(req)
+
+	var failures []string
+	for _, p := range j.providers {
+		ask := p.ask
+		if j.opts.Usage != nil {
+			ask = j.opts.Usage.wrap(ask, p.providerConfig)
+		}
+		if j.opts.Audit != nil {
+			ask = j.opts.Audit.wrap(ask, p, diff, req)
+		}
+		answer, err := withRetry(ask, j.opts.Retry)(ctx, p.Endpoint, req.Prompt, req.Question)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", p.displayName(), err))
+			continue
+		}
+		m := lintJudgeScorePattern.FindStringSubmatch(answer)
+		if m == nil {
+			failures = append(failures, fmt.Sprintf("%s: no score in %q", p.displayName(), truncateRunes(answer, 80)))
+			continue
+		}
+		score, _ := strconv.Atoi(m[1])
+		if score >= 4 {
+			return lintFinding{}, false, nil
+		}
+		reason := "the message does not match the diff"
+		if r := lintJudgeReasonPattern.FindStringSubmatch(answer); r != nil {
+			reason = strings.TrimSpace(r[1])
+		}
+		return lintFinding{Rule: LintRuleJudge, Penalty: (5 - score) * lintPenaltyJudgePoint,
+			Message: fmt.Sprintf("%s rated %d/5: %s", p.displayName(), score, reason)}, true, nil
+	}
+	return lintFinding{}, false, irr.Error("all providers failed: %s", strings.Join(failures, "; "))
+}
+
+// writeLint 按 format 输出 lint 报告
+func writeLint(w io.Writer, format string, r lintReport) error {
+	if format == InsightFormatJSON {
+		return writeJSON(w, r)
+	}
+	sb := strings.Builder{}
+	sb.WriteString("\n# Commit message lint\n\n")
+	sb.WriteString(fmt.Sprintf("Range %s, style %s", r.Range, r.Style))
+	if r.Threshold > 0 {
+		sb.WriteString(fmt.Sprintf(", threshold %d", r.Threshold))
+	}
+	if r.Judge {
+		sb.WriteString(", judged by a model")
+	}
+	sb.WriteString(".\n\n")
+	for _, result := range r.Results {
+		sb.WriteString(fmt.Sprintf("%3d  %s  %s\n", result.Score, result.Hash, result.Subject))
+		for _, f := range result.Findings {
+			sb.WriteString(fmt.Sprintf("     - %s (-%d): %s\n", f.Rule, f.Penalty, f.Message))
+		}
+	}
+	if r.Commits == 0 {
+		sb.WriteString("No commits to lint.\n")
+		_, err := fmt.Fprintln(w, sb.String())
+		return err
+	}
+	sb.WriteString(fmt.Sprintf("\nAverage score %s over %d commits", formatFloat(r.AverageScore), r.Commits))
+	if r.Threshold > 0 {
+		sb.WriteString(fmt.Sprintf("; %d below %d", r.BelowThreshold, r.Threshold))
+	}
+	sb.WriteString(".\n")
+	if len(r.Rules) > 0 {
+		parts := make([]string, 0, len(r.Rules))
+		for _, rule := range r.Rules {
+			parts = append(parts, fmt.Sprintf("%s %d", rule.Name, rule.Commits))
+		}
+		sb.WriteString("Findings: " + strings.Join(parts, ", ") + "\n")
+	}
+	_, err := fmt.Fprintln(w, sb.String())
+	return err
+}
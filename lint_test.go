@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lintRules(t *testing.T, conf lintConfig) lintConfig {
+	t.Helper()
+	rules, err := conf.withDefaults()
+	if err != nil {
+		t.Fatalf("withDefaults() error = %v", err)
+	}
+	return rules
+}
+
+func findingRules(findings []lintFinding) string {
+	var rules []string
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+	return strings.Join(rules, ",")
+}
+
+func TestLintCommitMessageRules(t *testing.T) {
+	rules := lintRules(t, lintConfig{})
+	big := []fileStat{{Path: "a.go", Added: 90, Removed: 20}}
+	for _, tc := range []struct {
+		name string
+		c    commitRecord
+		want string
+	}{
+		{"good", commitRecord{Subject: "feat(api): add cursor pagination"}, ""},
+		{"empty", commitRecord{Subject: " "}, LintRuleSubjectEmpty},
+		{"long", commitRecord{Subject: "fix: " + strings.Repeat("handle ", 12)}, LintRuleSubjectLength},
+		{"past tense", commitRecord{Subject: "fix: fixed the nil map in the cache"}, LintRuleImperative},
+		{"third person", commitRecord{Subject: "feat: adds retry to uploads"}, LintRuleImperative},
+		{"imperative ed", commitRecord{Subject: "perf: speed up blame parsing"}, ""},
+		{"gerund", commitRecord{Subject: "fix: removing the stale lock"}, LintRuleImperative},
+		{"doubled consonant", commitRecord{Subject: "chore: dropped the old flag"}, LintRuleImperative},
+		{"adjective", commitRecord{Subject: "feat: embedded cache"}, ""},
+		{"participle noun", commitRecord{Subject: "fix: nested loops"}, ""},
+		{"prefixed participle", commitRecord{Subject: "fix: unused variable warning"}, ""},
+		{"shared", commitRecord{Subject: "refactor: shared helpers"}, ""},
+		{"gerund noun", commitRecord{Subject: "fix: logging crashes on nil"}, ""},
+		{"plural noun", commitRecord{Subject: "docs: tests layout"}, ""},
+		{"single word", commitRecord{Subject: "feat(api): pagination"}, ""},
+		{"not conventional", commitRecord{Subject: "Add cursor pagination"}, LintRuleStyle},
+		{"unknown type", commitRecord{Subject: "feature: add cursor pagination"}, LintRuleType},
+		{"vague", commitRecord{Subject: "fix: update"}, LintRuleVague},
+		{"vague free text", commitRecord{Subject: "wip"}, LintRuleStyle + "," + LintRuleVague},
+		{"revert", commitRecord{Subject: `Revert "feat: add cursor pagination"`}, ""},
+		{"large without body", commitRecord{Subject: "refactor: split the parser", Body: "Signed-off-by: A <a@example.com>", Files: big}, LintRuleBody},
+		{"large with body", commitRecord{Subject: "refactor: split the parser", Body: "The parser grew too big to test.", Files: big}, ""},
+	} {
+		if got := findingRules(lintCommitMessage(tc.c, rules)); got != tc.want {
+			t.Errorf("%s: lintCommitMessage(%q) rules = %q, want %q", tc.name, tc.c.Subject, got, tc.want)
+		}
+	}
+
+	free := lintRules(t, lintConfig{Style: LintStyleFree, MaxSubject: 20})
+	if got := findingRules(lintCommitMessage(commitRecord{Subject: "Add cursor pagination to the API"}, free)); got != LintRuleSubjectLength {
+		t.Errorf("free style rules = %q, want only the subject length", got)
+	}
+	if _, err := (lintConfig{Style: "angular"}).withDefaults(); err == nil {
+		t.Error("withDefaults(style angular) error = nil, want error")
+	}
+	if _, err := (lintConfig{Threshold: 101}).withDefaults(); err == nil {
+		t.Error("withDefaults(threshold 101) error = nil, want error")
+	}
+}
+
+func newLintRepo(t *testing.T) string {
+	t.Helper()
+	at := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	return newScriptRepo(t, scriptCommit("Ann <ann@example.com>", at, "chore: initial import", scriptFile("a.go", "a\n"))+
+		scriptCommit("Ann <ann@example.com>", at.Add(time.Hour), "feat(api): add cursor pagination", scriptFile("a.go", "a\nb\n"))+
+		scriptCommit("Bob <bob@example.com>", at.Add(2*time.Hour), "updated", scriptFile("a.go", "a\nb\nc\n")))
+}
+
+func TestRunLintScoresRange(t *testing.T) {
+	dir := newLintRepo(t)
+	report, err := runLint(context.Background(), dir, lintOptions{Range: "HEAD~2..HEAD"}, appConfig{Lint: lintConfig{Threshold: 80}}, nil)
+	if err != nil {
+		t.Fatalf("runLint() error = %v", err)
+	}
+	if report.Commits != 2 || report.Results[0].Subject != "updated" || report.Results[1].Score != 100 {
+		t.Fatalf("results = %+v, want the two newest commits, newest first", report.Results)
+	}
+	if bad := report.Results[0]; bad.Score != 40 || findingRules(bad.Findings) != "style,imperative,vague" {
+		t.Errorf("bad commit = %+v, want 100-20-15-25", bad)
+	}
+	if report.AverageScore != 70 || report.BelowThreshold != 1 || report.Threshold != 80 {
+		t.Errorf("report = %+v, want average 70 with one commit below 80", report)
+	}
+
+	report, err = runLint(context.Background(), dir, lintOptions{Range: "HEAD~2..HEAD", Threshold: 30, Format: InsightFormatJSON}, appConfig{Lint: lintConfig{Threshold: 80}}, nil)
+	if err != nil || report.BelowThreshold != 0 || report.Threshold != 30 {
+		t.Errorf("runLint(--threshold 30) = %+v, %v, want the flag to override the config", report, err)
+	}
+	var sb strings.Builder
+	if err = writeLint(&sb, InsightFormatJSON, report); err != nil {
+		t.Fatalf("writeLint(json) error = %v", err)
+	}
+	var decoded map[string]any
+	if err = json.Unmarshal([]byte(sb.String()), &decoded); err != nil || decoded["schema_version"] != float64(lintSchemaVersion) {
+		t.Errorf("json output = %s, err %v", sb.String(), err)
+	}
+	sb.Reset()
+	if err = writeLint(&sb, InsightFormatText, report); err != nil {
+		t.Fatalf("writeLint(text) error = %v", err)
+	}
+	for _, want := range []string{" 40  ", "     - vague (-25): ", "Average score 70 over 2 commits; 0 below 30.", "Findings: imperative 1, style 1, vague 1"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, sb.String())
+		}
+	}
+
+	if _, err = runLint(context.Background(), dir, lintOptions{}, appConfig{}, nil); err == nil || !strings.Contains(err.Error(), "--range") {
+		t.Errorf("runLint() without an upstream error = %v, want a hint to pass --range", err)
+	}
+	if _, err = runLint(context.Background(), dir, lintOptions{Range: "HEAD", Format: "csv"}, appConfig{}, nil); err == nil {
+		t.Error("runLint(format csv) error = nil, want error")
+	}
+}
+
+func TestRunLintJudgeUsesProviders(t *testing.T) {
+	t.Setenv("VOLC_ACCESSKEY", "ak")
+	t.Setenv("VOLC_SECRETKEY", "sk")
+	t.Setenv("DOUBAO_ENDPOINT", "ep")
+	dir := newLintRepo(t)
+
+	var questions []string
+	ask := func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		questions = append(questions, question)
+		if strings.Contains(question, "Commit message:\nupdated\n") {
+			return "SCORE: 2\nREASON: the message does not mention the new line", nil
+		}
+		return "SCORE: 5\nREASON: accurate", nil
+	}
+	report, err := runLint(context.Background(), dir, lintOptions{Range: "HEAD~2..HEAD", Judge: true}, appConfig{}, ask)
+	if err != nil {
+		t.Fatalf("runLint(judge) error = %v", err)
+	}
+	if len(questions) != 2 || !strings.Contains(questions[0], "+c") {
+		t.Errorf("judge questions = %q, want each message with its diff", questions)
+	}
+	bad := report.Results[0]
+	if bad.Score != 10 || !strings.Contains(findingRules(bad.Findings), LintRuleJudge) || !strings.Contains(bad.Findings[len(bad.Findings)-1].Message, "rated 2/5") {
+		t.Errorf("judged commit = %+v, want a judge finding of -30", bad)
+	}
+	if report.Results[1].Score != 100 {
+		t.Errorf("good commit = %+v, want no judge finding", report.Results[1])
+	}
+
+	_, err = runLint(context.Background(), dir, lintOptions{Range: "HEAD", Judge: true}, appConfig{Providers: []providerConfig{{Type: ProviderTypeOffline}}}, ask)
+	if err == nil || !strings.Contains(err.Error(), "needs a model provider") {
+		t.Errorf("runLint(judge, offline only) error = %v, want an error", err)
+	}
+}
@@ -20,18 +20,40 @@ const (
 	CMDNameInstallAlias = "install_alias"
 	CMDNameComment      = "comment"
 	CMDNameInsight      = "insight"
+	CMDNameCache        = "cache"
+	CMDNameCacheClear   = "clear"
+	CMDNameUsage        = "usage"
+	CMDNameHotspots     = "hotspots"
+	CMDNameOwnership    = "ownership"
+	CMDNameLint         = "lint"
+	CMDNameSummary      = "summary"
+	CMDNameStandup      = "standup"
 )
 
 type appActions struct {
 	installAlias func() error
-	insight      func(string) error
-	comment      func(ctx context.Context, diff, ak, sk, ep, pp string) error
+	insight      func(opts insightOptions) error
+	comment      func(ctx context.Context, opts commentOptions) error
+	clearCache   func(configPath string) error
+	usage        func(since, configPath string) error
+	hotspots     func(opts hotspotOptions) error
+	ownership    func(opts ownershipOptions) error
+	lint         func(opts lintOptions) error
+	summary      func(ctx context.Context, opts summaryOptions) error
+	standup      func(ctx context.Context, opts standupOptions) error
 }
 
 var defaultAppActions = appActions{
 	installAlias: installAlias,
 	insight:      insight,
 	comment:      autoComment,
+	clearCache:   clearCache,
+	usage:        showUsage,
+	hotspots:     hotspots,
+	ownership:    ownership,
+	lint:         lint,
+	summary:      summary,
+	standup:      standup,
 }
 
 // defaultConf is the default configuration for the bot.
@@ -58,16 +80,48 @@ informative commit comments`
 
 	app.Child(CMDNameInstallAlias).Set.Usage("install the Git alias").End.Action(func(c *cli.Context) error { return actions.installAlias() })
 
-	app.Child(CMDNameInsight).Set.Usage("insight the code changes").End.Flags(
-		&cli.StringFlag{
+	app.Child(CMDNameInsight).Set.Usage("insight the code changes").End.Flags(withHistoryFilterFlags(
+		&cli.StringSliceFlag{
 			Name:     "committer",
-			Usage:    "The committer (legacy alias: --commiter)",
+			Usage:    "The committer: exact name, email, /regex/ or a key under identities in the config; repeat for several identities (legacy alias: --commiter)",
 			Aliases:  []string{"commiter"},
-			Required: true,
+			Required: false,
 		},
-	).Action(func(c *cli.Context) error {
-		committer := c.String("committer")
-		return actions.insight(committer)
+		&cli.BoolFlag{Name: "all", Usage: "Report every author in one table instead of a single committer", Required: false},
+		&cli.StringFlag{Name: "team", Usage: "Report the members listed in this YAML team file in one table", Required: false},
+		&cli.StringFlag{Name: "format", Usage: "Output format: text, json, csv, markdown or html; text is coloured only on a terminal without NO_COLOR", Value: InsightFormatText, Required: false},
+		&cli.StringFlag{Name: "tz", Usage: "Time zone for the activity heatmap, e.g. UTC, Asia/Shanghai or Local (default: each author's own zone)", Required: false},
+		&cli.StringFlag{Name: "work-hours", Usage: "Weekday working hours as START-END; commits outside count as after-hours", Value: DefaultWorkHours, Required: false},
+		&cli.StringFlag{Name: "bucket", Usage: "Group commit habits by day, week (ISO 8601) or month", Value: BucketWeek, Required: false},
+		&cli.IntFlag{Name: "dir-depth", Usage: "Group top directories by this many leading path segments", Value: 1, Required: false},
+		&cli.IntFlag{Name: "rework-days", Usage: "Count a fix as rework when it touches files changed by a feat this many days earlier", Value: DefaultReworkDays, Required: false},
+		&cli.BoolFlag{Name: "go-packages", Usage: "Group Go files by package import path, using the go.mod files at the scanned revision", Required: false},
+		&cli.StringFlag{Name: "output", Usage: "Write the report to this file instead of stdout", Aliases: []string{"o"}, Required: false},
+		&cli.StringFlag{Name: "sort", Usage: "Sort the team table by name, commits, added, removed, files, days, main, non-main, after-hours or weekend", Value: "commits", Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining identities (alternative to %s)", EnvKeyConfigPath), Required: false},
+	)...).Action(func(c *cli.Context) error {
+		opts := historyFilterOptions(c)
+		return actions.insight(insightOptions{
+			Committers: c.StringSlice("committer"),
+			ConfigPath: c.String("config"),
+			Since:      opts.Since,
+			Until:      opts.Until,
+			Ref:        opts.Ref,
+			Paths:      opts.Paths,
+			NoMerges:   opts.NoMerges,
+			Exclude:    opts.Exclude,
+			All:        c.Bool("all"),
+			TeamPath:   c.String("team"),
+			Sort:       c.String("sort"),
+			Bucket:     c.String("bucket"),
+			DirDepth:   c.Int("dir-depth"),
+			GoPackages: c.Bool("go-packages"),
+			ReworkDays: c.Int("rework-days"),
+			TimeZone:   c.String("tz"),
+			WorkHours:  c.String("work-hours"),
+			Format:     c.String("format"),
+			Output:     c.String("output"),
+		})
 	})
 
 	app.Child(CMDNameComment).Flags(
@@ -76,6 +130,13 @@ informative commit comments`
 		&cli.StringFlag{Name: "secret_key", Usage: fmt.Sprintf("Secret key for the API (alternative to %s)", coze.EnvKeyVOLCSecretKey), Aliases: []string{"sk"}, Required: false},
 		&cli.StringFlag{Name: "endpoint", Usage: fmt.Sprintf("Endpoint for generating the comment (alternative to  %s)", coze.EnvKeyDoubaoEndpoint), Aliases: []string{"e"}, Required: false},
 		&cli.StringFlag{Name: "prompt", Usage: "Custom prompt for generating the comment", Aliases: []string{"p"}, Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining the provider fallback chain (alternative to %s)", EnvKeyConfigPath), Required: false},
+		&cli.BoolFlag{Name: "dry-run", Usage: "Print the providers, prompt and question that would be sent, without contacting the model", Required: false},
+		&cli.BoolFlag{Name: "no-cache", Usage: "Neither read nor write the local response cache", Required: false},
+		&cli.BoolFlag{Name: "offline", Usage: "Generate a heuristic draft locally without contacting the model", Required: false},
+		&cli.DurationFlag{Name: "timeout", Usage: "Timeout for each model call attempt", Value: defaultRetryPolicy.Timeout, Required: false},
+		&cli.IntFlag{Name: "retries", Usage: "Retries after a timeout, rate limit (429) or server error (5xx)", Value: defaultRetryPolicy.MaxRetries, Required: false},
+		&cli.BoolFlag{Name: "no-shortcuts", Usage: "Always call the model, even for trivial changes such as gofmt, renames, dependency bumps, docs-only changes or file deletions", Required: false},
 	).Set.Custom(func(c *cli.Command) {
 		c.Usage = fmt.Sprintf(`Generate a commit comment based on the provided diff information
 
@@ -83,21 +144,186 @@ Environment Variables:
    %s	Access key for the API (alternative to -ak)
    %s	Secret key for the API (alternative to -sk)
    %s	Endpoint for the API (alternative to -endpoint)
+   %s	Config file path (alternative to -config)
 
 Example:
-   commitron %s --access_key YOUR_ACCESS_KEY --secret_key YOUR_SECRET_KEY --endpoint YOUR_MODEL_ENDPOINT --diff \"...\"`, coze.EnvKeyVOLCAccessKey, coze.EnvKeyVOLCSecretKey, coze.EnvKeyDoubaoEndpoint, CMDNameComment)
+   commitron %s --access_key YOUR_ACCESS_KEY --secret_key YOUR_SECRET_KEY --endpoint YOUR_MODEL_ENDPOINT --diff \"...\"`, coze.EnvKeyVOLCAccessKey, coze.EnvKeyVOLCSecretKey, coze.EnvKeyDoubaoEndpoint, EnvKeyConfigPath, CMDNameComment)
 	}).End.Action(func(c *cli.Context) error {
-		diffInfo := c.String("diff")
-		ak := c.String("access_key")
-		sk := c.String("secret_key")
-		ep := c.String("endpoint")
-		pp := c.String("prompt")
-		return actions.comment(c.Context, diffInfo, ak, sk, ep, pp)
+		return actions.comment(c.Context, commentOptions{
+			Diff:        c.String("diff"),
+			AccessKey:   c.String("access_key"),
+			SecretKey:   c.String("secret_key"),
+			Endpoint:    c.String("endpoint"),
+			Prompt:      c.String("prompt"),
+			NoShortcuts: c.Bool("no-shortcuts"),
+			Offline:     c.Bool("offline"),
+			Retry:       retryPolicyFromFlags(c),
+			ConfigPath:  c.String("config"),
+			NoCache:     c.Bool("no-cache"),
+			DryRun:      c.Bool("dry-run"),
+		})
+	})
+
+	app.Child(CMDNameCache).Set.Usage("manage the local response cache").End.
+		Child(CMDNameCacheClear).Set.Usage("remove all cached commit messages").End.Flags(
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining cache.dir (alternative to %s)", EnvKeyConfigPath), Required: false},
+	).Action(func(c *cli.Context) error { return actions.clearCache(c.String("config")) })
+
+	app.Child(CMDNameHotspots).Set.Usage("rank files by recent change frequency times size to guide refactoring").End.Flags(withHistoryFilterFlags(
+		&cli.IntFlag{Name: "limit", Usage: "Number of files to list", Value: defaultHotspotLimit, Required: false},
+		&cli.StringFlag{Name: "size", Usage: "Size measure: lines, or indent (total indentation depth, a complexity proxy)", Value: HotspotSizeLines, Required: false},
+		&cli.IntFlag{Name: "half-life", Usage: "Days after which a change counts half as much", Value: defaultHotspotHalfLife, Required: false},
+		&cli.IntFlag{Name: "min-authors", Usage: "Flag files changed by at least this many authors", Value: defaultHotspotMinAuthors, Required: false},
+		&cli.IntFlag{Name: "min-fixes", Usage: "Flag files changed by at least this many fix commits", Value: defaultHotspotMinFixes, Required: false},
+		&cli.StringFlag{Name: "format", Usage: "Output format: text, json, csv or markdown", Value: InsightFormatText, Required: false},
+		&cli.StringFlag{Name: "output", Usage: "Write the report to this file instead of stdout", Aliases: []string{"o"}, Required: false},
+	)...).Action(func(c *cli.Context) error {
+		return actions.hotspots(hotspotOptions{
+			Filter:     historyFilterOptions(c),
+			Limit:      c.Int("limit"),
+			Size:       c.String("size"),
+			HalfLife:   c.Int("half-life"),
+			MinAuthors: c.Int("min-authors"),
+			MinFixes:   c.Int("min-fixes"),
+			Format:     c.String("format"),
+			Output:     c.String("output"),
+		})
+	})
+
+	app.Child(CMDNameOwnership).Set.Usage("show who owns each directory, its bus factor and a suggested CODEOWNERS").End.Flags(withHistoryFilterFlags(
+		&cli.IntFlag{Name: "depth", Usage: "Group files by this many leading directories", Value: defaultOwnershipDepth, Required: false},
+		&cli.BoolFlag{Name: "no-blame", Usage: "Skip git blame and measure ownership by commits only", Required: false},
+		&cli.StringFlag{Name: "codeowners", Usage: "CODEOWNERS path, relative to the repository root, to compare with; read at the scanned revision like the defaults .github/CODEOWNERS, CODEOWNERS and docs/CODEOWNERS", Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining identities that map @handles to authors (alternative to %s)", EnvKeyConfigPath), Required: false},
+		&cli.StringFlag{Name: "format", Usage: "Output format: text, json, csv or markdown", Value: InsightFormatText, Required: false},
+		&cli.StringFlag{Name: "output", Usage: "Write the report to this file instead of stdout", Aliases: []string{"o"}, Required: false},
+	)...).Action(func(c *cli.Context) error {
+		return actions.ownership(ownershipOptions{
+			Filter:         historyFilterOptions(c),
+			Depth:          c.Int("depth"),
+			NoBlame:        c.Bool("no-blame"),
+			CodeownersPath: c.String("codeowners"),
+			ConfigPath:     c.String("config"),
+			Format:         c.String("format"),
+			Output:         c.String("output"),
+		})
+	})
+
+	app.Child(CMDNameLint).Set.Usage("score the commit messages in a range, for review or a pre-push hook").End.Flags(
+		&cli.StringFlag{Name: "range", Usage: "Commits to check, e.g. origin/main..HEAD (default: commits not yet pushed to the upstream branch)", Required: false},
+		&cli.IntFlag{Name: "threshold", Usage: "Exit with status 1 when a commit scores below this (0-100; default: lint.threshold in the config, 0 only reports)", Required: false},
+		&cli.BoolFlag{Name: "judge", Usage: "Also ask the configured model providers whether each message matches its diff", Required: false},
+		&cli.StringFlag{Name: "format", Usage: "Output format: text or json", Value: InsightFormatText, Required: false},
+		&cli.StringFlag{Name: "output", Usage: "Write the report to this file instead of stdout", Aliases: []string{"o"}, Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining the lint style and providers (alternative to %s)", EnvKeyConfigPath), Required: false},
+		&cli.DurationFlag{Name: "timeout", Usage: "Timeout for each model call attempt with --judge", Value: defaultRetryPolicy.Timeout, Required: false},
+		&cli.IntFlag{Name: "retries", Usage: "Retries after a timeout, rate limit (429) or server error (5xx) with --judge", Value: defaultRetryPolicy.MaxRetries, Required: false},
+	).Action(func(c *cli.Context) error {
+		return actions.lint(lintOptions{
+			Range:      c.String("range"),
+			Threshold:  c.Int("threshold"),
+			Judge:      c.Bool("judge"),
+			Format:     c.String("format"),
+			Output:     c.String("output"),
+			ConfigPath: c.String("config"),
+			Retry:      retryPolicyFromFlags(c),
+		})
+	})
+
+	app.Child(CMDNameSummary).Set.Usage("ask the model for a narrative summary of one author's work in a period").End.Flags(withHistoryFilterFlags(
+		&cli.StringSliceFlag{Name: "committer", Usage: "The author: exact name, email, /regex/ or a key under identities in the config; repeat for several identities", Required: true},
+		&cli.StringFlag{Name: "prompt", Usage: "Custom prompt for writing the summary", Aliases: []string{"p"}, Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining identities and the provider fallback chain (alternative to %s)", EnvKeyConfigPath), Required: false},
+		&cli.BoolFlag{Name: "dry-run", Usage: "Print the providers, prompt and question that would be sent, without contacting the model", Required: false},
+		&cli.BoolFlag{Name: "no-cache", Usage: "Neither read nor write the local response cache", Required: false},
+		&cli.BoolFlag{Name: "offline", Usage: "Group the commits by type locally without contacting the model", Required: false},
+		&cli.DurationFlag{Name: "timeout", Usage: "Timeout for each model call attempt", Value: defaultRetryPolicy.Timeout, Required: false},
+		&cli.IntFlag{Name: "retries", Usage: "Retries after a timeout, rate limit (429) or server error (5xx)", Value: defaultRetryPolicy.MaxRetries, Required: false},
+		&cli.StringFlag{Name: "output", Usage: "Write the summary to this file instead of stdout", Aliases: []string{"o"}, Required: false},
+	)...).Action(func(c *cli.Context) error {
+		filter := historyFilterOptions(c)
+		filter.Committers = c.StringSlice("committer")
+		return actions.summary(c.Context, summaryOptions{
+			Filter:     filter,
+			Prompt:     c.String("prompt"),
+			ConfigPath: c.String("config"),
+			DryRun:     c.Bool("dry-run"),
+			Offline:    c.Bool("offline"),
+			NoCache:    c.Bool("no-cache"),
+			Retry:      retryPolicyFromFlags(c),
+			Output:     c.String("output"),
+		})
+	})
+
+	app.Child(CMDNameStandup).Set.Usage("draft a yesterday / today / blockers standup note from your recent commits and work in progress").End.Flags(
+		&cli.StringSliceFlag{Name: "repo", Usage: "Local repository to include (repeatable; default: standup.repos in the config, or the current repository)", Required: false},
+		&cli.StringSliceFlag{Name: "committer", Usage: "The author: exact name, email, /regex/ or a key under identities in the config (default: git config user.email of each repository)", Required: false},
+		&cli.StringFlag{Name: "since", Usage: "Only include commits after this date, in any format git log accepts (default: start of the previous working day)", Required: false},
+		&cli.BoolFlag{Name: "llm", Usage: "Ask the configured model providers to write the note from the collected activity", Required: false},
+		&cli.StringFlag{Name: "prompt", Usage: "Custom prompt for writing the note with --llm", Aliases: []string{"p"}, Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining repositories, identities and providers (alternative to %s)", EnvKeyConfigPath), Required: false},
+		&cli.BoolFlag{Name: "dry-run", Usage: "With --llm, print the providers, prompt and question that would be sent, without contacting the model", Required: false},
+		&cli.BoolFlag{Name: "no-cache", Usage: "Neither read nor write the local response cache", Required: false},
+		&cli.DurationFlag{Name: "timeout", Usage: "Timeout for each model call attempt with --llm", Value: defaultRetryPolicy.Timeout, Required: false},
+		&cli.IntFlag{Name: "retries", Usage: "Retries after a timeout, rate limit (429) or server error (5xx) with --llm", Value: defaultRetryPolicy.MaxRetries, Required: false},
+		&cli.StringFlag{Name: "output", Usage: "Write the note to this file instead of stdout", Aliases: []string{"o"}, Required: false},
+	).Action(func(c *cli.Context) error {
+		return actions.standup(c.Context, standupOptions{
+			Repos:      c.StringSlice("repo"),
+			Committers: c.StringSlice("committer"),
+			Since:      c.String("since"),
+			ConfigPath: c.String("config"),
+			LLM:        c.Bool("llm"),
+			Prompt:     c.String("prompt"),
+			DryRun:     c.Bool("dry-run"),
+			NoCache:    c.Bool("no-cache"),
+			Retry:      retryPolicyFromFlags(c),
+			Output:     c.String("output"),
+		})
+	})
+
+	app.Child(CMDNameUsage).Set.Usage("summarize token usage and spend per day and per repository").End.Flags(
+		&cli.StringFlag{Name: "since", Usage: "Start of the report: YYYY-MM-DD, a number of days such as 30d, or a duration such as 72h (default: start of this month)", Required: false},
+		&cli.StringFlag{Name: "config", Usage: fmt.Sprintf("Config file defining prices and budget (alternative to %s)", EnvKeyConfigPath), Required: false},
+	).Action(func(c *cli.Context) error {
+		return actions.usage(c.String("since"), c.String("config"))
 	})
 
 	return app
 }
 
+// withHistoryFilterFlags 在 flags 之后加上读取提交历史的子命令共用的过滤参数
+func withHistoryFilterFlags(flags ...cli.Flag) []cli.Flag {
+	return append(flags,
+		&cli.StringFlag{Name: "since", Usage: "Only count commits after this date, in any format git log accepts (e.g. 2026-01-01, \"3 months ago\")", Required: false},
+		&cli.StringFlag{Name: "until", Usage: "Only count commits before this date, in any format git log accepts", Required: false},
+		&cli.StringFlag{Name: "ref", Usage: "Branch, tag or range such as v1.2..v1.3 to scan instead of HEAD", Required: false},
+		&cli.StringSliceFlag{Name: "path", Usage: "Only count changes under this pathspec (repeatable)", Required: false},
+		&cli.StringSliceFlag{Name: "exclude", Usage: "Ignore files matching this glob, where ** crosses directories (repeatable)", Required: false},
+		&cli.BoolFlag{Name: "no-merges", Usage: "Skip merge commits", Required: false},
+	)
+}
+
+// historyFilterOptions 读取 historyFilterFlags 中的参数
+func historyFilterOptions(c *cli.Context) insightOptions {
+	return insightOptions{
+		Since:    c.String("since"),
+		Until:    c.String("until"),
+		Ref:      c.String("ref"),
+		Paths:    c.StringSlice("path"),
+		NoMerges: c.Bool("no-merges"),
+		Exclude:  c.StringSlice("exclude"),
+	}
+}
+
+// retryPolicyFromFlags 以默认退避间隔为基础, 应用 --timeout 和 --retries
+func retryPolicyFromFlags(c *cli.Context) retryPolicy {
+	policy := defaultRetryPolicy
+	policy.Timeout = c.Duration("timeout")
+	policy.MaxRetries = c.Int("retries")
+	return policy
+}
+
 func runApp() error {
 	return newAppBuilder().RunBaseAsApp()
 }
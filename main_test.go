@@ -20,13 +20,14 @@ func TestInsightFlagSupportsCommitterAndLegacyAlias(t *testing.T) {
 	if insightCmd == nil {
 		t.Fatal("insight command is not registered")
 	}
-	if len(insightCmd.Flags) != 1 {
-		t.Fatalf("insight command has %d flags, want 1", len(insightCmd.Flags))
+	var flag *cli.StringSliceFlag
+	for _, f := range insightCmd.Flags {
+		if sf, ok := f.(*cli.StringSliceFlag); ok && sf.Name == "committer" {
+			flag = sf
+		}
 	}
-
-	flag, ok := insightCmd.Flags[0].(*cli.StringFlag)
-	if !ok {
-		t.Fatalf("insight flag has type %T, want *cli.StringFlag", insightCmd.Flags[0])
+	if flag == nil {
+		t.Fatal("insight command has no repeatable committer flag")
 	}
 	if flag.Name != "committer" {
 		t.Fatalf("insight flag name = %q, want %q", flag.Name, "committer")
@@ -34,8 +35,8 @@ func TestInsightFlagSupportsCommitterAndLegacyAlias(t *testing.T) {
 	if len(flag.Aliases) != 1 || flag.Aliases[0] != "commiter" {
 		t.Fatalf("insight flag aliases = %v, want [commiter]", flag.Aliases)
 	}
-	if !flag.Required {
-		t.Fatal("insight committer flag is not required")
+	if flag.Required {
+		t.Fatal("insight committer flag is required, want optional with --all and --team")
 	}
 	if !strings.Contains(flag.Usage, "--commiter") {
 		t.Fatalf("insight flag usage = %q, want legacy alias mention", flag.Usage)
@@ -58,14 +59,19 @@ func TestInsightCommandPassesCommitterToAction(t *testing.T) {
 			args: []string{"commitron", CMDNameInsight, "--commiter", "Legacy Author"},
 			want: "Legacy Author",
 		},
+		{
+			name: "repeated identities",
+			args: []string{"commitron", CMDNameInsight, "--committer", "Test Author", "--committer", "test@example.com"},
+			want: "Test Author,test@example.com",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var got string
 			actions := stubAppActions(t)
-			actions.insight = func(committer string) error {
-				got = committer
+			actions.insight = func(opts insightOptions) error {
+				got = strings.Join(opts.Committers, ",")
 				return nil
 			}
 
@@ -81,17 +87,13 @@ func TestInsightCommandPassesCommitterToAction(t *testing.T) {
 }
 
 func TestCommentCommandPassesFlagsToAction(t *testing.T) {
-	var gotDiff, gotAccessKey, gotSecretKey, gotEndpoint, gotPrompt string
+	var gotOpts commentOptions
 	actions := stubAppActions(t)
-	actions.comment = func(ctx context.Context, diff, ak, sk, ep, pp string) error {
+	actions.comment = func(ctx context.Context, opts commentOptions) error {
 		if ctx == nil {
 			t.Error("commitron comment action context = nil, want non-nil context")
 		}
-		gotDiff = diff
-		gotAccessKey = ak
-		gotSecretKey = sk
-		gotEndpoint = ep
-		gotPrompt = pp
+		gotOpts = opts
 		return nil
 	}
 
@@ -117,11 +119,11 @@ func TestCommentCommandPassesFlagsToAction(t *testing.T) {
 		"prompt":   "test prompt",
 	}
 	got := map[string]string{
-		"diff":     gotDiff,
-		"ak":       gotAccessKey,
-		"sk":       gotSecretKey,
-		"endpoint": gotEndpoint,
-		"prompt":   gotPrompt,
+		"diff":     gotOpts.Diff,
+		"ak":       gotOpts.AccessKey,
+		"sk":       gotOpts.SecretKey,
+		"endpoint": gotOpts.Endpoint,
+		"prompt":   gotOpts.Prompt,
 	}
 	for field, wantValue := range want {
 		if got[field] != wantValue {
@@ -153,13 +155,228 @@ func stubAppActions(t *testing.T) appActions {
 			t.Fatal("installAlias action called unexpectedly")
 			return nil
 		},
-		insight: func(committer string) error {
-			t.Fatalf("insight action called unexpectedly with committer %q", committer)
+		insight: func(opts insightOptions) error {
+			t.Fatalf("insight action called unexpectedly with committers %q", opts.Committers)
+			return nil
+		},
+		clearCache: func(configPath string) error {
+			t.Fatalf("clearCache action called unexpectedly with config %q", configPath)
+			return nil
+		},
+		usage: func(since, configPath string) error {
+			t.Fatalf("usage action called unexpectedly with since %q", since)
+			return nil
+		},
+		hotspots: func(opts hotspotOptions) error {
+			t.Fatalf("hotspots action called unexpectedly with %+v", opts)
+			return nil
+		},
+		lint: func(opts lintOptions) error {
+			t.Fatalf("lint action called unexpectedly with %+v", opts)
+			return nil
+		},
+		ownership: func(opts ownershipOptions) error {
+			t.Fatalf("ownership action called unexpectedly with %+v", opts)
 			return nil
 		},
-		comment: func(ctx context.Context, diff, ak, sk, ep, pp string) error {
-			t.Fatalf("comment action called unexpectedly with diff %q, ak %q, sk %q, endpoint %q, prompt %q", diff, ak, sk, ep, pp)
+		summary: func(ctx context.Context, opts summaryOptions) error {
+			t.Fatalf("summary action called unexpectedly with %+v", opts)
 			return nil
 		},
+		standup: func(ctx context.Context, opts standupOptions) error {
+			t.Fatalf("standup action called unexpectedly with %+v", opts)
+			return nil
+		},
+		comment: func(ctx context.Context, opts commentOptions) error {
+			t.Fatalf("comment action called unexpectedly with diff %q, ak %q, sk %q, endpoint %q, prompt %q", opts.Diff, opts.AccessKey, opts.SecretKey, opts.Endpoint, opts.Prompt)
+			return nil
+		},
+	}
+}
+
+func TestCacheClearCommandCallsAction(t *testing.T) {
+	called := false
+	var gotConfig string
+	actions := stubAppActions(t)
+	actions.clearCache = func(configPath string) error {
+		called, gotConfig = true, configPath
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{"commitron", CMDNameCache, CMDNameCacheClear, "--config", "team.yaml"})
+	if err != nil {
+		t.Fatalf("commitron cache clear error = %v, want nil", err)
+	}
+	if !called {
+		t.Fatal("commitron cache clear did not call the clearCache action")
+	}
+	if gotConfig != "team.yaml" {
+		t.Fatalf("clearCache config = %q, want team.yaml", gotConfig)
+	}
+}
+
+func TestUsageCommandPassesSince(t *testing.T) {
+	var gotSince, gotConfig string
+	actions := stubAppActions(t)
+	actions.usage = func(since, configPath string) error {
+		gotSince, gotConfig = since, configPath
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{"commitron", CMDNameUsage, "--since", "30d", "--config", "c.yaml"})
+	if err != nil {
+		t.Fatalf("commitron usage error = %v, want nil", err)
+	}
+	if gotSince != "30d" || gotConfig != "c.yaml" {
+		t.Fatalf("usage action got since %q config %q, want 30d and c.yaml", gotSince, gotConfig)
+	}
+}
+
+func TestInsightCommandPassesFilters(t *testing.T) {
+	var got insightOptions
+	actions := stubAppActions(t)
+	actions.insight = func(opts insightOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{
+		"commitron", CMDNameInsight, "--committer", "Alice",
+		"--since", "2026-01-01", "--until", "2026-03-31", "--ref", "v1.2..v1.3",
+		"--path", "svc/api", "--path", "svc/web", "--exclude", "**/*_test.go", "--no-merges",
+		"--dir-depth", "3", "--go-packages", "--rework-days", "7",
+	})
+	if err != nil {
+		t.Fatalf("commitron insight error = %v, want nil", err)
+	}
+	if got.DirDepth != 3 || !got.GoPackages || got.ReworkDays != 7 {
+		t.Errorf("insight grouping = depth %d, go packages %v, rework days %d, want 3, true and 7", got.DirDepth, got.GoPackages, got.ReworkDays)
+	}
+	want := insightOptions{Since: "2026-01-01", Until: "2026-03-31", Ref: "v1.2..v1.3", NoMerges: true}
+	if got.Since != want.Since || got.Until != want.Until || got.Ref != want.Ref || got.NoMerges != want.NoMerges {
+		t.Errorf("insight options = %+v, want %+v", got, want)
+	}
+	if strings.Join(got.Paths, ",") != "svc/api,svc/web" || strings.Join(got.Exclude, ",") != "**/*_test.go" {
+		t.Errorf("insight paths = %v exclude = %v, want repeated flags", got.Paths, got.Exclude)
+	}
+}
+
+func TestInsightCommandPassesTeamOptions(t *testing.T) {
+	var got insightOptions
+	actions := stubAppActions(t)
+	actions.insight = func(opts insightOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{"commitron", CMDNameInsight, "--team", "team.yaml", "--sort", "added", "--format", "json", "-o", "team.json", "--bucket", "month"})
+	if err != nil {
+		t.Fatalf("commitron insight --team error = %v, want nil", err)
+	}
+	if got.TeamPath != "team.yaml" || got.Sort != "added" || got.Format != InsightFormatJSON || got.Output != "team.json" || got.Bucket != BucketMonth || got.All || len(got.Committers) != 0 {
+		t.Fatalf("insight options = %+v, want team.yaml sorted by added", got)
+	}
+}
+
+func TestHotspotsCommandPassesOptions(t *testing.T) {
+	var got hotspotOptions
+	actions := stubAppActions(t)
+	actions.hotspots = func(opts hotspotOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{
+		"commitron", CMDNameHotspots, "--since", "6 months ago", "--path", "svc", "--exclude", "**/*_test.go",
+		"--size", "indent", "--half-life", "30", "--limit", "5", "--format", "csv",
+	})
+	if err != nil {
+		t.Fatalf("commitron hotspots error = %v, want nil", err)
+	}
+	if got.Filter.Since != "6 months ago" || strings.Join(got.Filter.Paths, ",") != "svc" || strings.Join(got.Filter.Exclude, ",") != "**/*_test.go" {
+		t.Errorf("hotspots filter = %+v, want the shared history filters", got.Filter)
+	}
+	if got.Size != HotspotSizeIndent || got.HalfLife != 30 || got.Limit != 5 || got.Format != InsightFormatCSV {
+		t.Errorf("hotspots options = %+v", got)
+	}
+	if got.MinAuthors != defaultHotspotMinAuthors || got.MinFixes != defaultHotspotMinFixes {
+		t.Errorf("hotspots thresholds = %d, %d, want defaults", got.MinAuthors, got.MinFixes)
+	}
+}
+
+func TestOwnershipCommandPassesOptions(t *testing.T) {
+	var got ownershipOptions
+	actions := stubAppActions(t)
+	actions.ownership = func(opts ownershipOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{
+		"commitron", CMDNameOwnership, "--ref", "main", "--depth", "1", "--no-blame", "--codeowners", "OWNERS", "-o", "owners.md", "--format", "markdown",
+	})
+	if err != nil {
+		t.Fatalf("commitron ownership error = %v, want nil", err)
+	}
+	if got.Filter.Ref != "main" || got.Depth != 1 || !got.NoBlame || got.CodeownersPath != "OWNERS" || got.Output != "owners.md" || got.Format != InsightFormatMarkdown {
+		t.Errorf("ownership options = %+v", got)
+	}
+}
+
+func TestLintCommandPassesOptions(t *testing.T) {
+	var got lintOptions
+	actions := stubAppActions(t)
+	actions.lint = func(opts lintOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{
+		"commitron", CMDNameLint, "--range", "origin/main..HEAD", "--threshold", "70", "--judge", "--format", "json", "--retries", "0",
+	})
+	if err != nil {
+		t.Fatalf("commitron lint error = %v, want nil", err)
+	}
+	if got.Range != "origin/main..HEAD" || got.Threshold != 70 || !got.Judge || got.Format != InsightFormatJSON || got.Retry.MaxRetries != 0 || got.Retry.Timeout != defaultRetryPolicy.Timeout {
+		t.Errorf("lint options = %+v", got)
+	}
+}
+
+func TestSummaryCommandPassesOptions(t *testing.T) {
+	var got summaryOptions
+	actions := stubAppActions(t)
+	actions.summary = func(ctx context.Context, opts summaryOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{
+		"commitron", CMDNameSummary, "--committer", "Alice", "--since", "2026-10-01", "--no-merges", "--dry-run", "--offline", "-o", "week.md",
+	})
+	if err != nil {
+		t.Fatalf("commitron summary error = %v, want nil", err)
+	}
+	if len(got.Filter.Committers) != 1 || got.Filter.Committers[0] != "Alice" || got.Filter.Since != "2026-10-01" || !got.Filter.NoMerges ||
+		!got.DryRun || !got.Offline || got.NoCache || got.Output != "week.md" || got.Retry.MaxRetries != defaultRetryPolicy.MaxRetries {
+		t.Errorf("summary options = %+v", got)
+	}
+}
+
+func TestStandupCommandPassesOptions(t *testing.T) {
+	var got standupOptions
+	actions := stubAppActions(t)
+	actions.standup = func(ctx context.Context, opts standupOptions) error {
+		got = opts
+		return nil
+	}
+
+	err := runAppBuilderForTest(t, newAppBuilderWithActions(actions), []string{
+		"commitron", CMDNameStandup, "--repo", "~/src/api", "--repo", "~/src/web", "--llm", "--since", "yesterday",
+	})
+	if err != nil {
+		t.Fatalf("commitron standup error = %v, want nil", err)
+	}
+	if len(got.Repos) != 2 || got.Repos[1] != "~/src/web" || !got.LLM || got.Since != "yesterday" || got.DryRun || len(got.Committers) != 0 {
+		t.Errorf("standup options = %+v", got)
 	}
 }
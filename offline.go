@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// heuristicMessage 不调用模型, 依据变更路径和 Go 符号生成可编辑的提交信息草稿
+func heuristicMessage(diff string, load blobLoader) string {
+	files := parseDiff(diff)
+	if len(files) == 0 {
+		return "chore: update files"
+	}
+
+	paths := diffPaths(files)
+	header := conventionalHeader(heuristicType(files), commitScope(paths), heuristicSubject(files))
+
+	var body []string
+	for _, f := range files {
+		body = append(body, fmt.Sprintf("- %s %s (+%d -%d)", fileVerb(f), describePath(f), f.Added, f.Removed))
+		if symbols := goSymbolChanges(f, load); len(symbols) > 0 {
+			body = append(body, formatSymbolChanges(symbols))
+		}
+	}
+	return joinMessage(header, body)
+}
+
+// heuristicType 依据路径推断变更类型, 所有文件属于同一类别时取该类别
+func heuristicType(files []fileDiff) string {
+	classes := map[string]func(string) bool{
+		"test":  isTestPath,
+		"docs":  isDocPath,
+		"ci":    isCIPath,
+		"build": isBuildPath,
+	}
+	for _, typ := range []string{"test", "docs", "ci", "build"} {
+		if allPaths(files, classes[typ]) {
+			return typ
+		}
+	}
+	for _, f := range files {
+		if f.IsNew && !isTestPath(f.Path()) {
+			return "feat"
+		}
+	}
+	return "chore"
+}
+
+func allPaths(files []fileDiff, match func(string) bool) bool {
+	for _, f := range files {
+		if !match(f.Path()) {
+			return false
+		}
+	}
+	return true
+}
+
+func heuristicSubject(files []fileDiff) string {
+	if len(files) == 1 {
+		return fmt.Sprintf("%s %s", fileVerb(files[0]), path.Base(files[0].Path()))
+	}
+
+	verb := fileVerb(files[0])
+	for _, f := range files[1:] {
+		if fileVerb(f) != verb {
+			verb = "update"
+			break
+		}
+	}
+	return fmt.Sprintf("%s %d files", verb, len(files))
+}
+
+func fileVerb(f fileDiff) string {
+	switch {
+	case f.IsNew:
+		return "add"
+	case f.IsDeleted:
+		return "remove"
+	case f.IsRename && f.Added+f.Removed == 0:
+		return "move"
+	}
+	return "update"
+}
+
+func describePath(f fileDiff) string {
+	if f.IsRename {
+		return fmt.Sprintf("%s -> %s", f.OldPath, f.NewPath)
+	}
+	return f.Path()
+}
+
+// isTestPath 判断路径是否为测试文件
+func isTestPath(p string) bool {
+	base := path.Base(p)
+	return strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(p, "testdata/") || strings.Contains(p, "/testdata/") ||
+		strings.HasPrefix(p, "test/") || strings.Contains(p, "/test/")
+}
+
+// isCIPath 判断路径是否为持续集成配置
+func isCIPath(p string) bool {
+	switch path.Base(p) {
+	case ".gitlab-ci.yml", ".travis.yml", "Jenkinsfile", "azure-pipelines.yml":
+		return true
+	}
+	return strings.HasPrefix(p, ".github/workflows/") || strings.HasPrefix(p, ".circleci/")
+}
+
+// isBuildPath 判断路径是否为构建相关文件
+func isBuildPath(p string) bool {
+	base := path.Base(p)
+	switch strings.ToLower(base) {
+	case "makefile", "go.mod", "go.sum", "dockerfile", ".goreleaser.yml", ".goreleaser.yaml", "docker-compose.yml":
+		return true
+	}
+	return strings.HasSuffix(base, ".mk") || strings.HasPrefix(p, "build/")
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestHeuristicTypeByPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []fileDiff
+		want  string
+	}{
+		{name: "tests", files: []fileDiff{{NewPath: "insight_test.go"}, {NewPath: "testdata/log.txt"}}, want: "test"},
+		{name: "docs", files: []fileDiff{{NewPath: "README.md"}, {NewPath: "docs/usage.png"}}, want: "docs"},
+		{name: "ci", files: []fileDiff{{NewPath: ".github/workflows/go.yml"}}, want: "ci"},
+		{name: "build", files: []fileDiff{{NewPath: "makefile"}, {NewPath: "go.mod"}}, want: "build"},
+		{name: "new source", files: []fileDiff{{NewPath: "cache.go", IsNew: true}, {NewPath: "cache_test.go", IsNew: true}}, want: "feat"},
+		{name: "mixed edits", files: []fileDiff{{NewPath: "main.go"}, {NewPath: "README.md"}}, want: "chore"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heuristicType(tt.files); got != tt.want {
+				t.Fatalf("heuristicType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicMessageListsFilesAndSymbols(t *testing.T) {
+	diff := "diff --git a/cache.go b/cache.go\n" +
+		"new file mode 100644\n" +
+		"index 0000000..3333333\n" +
+		"--- /dev/null\n" +
+		"+++ b/cache.go\n" +
+		"@@ -0,0 +1,3 @@\n" +
+		"+package main\n" +
+		"+\n" +
+		"+func cacheKey() string { return \"\" }\n" +
+		"diff --git a/old.go b/legacy/old.go\n" +
+		"similarity index 100%\n" +
+		"rename from old.go\n" +
+		"rename to legacy/old.go\n"
+	load := fakeBlobLoader(map[string]string{"3333333": "package main\n\nfunc cacheKey() string { return \"\" }\n"})
+
+	got := heuristicMessage(diff, load)
+	want := "feat: update 2 files\n\n" +
+		"- add cache.go (+3 -0)\n" +
+		"  + func cacheKey\n" +
+		"- move old.go -> legacy/old.go (+0 -0)"
+	if got != want {
+		t.Fatalf("heuristicMessage() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/khicago/irr"
+)
+
+// ownershipSchemaVersion 是 ownership JSON 输出的版本
+const ownershipSchemaVersion = 1
+
+const (
+	defaultOwnershipDepth = 2
+	// maxSuggestedOwners 是每个目录建议的负责人上限
+	maxSuggestedOwners = 3
+)
+
+// ownershipOptions 汇总 ownership 子命令的输入
+type ownershipOptions struct {
+	// Filter 中的 Since、Until 和 NoMerges 只影响提交数, Ref、Paths 和 Exclude 同时决定统计哪些文件
+	Filter insightOptions
+	// Depth 是目录分组的层数
+	Depth int
+	// NoBlame 跳过 git blame, 只按提交数统计
+	NoBlame bool
+	// CodeownersPath 是用于比较的 CODEOWNERS, 为空时在仓库的默认位置查找
+	CodeownersPath string
+	ConfigPath     string
+	Format         string
+	Output         string
+}
+
+// authorShare 是一位作者在目录中的份额
+type authorShare struct {
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	Lines       int     `json:"lines"`
+	LineShare   float64 `json:"line_share"`
+	Commits     int     `json:"commits"`
+	CommitShare float64 `json:"commit_share"`
+}
+
+// directoryOwnership 是一个目录的所有权统计
+type directoryOwnership struct {
+	Path    string `json:"path"`
+	Files   int    `json:"files"`
+	Lines   int    `json:"lines"`
+	Commits int    `json:"commits"`
+	// BusFactor 是合计拥有超过一半代码的最少作者数; 有 blame 时按存活行数, 否则按提交数
+	BusFactor int           `json:"bus_factor"`
+	Authors   []authorShare `json:"authors"`
+	// Codeowners 是现有 CODEOWNERS 中负责该目录的人, StaleOwners 在统计范围内没有提交也没有存活的代码
+	Codeowners    []string `json:"codeowners"`
+	StaleOwners   []string `json:"stale_owners"`
+	UnknownOwners []string `json:"unknown_owners"`
+	Suggested     []string `json:"suggested_owners"`
+}
+
+// ownershipReport 是 ownership 子命令的输出, Suggested 是按各目录的建议负责人生成的 CODEOWNERS 规则
+type ownershipReport struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Filters       []string             `json:"filters"`
+	Rev           string               `json:"rev"`
+	Depth         int                  `json:"depth"`
+	Blame         bool                 `json:"blame"`
+	Codeowners    string               `json:"codeowners"`
+	Directories   []directoryOwnership `json:"directories"`
+	Suggested     []codeownersRule     `json:"suggested_codeowners"`
+}
+
+// ownershipAccumulator 汇总一个目录, 作者以小写邮箱区分
+type ownershipAccumulator struct {
+	dir     directoryOwnership
+	authors map[string]*authorShare
+}
+
+func (a *ownershipAccumulator) author(name, email string) *authorShare {
+	key := contactKey(name, email)
+	if a.authors[key] == nil {
+		a.authors[key] = &authorShare{Name: name, Email: email}
+	}
+	return a.authors[key]
+}
+
+// ownership 统计各目录的所有权并输出
+func ownership(opts ownershipOptions) error {
+	conf, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+	report, err := collectOwnership("", opts, conf.Identities)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err = writeOwnership(&out, opts.Format, report); err != nil {
+		return err
+	}
+	return writeInsightOutput(opts.Output, out.Bytes())
+}
+
+// collectOwnership 在 dir 中按目录统计作者的存活行数和提交数, 计算 bus factor 并与 CODEOWNERS 比较
+func collectOwnership(dir string, opts ownershipOptions, identities map[string][]string) (ownershipReport, error) {
+	if opts.Depth == 0 {
+		opts.Depth = defaultOwnershipDepth
+	}
+	if opts.Depth < 1 {
+		return ownershipReport{}, irr.Error("depth must be at least 1, got %d", opts.Depth)
+	}
+	if err := checkHotspotFormat(opts.Format); err != nil {
+		return ownershipReport{}, err
+	}
+	rev := hotspotRev(opts.Filter.Ref)
+
+	files, err := listTreeFiles(dir, rev, opts.Filter)
+	if err != nil {
+		return ownershipReport{}, err
+	}
+	dirs := make(map[string]*ownershipAccumulator)
+	accFor := func(path string) *ownershipAccumulator {
+		d := getDirectoryAtDepth(path, opts.Depth)
+		if dirs[d] == nil {
+			dirs[d] = &ownershipAccumulator{dir: directoryOwnership{Path: d}, authors: make(map[string]*authorShare)}
+		}
+		return dirs[d]
+	}
+	var textFiles []string
+	for _, f := range files {
+		accFor(f.Path).dir.Files++
+		if !f.Binary {
+			textFiles = append(textFiles, f.Path)
+		}
+	}
+
+	if !opts.NoBlame {
+		blamed, err := blameFiles(dir, rev, textFiles)
+		if err != nil {
+			return ownershipReport{}, err
+		}
+		for _, path := range textFiles {
+			acc := accFor(path)
+			for _, line := range blamed[path] {
+				acc.dir.Lines += line.Lines
+				acc.author(line.Name, line.Email).Lines += line.Lines
+			}
+		}
+	}
+
+	commits, err := loadInsightCommits(dir, opts.Filter, nil)
+	if err != nil {
+		return ownershipReport{}, err
+	}
+	for _, c := range commits {
+		people := [][2]string{{c.AuthorName, c.AuthorEmail}}
+		for _, co := range c.CoAuthors {
+			name, email := parseContact(co)
+			people = append(people, [2]string{name, email})
+		}
+		touched := make(map[string]bool)
+		for _, f := range c.Files {
+			d := getDirectoryAtDepth(f.Path, opts.Depth)
+			// 只统计当前仍存在的目录
+			if touched[d] || dirs[d] == nil {
+				continue
+			}
+			touched[d] = true
+			acc := dirs[d]
+			acc.dir.Commits++
+			seen := make(map[string]bool)
+			for _, p := range people {
+				if key := contactKey(p[0], p[1]); !seen[key] {
+					seen[key] = true
+					acc.author(p[0], p[1]).Commits++
+				}
+			}
+		}
+	}
+
+	rules, codeownersPath, err := loadCodeowners(dir, rev, opts.CodeownersPath)
+	if err != nil {
+		return ownershipReport{}, err
+	}
+
+	report := ownershipReport{
+		SchemaVersion: ownershipSchemaVersion,
+		Filters:       opts.Filter.describeFilters(),
+		Rev:           rev,
+		Depth:         opts.Depth,
+		Blame:         !opts.NoBlame,
+		Codeowners:    codeownersPath,
+		Directories:   make([]directoryOwnership, 0, len(dirs)),
+		Suggested:     make([]codeownersRule, 0, len(dirs)),
+	}
+	if report.Filters == nil {
+		report.Filters = make([]string, 0)
+	}
+	names := make([]string, 0, len(dirs))
+	for d := range dirs {
+		names = append(names, d)
+	}
+	// root 排在最前, 在 CODEOWNERS 中作为默认规则, 后面更具体的目录覆盖它
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "root") != (names[j] == "root") {
+			return names[i] == "root"
+		}
+		return names[i] < names[j]
+	})
+	for _, d := range names {
+		owned := finishOwnership(dirs[d], !opts.NoBlame)
+		owned.Codeowners = codeownersFor(rules, d)
+		if owned.Codeowners == nil {
+			owned.Codeowners = make([]string, 0)
+		}
+		owned.StaleOwners, owned.UnknownOwners = checkCodeowners(owned.Codeowners, owned.Authors, identities)
+		report.Directories = append(report.Directories, owned)
+		if len(owned.Suggested) > 0 {
+			report.Suggested = append(report.Suggested, codeownersRule{Pattern: codeownersPattern(d), Owners: owned.Suggested})
+		}
+	}
+	return report, nil
+}
+
+// finishOwnership 计算份额并排序作者, 得出 bus factor 和建议的负责人
+func finishOwnership(acc *ownershipAccumulator, byLines bool) directoryOwnership {
+	owned := acc.dir
+	owned.Authors = make([]authorShare, 0, len(acc.authors))
+	for _, a := range acc.authors {
+		a.LineShare = share(a.Lines, owned.Lines)
+		a.CommitShare = share(a.Commits, owned.Commits)
+		owned.Authors = append(owned.Authors, *a)
+	}
+	weight := func(a authorShare) int {
+		if byLines && owned.Lines > 0 {
+			return a.Lines
+		}
+		return a.Commits
+	}
+	total := owned.Commits
+	if byLines && owned.Lines > 0 {
+		total = owned.Lines
+	}
+	sort.Slice(owned.Authors, func(i, j int) bool {
+		a, b := owned.Authors[i], owned.Authors[j]
+		if weight(a) != weight(b) {
+			return weight(a) > weight(b)
+		}
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Name < b.Name
+	})
+
+	owned.Suggested = make([]string, 0, maxSuggestedOwners)
+	covered := 0
+	for _, a := range owned.Authors {
+		if total == 0 || covered*2 > total {
+			break
+		}
+		covered += weight(a)
+		owned.BusFactor++
+		if len(owned.Suggested) < maxSuggestedOwners {
+			owner := a.Email
+			if owner == "" {
+				owner = a.Name
+			}
+			owned.Suggested = append(owned.Suggested, owner)
+		}
+	}
+	return owned
+}
+
+// checkCodeowners 找出现有负责人中在目录里没有提交也没有存活代码的人, 以及无法对应到作者的人
+func checkCodeowners(owners []string, authors []authorShare, identities map[string][]string) (stale, unknown []string) {
+	stale, unknown = make([]string, 0), make([]string, 0)
+	for _, owner := range owners {
+		matcher, ok := codeownerMatcher(owner, identities)
+		if !ok {
+			unknown = append(unknown, owner)
+			continue
+		}
+		active := false
+		for _, a := range authors {
+			if (a.Lines > 0 || a.Commits > 0) && matcher.matches(a.Name, a.Email) {
+				active = true
+				break
+			}
+		}
+		if !active {
+			stale = append(stale, owner)
+		}
+	}
+	return stale, unknown
+}
+
+// treeFile 是 rev 中的一个文件
+type treeFile struct {
+	Path   string
+	Binary bool
+}
+
+// listTreeFiles 列出 rev 中符合 --path 和 --exclude 的文件
+//
+// 与空树比较的 git diff --numstat 支持完整的 pathspec 语法, 并标出二进制文件
+func listTreeFiles(dir, rev string, filter insightOptions) ([]treeFile, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, irr.Error("invalid ref %q", rev)
+	}
+	cmd := exec.Command("git", "hash-object", "-t", "tree", "--stdin")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, irr.Wrap(err, "failed to compute the empty tree")
+	}
+	args := []string{"diff", "--numstat", "-z", "--no-renames", strings.TrimSpace(string(out)), rev}
+	pathArgs, err := (insightOptions{Paths: filter.Paths, Exclude: filter.Exclude}).gitLogArgs()
+	if err != nil {
+		return nil, err
+	}
+	output, err := executeGitCommandIn(dir, append(args, pathArgs...)...)
+	if err != nil {
+		return nil, irr.Wrap(err, "failed to list files at %s", rev)
+	}
+
+	var files []treeFile
+	for _, entry := range strings.Split(output, "\x00") {
+		fields := strings.SplitN(entry, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		files = append(files, treeFile{Path: fields[2], Binary: fields[0] == "-"})
+	}
+	return files, nil
+}
+
+// blameLines 是 blame 中一位作者在一个文件中的存活行数
+type blameLines struct {
+	Name, Email string
+	Lines       int
+}
+
+// blameFiles 并行对每个文件执行 git blame --line-porcelain, 按作者汇总存活行数
+func blameFiles(dir, rev string, paths []string) (map[string][]blameLines, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		result   = make(map[string][]blameLines, len(paths))
+		firstErr error
+		jobs     = make(chan string)
+	)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				lines, err := blameFile(dir, rev, path)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				result[path] = lines
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+	return result, firstErr
+}
+
+// blameFile 统计一个文件中每位作者的存活行数, 作者经过 .mailmap 映射
+func blameFile(dir, rev, path string) ([]blameLines, error) {
+	cmd := exec.Command("git", "blame", "--line-porcelain", rev, "--", path)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, irr.Wrap(err, "git blame %s failed: %s", path, strings.TrimSpace(stderr.String()))
+	}
+	return parseBlamePorcelain(bytes.NewReader(out))
+}
+
+// parseBlamePorcelain 解析 --line-porcelain 输出, 每行代码前都有完整的 author 和 author-mail
+func parseBlamePorcelain(r io.Reader) ([]blameLines, error) {
+	var (
+		order  []string
+		counts = make(map[string]*blameLines)
+		name   string
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "author "):
+			name = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-mail "):
+			email := strings.Trim(strings.TrimPrefix(line, "author-mail "), "<>")
+			key := contactKey(name, email)
+			if counts[key] == nil {
+				counts[key] = &blameLines{Name: name, Email: email}
+				order = append(order, key)
+			}
+			counts[key].Lines++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, irr.Wrap(err, "failed to parse git blame output")
+	}
+	lines := make([]blameLines, 0, len(order))
+	for _, key := range order {
+		lines = append(lines, *counts[key])
+	}
+	return lines, nil
+}
+
+// writeOwnership 按 format 输出所有权报告
+func writeOwnership(w io.Writer, format string, r ownershipReport) error {
+	if err := checkHotspotFormat(format); err != nil {
+		return err
+	}
+	switch format {
+	case InsightFormatJSON:
+		return writeJSON(w, r)
+	case InsightFormatCSV:
+		records := [][]string{{"directory", "bus_factor", "author", "email", "lines", "line_share", "commits", "commit_share"}}
+		for _, d := range r.Directories {
+			for _, a := range d.Authors {
+				records = append(records, []string{d.Path, strconv.Itoa(d.BusFactor), a.Name, a.Email, strconv.Itoa(a.Lines),
+					formatFloat(a.LineShare), strconv.Itoa(a.Commits), formatFloat(a.CommitShare)})
+			}
+		}
+		return writeCSV(w, records)
+	case InsightFormatMarkdown:
+		sb := strings.Builder{}
+		sb.WriteString("# Ownership\n\n" + markdownCell(describeOwnership(r)) + "\n")
+		for _, d := range r.Directories {
+			sb.WriteString(fmt.Sprintf("\n## %s (bus factor %d)\n\n", markdownCell(d.Path), d.BusFactor))
+			sb.WriteString("| Author | Lines | Line share | Commits | Commit share |\n| --- | ---: | ---: | ---: | ---: |\n")
+			for _, a := range d.Authors {
+				sb.WriteString(fmt.Sprintf("| %s | %d | %s | %d | %s |\n", markdownCell(formatContact(a.Name, a.Email)), a.Lines, formatShare(a.LineShare), a.Commits, formatShare(a.CommitShare)))
+			}
+			sb.WriteString(markdownCell(describeCodeowners(d)) + "\n")
+		}
+		sb.WriteString("\n## Suggested CODEOWNERS\n\n```text\n")
+		writeSuggestedCodeowners(&sb, r.Suggested)
+		sb.WriteString("```\n")
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("\n# Ownership\n\n" + describeOwnership(r) + "\n")
+	for _, d := range r.Directories {
+		sb.WriteString(fmt.Sprintf("\n## %s (bus factor %d, %d files, %d lines, %d commits)\n\n", d.Path, d.BusFactor, d.Files, d.Lines, d.Commits))
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "AUTHOR\tLINES\tSHARE\tCOMMITS\tSHARE")
+		for _, a := range d.Authors {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\n", formatContact(a.Name, a.Email), a.Lines, formatShare(a.LineShare), a.Commits, formatShare(a.CommitShare))
+		}
+		_ = tw.Flush()
+		sb.WriteString(describeCodeowners(d) + "\n")
+	}
+	sb.WriteString("\n## Suggested CODEOWNERS\n\n")
+	writeSuggestedCodeowners(&sb, r.Suggested)
+	_, err := fmt.Fprintln(w, sb.String())
+	return err
+}
+
+// describeOwnership 说明统计口径和过滤条件
+func describeOwnership(r ownershipReport) string {
+	s := fmt.Sprintf("Directories %d levels deep at %s. Bus factor counts the fewest authors who own more than half of the ", r.Depth, r.Rev)
+	if r.Blame {
+		s += "surviving lines (git blame)."
+	} else {
+		s += "commits."
+	}
+	if r.Codeowners != "" {
+		s += " Compared with " + r.Codeowners + "."
+	}
+	if len(r.Filters) > 0 {
+		s += " Filtered by " + strings.Join(r.Filters, "; ") + "."
+	}
+	return s
+}
+
+// describeCodeowners 说明目录现有的负责人和建议
+func describeCodeowners(d directoryOwnership) string {
+	s := "Suggested owners: " + strings.Join(d.Suggested, " ")
+	if len(d.Codeowners) > 0 {
+		s += "\nCODEOWNERS: " + strings.Join(d.Codeowners, " ")
+	}
+	if len(d.StaleOwners) > 0 {
+		s += "\nStale owners (no commits or surviving lines here): " + strings.Join(d.StaleOwners, " ")
+	}
+	if len(d.UnknownOwners) > 0 {
+		s += "\nOwners not matched to any author (add them under identities): " + strings.Join(d.UnknownOwners, " ")
+	}
+	return s
+}
+
+func writeSuggestedCodeowners(sb *strings.Builder, rules []codeownersRule) {
+	for _, rule := range rules {
+		sb.WriteString(rule.Pattern + " " + strings.Join(rule.Owners, " ") + "\n")
+	}
+}
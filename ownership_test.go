@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newOwnershipRepo(t *testing.T) string {
+	t.Helper()
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	return newScriptRepo(t, scriptCommit("Ann <ann@example.com>", at, "add api",
+		scriptFile("svc/api/a.go", strings.Repeat("a\n", 10))+scriptFile("svc/api/b.go", strings.Repeat("b\n", 4))+
+			scriptFile("README.md", "# x\n")+scriptFile("logo.png", "\x00\x01")+
+			scriptFile("CODEOWNERS", "* @lead\n/svc/api/ @old ann@example.com # api\n"))+
+		scriptCommit("Bob <bob@example.com>", at.Add(time.Hour), "add db",
+			scriptFile("svc/api/c.go", strings.Repeat("c\n", 6))+scriptFile("svc/db/x.go", strings.Repeat("x\n", 3)))+
+		scriptCommit("Cid <cid@example.com>", at.Add(2*time.Hour), "tune db\n\nCo-authored-by: Bob <bob@example.com>",
+			scriptFile("svc/db/x.go", strings.Repeat("x\n", 3)+"y\n")))
+}
+
+func TestCollectOwnershipByBlame(t *testing.T) {
+	dir := newOwnershipRepo(t)
+	report, err := collectOwnership(dir, ownershipOptions{}, map[string][]string{"old": {"old@example.com"}})
+	if err != nil {
+		t.Fatalf("collectOwnership() error = %v", err)
+	}
+	var paths []string
+	for _, d := range report.Directories {
+		paths = append(paths, d.Path)
+	}
+	if strings.Join(paths, ",") != "root,svc/api,svc/db" || report.Depth != defaultOwnershipDepth || report.Codeowners != "CODEOWNERS" {
+		t.Fatalf("directories = %v at depth %d with %q, want root first, then svc/api and svc/db from CODEOWNERS", paths, report.Depth, report.Codeowners)
+	}
+
+	api := report.Directories[1]
+	if api.Files != 3 || api.Lines != 20 || api.Commits != 2 || api.BusFactor != 1 {
+		t.Errorf("svc/api = %+v, want 3 files, 20 lines, 2 commits and bus factor 1", api)
+	}
+	if a := api.Authors[0]; a.Email != "ann@example.com" || a.Lines != 14 || a.LineShare != 0.7 || a.CommitShare != 0.5 {
+		t.Errorf("svc/api top author = %+v, want Ann with 14 lines (70%%) and half of the commits", a)
+	}
+	if strings.Join(api.Codeowners, " ") != "@old ann@example.com" || strings.Join(api.StaleOwners, " ") != "@old" || len(api.UnknownOwners) != 0 {
+		t.Errorf("svc/api owners = %v, stale %v, unknown %v, want @old stale", api.Codeowners, api.StaleOwners, api.UnknownOwners)
+	}
+
+	db := report.Directories[2]
+	if db.Lines != 4 || db.BusFactor != 1 || db.Authors[0].Email != "bob@example.com" || db.Authors[0].Commits != 2 {
+		t.Errorf("svc/db = %+v, want Bob owning 3 of 4 lines with 2 commits including the co-authored one", db)
+	}
+	if strings.Join(db.UnknownOwners, " ") != "@lead" {
+		t.Errorf("svc/db unknown owners = %v, want @lead from the * rule", db.UnknownOwners)
+	}
+	if root := report.Directories[0]; root.Files != 3 || root.Lines != 3 {
+		t.Errorf("root = %+v, want 3 files and 3 lines, without the binary logo", root)
+	}
+
+	var rules []string
+	for _, rule := range report.Suggested {
+		rules = append(rules, rule.Pattern+" "+strings.Join(rule.Owners, " "))
+	}
+	if strings.Join(rules, "\n") != "* ann@example.com\n/svc/api/ ann@example.com\n/svc/db/ bob@example.com" {
+		t.Errorf("suggested CODEOWNERS = %q", rules)
+	}
+}
+
+func TestCollectOwnershipByCommits(t *testing.T) {
+	dir := newOwnershipRepo(t)
+	report, err := collectOwnership(dir, ownershipOptions{Depth: 1, NoBlame: true, Filter: insightOptions{Paths: []string{"svc"}}}, nil)
+	if err != nil {
+		t.Fatalf("collectOwnership(no blame) error = %v", err)
+	}
+	if len(report.Directories) != 1 || report.Blame {
+		t.Fatalf("directories = %+v, want only svc without blame", report.Directories)
+	}
+	svc := report.Directories[0]
+	if svc.Path != "svc" || svc.Lines != 0 || svc.Files != 4 || svc.Commits != 3 || svc.BusFactor != 1 {
+		t.Errorf("svc = %+v, want 4 files, 3 commits and bus factor 1 by commits", svc)
+	}
+	if strings.Join(svc.Suggested, " ") != "bob@example.com" || svc.Authors[0].CommitShare != 0.667 {
+		t.Errorf("svc authors = %+v, want Bob with two thirds of the commits", svc.Authors)
+	}
+	if strings.Join(svc.UnknownOwners, " ") != "@lead" || len(svc.StaleOwners) != 0 {
+		t.Errorf("svc owners = %v, stale %v, unknown %v, want @lead unknown without identities", svc.Codeowners, svc.StaleOwners, svc.UnknownOwners)
+	}
+
+	if _, err = collectOwnership(dir, ownershipOptions{Depth: -1}, nil); err == nil {
+		t.Error("collectOwnership(depth -1) error = nil, want error")
+	}
+}
+
+func TestCollectOwnershipReadsCodeownersPathAtRev(t *testing.T) {
+	// 测试仓库没有检出工作区, 只能从版本中读到 CODEOWNERS
+	dir := newOwnershipRepo(t)
+	report, err := collectOwnership(dir, ownershipOptions{NoBlame: true, CodeownersPath: "./CODEOWNERS"}, nil)
+	if err != nil {
+		t.Fatalf("collectOwnership(--codeowners) error = %v", err)
+	}
+	if report.Codeowners != "CODEOWNERS" || strings.Join(report.Directories[1].Codeowners, " ") != "@old ann@example.com" {
+		t.Errorf("codeowners = %q with svc/api owners %v, want CODEOWNERS read at HEAD", report.Codeowners, report.Directories[1].Codeowners)
+	}
+
+	if _, err = collectOwnership(dir, ownershipOptions{NoBlame: true, CodeownersPath: ".github/CODEOWNERS"}, nil); err == nil {
+		t.Error("collectOwnership(missing --codeowners) error = nil, want error")
+	}
+}
+
+func TestWriteOwnershipFormats(t *testing.T) {
+	report, err := collectOwnership(newOwnershipRepo(t), ownershipOptions{}, map[string][]string{"old": {"old@example.com"}})
+	if err != nil {
+		t.Fatalf("collectOwnership() error = %v", err)
+	}
+
+	var sb strings.Builder
+	if err = writeOwnership(&sb, InsightFormatText, report); err != nil {
+		t.Fatalf("writeOwnership(text) error = %v", err)
+	}
+	for _, want := range []string{"## svc/api (bus factor 1, 3 files, 20 lines, 2 commits)", "Stale owners", "## Suggested CODEOWNERS", "/svc/db/ bob@example.com"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, sb.String())
+		}
+	}
+
+	sb.Reset()
+	if err = writeOwnership(&sb, InsightFormatJSON, report); err != nil {
+		t.Fatalf("writeOwnership(json) error = %v", err)
+	}
+	var decoded map[string]any
+	if err = json.Unmarshal([]byte(sb.String()), &decoded); err != nil || decoded["schema_version"] != float64(ownershipSchemaVersion) {
+		t.Errorf("json output = %s, err %v", sb.String(), err)
+	}
+
+	sb.Reset()
+	if err = writeOwnership(&sb, InsightFormatCSV, report); err != nil {
+		t.Fatalf("writeOwnership(csv) error = %v", err)
+	}
+	if !strings.HasPrefix(sb.String(), "directory,bus_factor,author,email,lines,line_share,commits,commit_share\n") {
+		t.Errorf("csv output = %s", sb.String())
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bagaking/botheater/driver/coze"
+	"github.com/khicago/got/util/typer"
+	"github.com/khicago/irr"
+)
+
+const (
+	ProviderTypeDoubao  = "doubao"
+	ProviderTypeOllama  = "ollama"
+	ProviderTypeOffline = "offline"
+
+	defaultOllamaEndpoint = "http://localhost:11434"
+)
+
+// providerConfig 描述 fallback 链中的一个模型后端
+type providerConfig struct {
+	// Name 用于日志和报告, 为空时使用 Type
+	Name string `yaml:"name,omitempty"`
+	// Type 取值 doubao, ollama 或 offline
+	Type string `yaml:"type"`
+	// Endpoint 对 doubao 是模型 endpoint id, 对 ollama 是服务地址
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Model 仅 ollama 使用
+	Model string `yaml:"model,omitempty"`
+}
+
+// defaultProviders 在未配置 providers 时使用: 先调用 doubao, 失败后退化为本地启发式
+var defaultProviders = []providerConfig{
+	{Name: ProviderTypeDoubao, Type: ProviderTypeDoubao},
+	{Name: ProviderTypeOffline, Type: ProviderTypeOffline},
+}
+
+func (p providerConfig) displayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Type
+}
+
+// modelRequest 是发送给模型的内容
+type modelRequest struct {
+	Prompt   string
+	Question string
+	// Compaction 记录构造 Question 时被压缩、截断或丢弃的文件
+	Compaction questionReport
+	// Offline 生成 offline 后端的草稿, 为 nil 时使用基于 diff 的 heuristicMessage
+	Offline func() string
+	// Redactions 记录 redactRequest 在 Question 中替换的秘密
+	Redactions []redactionCount
+	// Noun 是 stderr 提示和错误中对回答的称呼, 为空时为 commit message
+	Noun string
+}
+
+// resolvedProvider 是完成凭证与端点解析、可以直接调用的后端
+type resolvedProvider struct {
+	providerConfig
+	ask askQuestionFunc
+}
+
+// resolveProvider 解析后端的端点与凭证, 缺少必要配置时返回错误
+//
+// doubaoAsk 是 doubao 后端实际发起请求的函数, 便于测试替换
+func resolveProvider(conf providerConfig, opts commentOptions, doubaoAsk askQuestionFunc) (resolvedProvider, error) {
+	switch conf.Type {
+	case ProviderTypeDoubao:
+		ak := firstNonBlank(opts.AccessKey, coze.EnvKeyVOLCAccessKey.Read())
+		sk := firstNonBlank(opts.SecretKey, coze.EnvKeyVOLCSecretKey.Read())
+		// 端点依次取 --endpoint、配置文件和 DOUBAO_ENDPOINT, 与凭证一样以参数优先
+		conf.Endpoint = firstNonBlank(opts.Endpoint, conf.Endpoint, coze.EnvKeyDoubaoEndpoint.Read())
+
+		// Check if the access key and secret key are set
+		if ak == "" || sk == "" {
+			return resolvedProvider{}, irr.Error("Please provide the access key and secret key using flags or environment variables")
+		}
+		if conf.Endpoint == "" {
+			return resolvedProvider{}, irr.Error("Please provide the endpoint using flags or environment variables")
+		}
+		return resolvedProvider{providerConfig: conf, ask: withCozeCredentials(ak, sk, doubaoAsk)}, nil
+	case ProviderTypeOllama:
+		conf.Endpoint = firstNonBlank(conf.Endpoint, defaultOllamaEndpoint)
+		if conf.Model == "" {
+			return resolvedProvider{}, irr.Error("provider %s: ollama requires a model", conf.displayName())
+		}
+		return resolvedProvider{providerConfig: conf, ask: ollamaAsk(conf.Model, http.DefaultClient)}, nil
+	case ProviderTypeOffline:
+		return resolvedProvider{providerConfig: conf}, nil
+	}
+	return resolvedProvider{}, irr.Error("provider %s: unknown type %q", conf.displayName(), conf.Type)
+}
+
+// askProviders 依次尝试 fallback 链中的后端, 返回第一个成功的结果, 并在 stderr 报告使用的后端
+//
+// 配置了缓存时, 先按链中每个可用后端查找缓存, 命中则直接返回
+func askProviders(ctx context.Context, opts commentOptions, doubaoAsk askQuestionFunc, req modelRequest) (string, error) {
+	req = redactRequest(req)
+	noun := typer.Or(req.Noun, "commit message")
+	chain := opts.Providers
+	if len(chain) == 0 {
+		chain = defaultProviders
+	}
+
+	resolved := make([]resolvedProvider, len(chain))
+	resolveErrs := make([]error, len(chain))
+	for i, conf := range chain {
+		resolved[i], resolveErrs[i] = resolveProvider(conf, opts, doubaoAsk)
+	}
+
+	if opts.Cache != nil {
+		for i, p := range resolved {
+			if resolveErrs[i] != nil || p.Type == ProviderTypeOffline {
+				continue
+			}
+			if entry, ok := opts.Cache.get(cacheKey(opts.Diff, req.Prompt, p.providerConfig)); ok {
+				fmt.Fprintf(os.Stderr, "commitron: %s served from cache (generated by %s)\n", noun, entry.Provider)
+				return entry.Message, nil
+			}
+		}
+	}
+
+	var failures []string
+	for i, p := range resolved {
+		// 取消 (如 Ctrl-C) 或超时不算后端失败, 不再尝试后面的后端, 也不用离线草稿冒充结果
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		name := chain[i].displayName()
+		if chain[i].Type == ProviderTypeOffline {
+			if len(failures) > 0 {
+				fmt.Fprintf(os.Stderr, "commitron: %s; using offline heuristic draft\n", strings.Join(failures, "; "))
+			}
+			if req.Offline != nil {
+				return req.Offline(), nil
+			}
+			return heuristicMessage(opts.Diff, readGitBlob), nil
+		}
+
+		err := resolveErrs[i]
+		if err == nil {
+			ask := p.ask
+			if opts.Usage != nil {
+				ask = opts.Usage.wrap(ask, p.providerConfig)
+			}
+			if opts.Audit != nil {
+				ask = opts.Audit.wrap(ask, p, opts.Diff, req)
+			}
+			var comment string
+			if comment, err = withRetry(ask, opts.Retry)(ctx, p.Endpoint, req.Prompt, req.Question); err == nil {
+				if len(failures) > 0 {
+					fmt.Fprintf(os.Stderr, "commitron: %s\n", strings.Join(failures, "; "))
+				}
+				fmt.Fprintf(os.Stderr, "commitron: %s generated by %s\n", noun, name)
+				if opts.Cache != nil {
+					if err = opts.Cache.put(cacheKey(opts.Diff, req.Prompt, p.providerConfig), name, comment); err != nil {
+						fmt.Fprintf(os.Stderr, "commitron: %v\n", err)
+					}
+				}
+				return comment, nil
+			}
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	return "", irr.Error("failed to generate %s, all providers failed: %s", noun, strings.Join(failures, "; "))
+}
+
+// askWithQuestion 为没有 diff 的请求调用 askProviders, 如 summary 和 standup, 同样经过 fallback 链、缓存、审计日志和月度预算;
+// 缓存键和审计日志中的哈希都基于问题本身
+func askWithQuestion(ctx context.Context, opts commentOptions, doubaoAsk askQuestionFunc, req modelRequest) (string, error) {
+	opts.Diff = req.Question
+	return askProviders(ctx, opts, doubaoAsk, req)
+}
+
+// withCozeCredentials 在调用期间设置 coze 驱动使用的全局凭证, 调用结束后恢复
+func withCozeCredentials(ak, sk string, ask askQuestionFunc) askQuestionFunc {
+	return func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		previousAccessKey := coze.VOLC_ACCESSKEY
+		previousSecretKey := coze.VOLC_SECRETKEY
+		coze.VOLC_ACCESSKEY = ak
+		coze.VOLC_SECRETKEY = sk
+		defer func() {
+			coze.VOLC_ACCESSKEY = previousAccessKey
+			coze.VOLC_SECRETKEY = previousSecretKey
+		}()
+		return ask(ctx, endpoint, prompt, question)
+	}
+}
+
+type (
+	ollamaMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	ollamaChatRequest struct {
+		Model    string          `json:"model"`
+		Messages []ollamaMessage `json:"messages"`
+		Stream   bool            `json:"stream"`
+	}
+
+	ollamaChatResponse struct {
+		Message ollamaMessage `json:"message"`
+		Error   string        `json:"error,omitempty"`
+		// PromptEvalCount 和 EvalCount 是 Ollama 报告的提示与生成 token 数
+		PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+		EvalCount       int `json:"eval_count,omitempty"`
+	}
+)
+
+// ollamaAsk 通过 Ollama 的 /api/chat 接口提问, endpoint 是服务地址
+func ollamaAsk(model string, client *http.Client) askQuestionFunc {
+	return func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		body, err := json.Marshal(ollamaChatRequest{
+			Model: model,
+			Messages: []ollamaMessage{
+				{Role: "system", Content: prompt},
+				{Role: "user", Content: question},
+			},
+		})
+		if err != nil {
+			return "", irr.Wrap(err, "failed to encode ollama request")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/api/chat", bytes.NewReader(body))
+		if err != nil {
+			return "", irr.Wrap(err, "failed to create ollama request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", irr.Wrap(err, "ollama request failed")
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", irr.Wrap(err, "failed to read ollama response")
+		}
+		if resp.StatusCode/100 != 2 {
+			return "", &statusError{Provider: "ollama", Code: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+		}
+
+		var chat ollamaChatResponse
+		if err = json.Unmarshal(data, &chat); err != nil {
+			return "", irr.Wrap(err, "failed to decode ollama response")
+		}
+		if chat.Error != "" {
+			return "", irr.Error("ollama error: %s", chat.Error)
+		}
+		if strings.TrimSpace(chat.Message.Content) == "" {
+			return "", irr.Error("ollama returned empty content")
+		}
+		reportTokenUsage(ctx, chat.PromptEvalCount, chat.EvalCount)
+		return strings.TrimSpace(chat.Message.Content), nil
+	}
+}
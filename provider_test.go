@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const providerTestDiff = "diff --git a/main.go b/main.go\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -1 +1 @@\n" +
+	"-x := 1\n" +
+	"+x := 2\n"
+
+func newOllamaTestServer(t *testing.T, status int, content string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("ollama path = %q, want /api/chat", r.URL.Path)
+		}
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode ollama request: %v", err)
+		}
+		if req.Model != "test-model" || len(req.Messages) != 2 || req.Stream {
+			t.Errorf("ollama request = %+v, want test-model with system and user messages", req)
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(ollamaChatResponse{Message: ollamaMessage{Role: "assistant", Content: content}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAskProvidersFallsThroughChain(t *testing.T) {
+	server := newOllamaTestServer(t, http.StatusOK, "feat(main): bump x\n")
+
+	doubaoCalls := 0
+	doubaoAsk := func(context.Context, string, string, string) (string, error) {
+		doubaoCalls++
+		return "", errors.New("503 service unavailable: maintenance window")
+	}
+	opts := commentOptions{
+		Diff:      providerTestDiff,
+		AccessKey: "test-ak",
+		SecretKey: "test-sk",
+		Providers: []providerConfig{
+			{Name: "internal", Type: ProviderTypeDoubao, Endpoint: "test-endpoint"},
+			{Name: "local", Type: ProviderTypeOllama, Endpoint: server.URL, Model: "test-model"},
+			{Type: ProviderTypeOffline},
+		},
+	}
+
+	got, err := askProviders(context.Background(), opts, doubaoAsk, modelRequest{Prompt: "prompt", Question: "question"})
+	if err != nil {
+		t.Fatalf("askProviders() error = %v, want ollama answer", err)
+	}
+	if got != "feat(main): bump x" {
+		t.Fatalf("askProviders() = %q, want trimmed ollama answer", got)
+	}
+	if doubaoCalls != 1 {
+		t.Fatalf("doubao called %d times, want 1", doubaoCalls)
+	}
+}
+
+func TestAskProvidersUsesOfflineWhenAllModelsFail(t *testing.T) {
+	server := newOllamaTestServer(t, http.StatusBadGateway, "")
+	opts := commentOptions{
+		Diff: providerTestDiff,
+		Providers: []providerConfig{
+			{Name: "local", Type: ProviderTypeOllama, Endpoint: server.URL, Model: "test-model"},
+			{Type: ProviderTypeOffline},
+		},
+	}
+
+	got, err := askProviders(context.Background(), opts, nil, modelRequest{Prompt: "prompt", Question: "question"})
+	if err != nil {
+		t.Fatalf("askProviders() error = %v, want offline draft", err)
+	}
+	if !strings.HasPrefix(got, "chore: update main.go") {
+		t.Fatalf("askProviders() = %q, want offline heuristic draft", got)
+	}
+}
+
+func TestAskProvidersStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	doubaoAsk := func(context.Context, string, string, string) (string, error) {
+		cancel()
+		return "", context.Canceled
+	}
+	opts := commentOptions{Diff: providerTestDiff, AccessKey: "test-ak", SecretKey: "test-sk", Endpoint: "test-endpoint", Retry: fastRetryPolicy}
+
+	got, err := askProviders(ctx, opts, doubaoAsk, modelRequest{Prompt: "prompt", Question: "question"})
+	if !errors.Is(err, context.Canceled) || got != "" {
+		t.Fatalf("askProviders(cancelled) = %q, %v, want context.Canceled instead of the offline draft", got, err)
+	}
+}
+
+func TestAskProvidersReportsEveryFailureWithoutOffline(t *testing.T) {
+	opts := commentOptions{
+		Diff: providerTestDiff,
+		Providers: []providerConfig{
+			{Name: "internal", Type: ProviderTypeDoubao},
+			{Name: "local", Type: ProviderTypeOllama},
+			{Name: "mystery", Type: "gpt"},
+		},
+	}
+	t.Setenv("VOLC_ACCESSKEY", "")
+	t.Setenv("VOLC_SECRETKEY", "")
+	t.Setenv("DOUBAO_ENDPOINT", "")
+
+	_, err := askProviders(context.Background(), opts, nil, modelRequest{Prompt: "prompt", Question: "question"})
+	if err == nil {
+		t.Fatal("askProviders() error = nil, want all providers failed")
+	}
+	for _, want := range []string{"failed to generate commit message", "internal: Please provide the access key", "local: provider local: ollama requires a model", `unknown type "gpt"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("askProviders() error = %q, want substring %q", err, want)
+		}
+	}
+}
+
+func TestResolveProviderPrefersEndpointFlag(t *testing.T) {
+	t.Setenv("DOUBAO_ENDPOINT", "env-endpoint")
+	conf := providerConfig{Name: "internal", Type: ProviderTypeDoubao, Endpoint: "config-endpoint"}
+	opts := commentOptions{AccessKey: "test-ak", SecretKey: "test-sk"}
+
+	for _, tc := range []struct {
+		flag, config, want string
+	}{
+		{"flag-endpoint", "config-endpoint", "flag-endpoint"},
+		{"", "config-endpoint", "config-endpoint"},
+		{"", "", "env-endpoint"},
+	} {
+		opts.Endpoint, conf.Endpoint = tc.flag, tc.config
+		p, err := resolveProvider(conf, opts, nil)
+		if err != nil || p.Endpoint != tc.want {
+			t.Errorf("resolveProvider(flag %q, config %q) endpoint = %q, %v, want %q", tc.flag, tc.config, p.Endpoint, err, tc.want)
+		}
+	}
+}
+
+func TestAskWithQuestionNamesTheAnswer(t *testing.T) {
+	opts := commentOptions{Providers: []providerConfig{{Name: "local", Type: ProviderTypeOllama}}}
+	_, err := askWithQuestion(context.Background(), opts, nil, modelRequest{Prompt: "prompt", Question: "question", Noun: "summary"})
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to generate summary") {
+		t.Fatalf("askWithQuestion() error = %v, want it to name the summary", err)
+	}
+}
+
+func TestLoadConfigReadsProvidersAndCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "providers:\n" +
+		"  - name: internal\n" +
+		"    type: doubao\n" +
+		"  - type: ollama\n" +
+		"    endpoint: http://127.0.0.1:11434\n" +
+		"    model: qwen2.5-coder\n" +
+		"  - type: offline\n" +
+		"cache:\n" +
+		"  ttl: 24h\n" +
+		"  max_entries: 10\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	conf, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	want := []providerConfig{
+		{Name: "internal", Type: ProviderTypeDoubao},
+		{Type: ProviderTypeOllama, Endpoint: "http://127.0.0.1:11434", Model: "qwen2.5-coder"},
+		{Type: ProviderTypeOffline},
+	}
+	if conf.Cache.TTL != 24*time.Hour || conf.Cache.MaxEntries != 10 {
+		t.Errorf("loadConfig() cache = %+v, want ttl 24h and 10 entries", conf.Cache)
+	}
+	if len(conf.Providers) != len(want) {
+		t.Fatalf("loadConfig() providers = %+v, want %+v", conf.Providers, want)
+	}
+	for i := range want {
+		if conf.Providers[i] != want[i] {
+			t.Errorf("provider[%d] = %+v, want %+v", i, conf.Providers[i], want[i])
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	t.Setenv(EnvKeyConfigPath, filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := loadConfig(""); err == nil {
+		t.Fatal("loadConfig() with missing COMMITRON_CONFIG error = nil, want error")
+	}
+
+	t.Setenv(EnvKeyConfigPath, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	conf, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig() without config file error = %v, want empty config", err)
+	}
+	if len(conf.Providers) != 0 {
+		t.Fatalf("loadConfig() providers = %+v, want none", conf.Providers)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// redactionRule 把匹配的秘密替换为 [REDACTED:name]
+type redactionRule struct {
+	name string
+	re   *regexp.Regexp
+	// group 为 0 时替换整个匹配, 否则只替换该分组, 如 password = "..." 中的值
+	group int
+	// alnum 要求被替换的值同时含有字母和数字, 避免把 token := getToken() 这样的代码当作秘密
+	alnum bool
+}
+
+// redactionCount 是一条规则在一次请求中替换的次数
+type redactionCount struct {
+	Rule  string `json:"rule"`
+	Count int    `json:"count"`
+}
+
+// redactionRules 按顺序应用, 前面规则的替换结果不会再被后面的规则匹配
+var redactionRules = []redactionRule{
+	{name: "private-key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "slack-token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{name: "bearer-token", re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{20,}=*)`), group: 1},
+	{name: "secret-literal", re: regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)\w*["']?\s*:?=?\s*["']([^"'\s]{8,})["']`), group: 1},
+	{name: "secret-assignment", re: regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)\w*\s*[:=]\s*([A-Za-z0-9+/_-]{16,})`), group: 1, alnum: true},
+}
+
+// redactSecrets 替换 text 中像秘密的片段, 返回替换后的文本和每条规则的替换次数 (没有替换时为空切片)
+func redactSecrets(text string) (string, []redactionCount) {
+	counts := []redactionCount{}
+	for _, rule := range redactionRules {
+		n := 0
+		var sb strings.Builder
+		last := 0
+		for _, m := range rule.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*rule.group], m[2*rule.group+1]
+			if start < 0 || strings.HasPrefix(text[start:end], "[REDACTED:") || (rule.alnum && !hasLetterAndDigit(text[start:end])) {
+				continue
+			}
+			sb.WriteString(text[last:start])
+			sb.WriteString("[REDACTED:" + rule.name + "]")
+			last = end
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		sb.WriteString(text[last:])
+		text = sb.String()
+		counts = append(counts, redactionCount{Rule: rule.name, Count: n})
+	}
+	return text, counts
+}
+
+// hasLetterAndDigit 判断 s 是否同时含有字母和数字
+func hasLetterAndDigit(s string) bool {
+	letter, digit := false, false
+	for _, r := range s {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+// redactRequest 在发送前替换问题中的秘密, 并把替换次数记录在请求中
+func redactRequest(req modelRequest) modelRequest {
+	req.Question, req.Redactions = redactSecrets(req.Question)
+	return req
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/khicago/irr"
+)
+
+// retryPolicy 控制单次模型调用的超时以及失败后的重试
+type retryPolicy struct {
+	// Timeout 是每次尝试的超时, 0 表示只受调用方 ctx 约束
+	Timeout time.Duration
+	// MaxRetries 是首次尝试之外的最大重试次数
+	MaxRetries int
+
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	Timeout:         60 * time.Second,
+	MaxRetries:      2,
+	InitialInterval: time.Second,
+	MaxInterval:     10 * time.Second,
+}
+
+// statusError 是模型服务返回的非 2xx HTTP 状态
+type statusError struct {
+	Provider string
+	Code     int
+	Body     string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Provider, e.Code, e.Body)
+}
+
+var (
+	// retryableStatusPattern 匹配无法取得类型化状态的错误 (如 SDK 返回的文本) 中的状态部分:
+	// "status 503"、"code=429" 或位于开头的状态码, 不匹配信息中其他位置出现的数字
+	retryableStatusPattern = regexp.MustCompile(`(?i)(?:^|\b(?:status|code|http)\s*[:=]?\s*)(429|5\d\d)\b`)
+	// retryablePhrases 是暂时性故障的固定说法
+	retryablePhrases = []string{
+		"too many requests", "rate limit", "internal server error", "bad gateway", "service unavailable",
+		"gateway timeout", "connection reset", "connection refused", "temporarily unavailable",
+	}
+)
+
+// withRetry 为 ask 增加单次超时和带抖动的指数退避重试
+//
+// 不可重试的错误立即返回; 重试耗尽后返回的错误会列出每次尝试的失败原因
+func withRetry(ask askQuestionFunc, policy retryPolicy) askQuestionFunc {
+	return func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		var (
+			answer   string
+			failures []string
+		)
+
+		operation := func() error {
+			attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+			if policy.Timeout > 0 {
+				attemptCtx, cancel = context.WithTimeout(ctx, policy.Timeout)
+			}
+			defer cancel()
+
+			got, err := ask(attemptCtx, endpoint, prompt, question)
+			if err == nil {
+				answer = got
+				return nil
+			}
+			failures = append(failures, fmt.Sprintf("attempt %d: %v", len(failures)+1, err))
+			if ctx.Err() != nil || !isRetryableError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+
+		if err := backoff.Retry(operation, newRetryBackOff(ctx, policy)); err != nil {
+			return "", irr.Error("model call failed after %d attempt(s): %s", len(failures), strings.Join(failures, "; "))
+		}
+		return answer, nil
+	}
+}
+
+func newRetryBackOff(ctx context.Context, policy retryPolicy) backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = policy.InitialInterval
+	b.MaxInterval = policy.MaxInterval
+	b.RandomizationFactor = 0.5
+	b.MaxElapsedTime = 0
+
+	retries := policy.MaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+	return backoff.WithContext(backoff.WithMaxRetries(b, uint64(retries)), ctx)
+}
+
+// isRetryableError 判断错误是否为超时、限流或服务端错误等暂时性故障
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code/100 == 5
+	}
+
+	msg := strings.ToLower(err.Error())
+	if retryableStatusPattern.MatchString(msg) {
+		return true
+	}
+	for _, phrase := range retryablePhrases {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khicago/irr"
+)
+
+var fastRetryPolicy = retryPolicy{
+	Timeout:         time.Second,
+	MaxRetries:      3,
+	InitialInterval: time.Millisecond,
+	MaxInterval:     2 * time.Millisecond,
+}
+
+// failingAsk 前 failures 次调用返回 err, 之后返回固定答案
+func failingAsk(failures int, err error, calls *int) askQuestionFunc {
+	return func(context.Context, string, string, string) (string, error) {
+		*calls++
+		if *calls <= failures {
+			return "", err
+		}
+		return "fix(retry): recover after transient errors", nil
+	}
+}
+
+func TestWithRetryRecoversFromTransientErrors(t *testing.T) {
+	calls := 0
+	ask := withRetry(failingAsk(2, errors.New("status 503: service unavailable"), &calls), fastRetryPolicy)
+
+	got, err := ask(context.Background(), "test-endpoint", "prompt", "question")
+	if err != nil {
+		t.Fatalf("withRetry() error = %v, want nil", err)
+	}
+	if got != "fix(retry): recover after transient errors" {
+		t.Fatalf("withRetry() = %q, want answer from third attempt", got)
+	}
+	if calls != 3 {
+		t.Fatalf("ask called %d times, want 3", calls)
+	}
+}
+
+func TestWithRetryListsAttemptsWhenRetriesExhausted(t *testing.T) {
+	calls := 0
+	ask := withRetry(failingAsk(10, errors.New("429 Too Many Requests"), &calls), fastRetryPolicy)
+
+	_, err := ask(context.Background(), "test-endpoint", "prompt", "question")
+	if err == nil {
+		t.Fatal("withRetry() error = nil, want exhausted retries")
+	}
+	if calls != 4 {
+		t.Fatalf("ask called %d times, want 1 attempt + 3 retries", calls)
+	}
+	for _, want := range []string{"after 4 attempt(s)", "attempt 1: 429", "attempt 4: 429"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("withRetry() error = %q, want substring %q", err, want)
+		}
+	}
+}
+
+func TestWithRetryStopsOnFatalErrors(t *testing.T) {
+	calls := 0
+	ask := withRetry(failingAsk(10, errors.New("401 unauthorized: invalid access key"), &calls), fastRetryPolicy)
+
+	_, err := ask(context.Background(), "test-endpoint", "prompt", "question")
+	if err == nil {
+		t.Fatal("withRetry() error = nil, want fatal error")
+	}
+	if calls != 1 {
+		t.Fatalf("ask called %d times, want no retry for fatal errors", calls)
+	}
+	if !strings.Contains(err.Error(), "after 1 attempt(s)") {
+		t.Fatalf("withRetry() error = %q, want single attempt summary", err)
+	}
+}
+
+func TestWithRetryAppliesPerAttemptTimeout(t *testing.T) {
+	calls := 0
+	slowThenFast := func(ctx context.Context, _, _, _ string) (string, error) {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "fix(retry): second attempt", nil
+	}
+	policy := fastRetryPolicy
+	policy.Timeout = 10 * time.Millisecond
+
+	got, err := withRetry(slowThenFast, policy)(context.Background(), "test-endpoint", "prompt", "question")
+	if err != nil {
+		t.Fatalf("withRetry() error = %v, want retry after timeout", err)
+	}
+	if got != "fix(retry): second attempt" || calls != 2 {
+		t.Fatalf("withRetry() = %q after %d calls, want second attempt answer", got, calls)
+	}
+}
+
+func TestWithRetryHonorsCallerCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	ask := func(context.Context, string, string, string) (string, error) {
+		calls++
+		cancel()
+		return "", errors.New("503 service unavailable")
+	}
+
+	if _, err := withRetry(ask, fastRetryPolicy)(ctx, "test-endpoint", "prompt", "question"); err == nil {
+		t.Fatal("withRetry() error = nil, want cancellation error")
+	}
+	if calls != 1 {
+		t.Fatalf("ask called %d times after caller cancellation, want 1", calls)
+	}
+}
+
+func TestIsRetryableErrorReadsOnlyTheStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&statusError{Provider: "ollama", Code: 503, Body: "overloaded"}, true},
+		{irr.Wrap(&statusError{Provider: "ollama", Code: 429}, "ollama request failed"), true},
+		{&statusError{Provider: "ollama", Code: 400, Body: "prompt of 5000 tokens exceeds 500"}, false},
+		{errors.New("code=429, message=quota exceeded"), true},
+		{errors.New("502 Bad Gateway"), true},
+		{fmt.Errorf("read response: %w", io.ErrUnexpectedEOF), true},
+		{errors.New("status 400: endpoint ep-20260500 rejected the request"), false},
+		{errors.New("invalid config: retry timeout must be positive"), false},
+		{errors.New("401 unauthorized: request of 5000 bytes"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryableError(tt.err); got != tt.want {
+			t.Errorf("isRetryableError(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
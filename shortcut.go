@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// shortcutRule 针对一类无需模型的变更生成确定性的提交信息
+type shortcutRule func(files []fileDiff) (string, bool)
+
+// shortcutRules 按优先级排列, 第一个命中的规则生效
+var shortcutRules = []shortcutRule{
+	fileDeletionShortcut,
+	pureRenameShortcut,
+	dependencyBumpShortcut,
+	formattingOnlyShortcut,
+	docsOnlyShortcut,
+}
+
+// shortcutMessage 识别无需调用模型的简单变更, 命中时直接返回提交信息
+func shortcutMessage(diff string) (string, bool) {
+	files := parseDiff(diff)
+	if len(files) == 0 {
+		return "", false
+	}
+	for _, rule := range shortcutRules {
+		if msg, ok := rule(files); ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// conventionalHeader 拼接 type(scope): subject 形式的 header
+func conventionalHeader(typ, scope, subject string) string {
+	if scope == "" {
+		return fmt.Sprintf("%s: %s", typ, subject)
+	}
+	return fmt.Sprintf("%s(%s): %s", typ, scope, subject)
+}
+
+// commitScope 依据变更路径推断 scope: 所有文件位于同一顶层目录时取该目录名
+func commitScope(paths []string) string {
+	scope := ""
+	for i, p := range paths {
+		dir := getDirectory(p)
+		if dir == "root" {
+			return ""
+		}
+		if i > 0 && dir != scope {
+			return ""
+		}
+		scope = dir
+	}
+	return scope
+}
+
+func diffPaths(files []fileDiff) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path())
+	}
+	return paths
+}
+
+// joinMessage 拼接 header 与 body 列表
+func joinMessage(header string, bodyLines []string) string {
+	if len(bodyLines) == 0 {
+		return header
+	}
+	return header + "\n\n" + strings.Join(bodyLines, "\n")
+}
+
+func fileDeletionShortcut(files []fileDiff) (string, bool) {
+	for _, f := range files {
+		if !f.IsDeleted {
+			return "", false
+		}
+	}
+	scope := commitScope(diffPaths(files))
+	if len(files) == 1 {
+		return conventionalHeader("chore", scope, "remove "+files[0].OldPath), true
+	}
+	var body []string
+	for _, f := range files {
+		body = append(body, "- remove "+f.OldPath)
+	}
+	return joinMessage(conventionalHeader("chore", scope, fmt.Sprintf("remove %d files", len(files))), body), true
+}
+
+func pureRenameShortcut(files []fileDiff) (string, bool) {
+	for _, f := range files {
+		if !f.IsRename || f.Added+f.Removed > 0 {
+			return "", false
+		}
+	}
+	describe := func(f fileDiff) string {
+		verb := "move"
+		if path.Dir(f.OldPath) == path.Dir(f.NewPath) {
+			verb = "rename"
+		}
+		return fmt.Sprintf("%s %s to %s", verb, f.OldPath, f.NewPath)
+	}
+	scope := commitScope(append(diffPaths(files), oldPaths(files)...))
+	if len(files) == 1 {
+		return conventionalHeader("refactor", scope, describe(files[0])), true
+	}
+	var body []string
+	for _, f := range files {
+		body = append(body, "- "+describe(f))
+	}
+	return joinMessage(conventionalHeader("refactor", scope, fmt.Sprintf("move %d files", len(files))), body), true
+}
+
+func oldPaths(files []fileDiff) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.OldPath)
+	}
+	return paths
+}
+
+// dependencyBumpShortcut 识别只修改 go.mod/go.sum 中依赖版本的变更
+func dependencyBumpShortcut(files []fileDiff) (string, bool) {
+	var goModFiles []fileDiff
+	for _, f := range files {
+		switch path.Base(f.Path()) {
+		case "go.mod":
+			goModFiles = append(goModFiles, f)
+		case "go.sum":
+		default:
+			return "", false
+		}
+	}
+
+	before, after := make(map[string]string), make(map[string]string)
+	for _, f := range goModFiles {
+		for _, line := range f.RemovedLines {
+			mod, version, ok := parseRequireLine(line)
+			if !ok {
+				return "", false
+			}
+			before[mod] = version
+		}
+		for _, line := range f.AddedLines {
+			mod, version, ok := parseRequireLine(line)
+			if !ok {
+				return "", false
+			}
+			after[mod] = version
+		}
+	}
+
+	var changes []string
+	for mod, to := range after {
+		if from, ok := before[mod]; !ok {
+			changes = append(changes, fmt.Sprintf("add %s %s", mod, to))
+		} else if from != to {
+			changes = append(changes, fmt.Sprintf("bump %s from %s to %s", mod, from, to))
+		}
+	}
+	for mod, from := range before {
+		if _, ok := after[mod]; !ok {
+			changes = append(changes, fmt.Sprintf("remove %s %s", mod, from))
+		}
+	}
+	sort.Strings(changes)
+
+	switch len(changes) {
+	case 0:
+		if len(goModFiles) > 0 {
+			return "", false
+		}
+		return conventionalHeader("build", "deps", "update go.sum"), true
+	case 1:
+		return conventionalHeader("build", "deps", changes[0]), true
+	}
+	body := make([]string, 0, len(changes))
+	for _, change := range changes {
+		body = append(body, "- "+change)
+	}
+	return joinMessage(conventionalHeader("build", "deps", fmt.Sprintf("update %d dependencies", len(changes))), body), true
+}
+
+// parseRequireLine 解析 go.mod 中的 require 行, 空行和 require 块的括号视为合法但无依赖
+func parseRequireLine(line string) (mod, version string, ok bool) {
+	line = strings.TrimSpace(line)
+	if idx := strings.Index(line, "//"); idx >= 0 {
+		line = strings.TrimSpace(line[:idx])
+	}
+	switch line {
+	case "", "require (", ")":
+		return "", "", true
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "require "))
+	if len(fields) != 2 || !strings.HasPrefix(fields[1], "v") {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
+
+// formattingOnlyShortcut 识别只改动 Go 代码空白的变更, 例如 gofmt
+//
+// 其他语言不处理: Python、YAML 的缩进和 Makefile 的 tab 都有语义
+func formattingOnlyShortcut(files []fileDiff) (string, bool) {
+	for _, f := range files {
+		if f.IsNew || f.IsDeleted || f.IsBinary || f.Added+f.Removed == 0 || !strings.HasSuffix(f.Path(), ".go") {
+			return "", false
+		}
+		if !sameGoTokens(f.AddedLines, f.RemovedLines) {
+			return "", false
+		}
+	}
+	return conventionalHeader("style", commitScope(diffPaths(files)), "apply gofmt"), true
+}
+
+// sameGoTokens 判断删除的行和新增的行按顺序是否为相同的 Go 词法单元; 字符串字面量中的空白照常比较
+//
+// 换行自动插入的分号不参与比较, 无法完整扫描的片段 (如只改到一半的原始字符串) 视为不同
+func sameGoTokens(added, removed []string) bool {
+	a, okA := goTokens(added)
+	b, okB := goTokens(removed)
+	return okA && okB && slices.Equal(a, b)
+}
+
+// goTokens 返回各行拼接后的词法单元, 注释去掉行尾空白后保留
+func goTokens(lines []string) ([]string, bool) {
+	src := []byte(strings.Join(lines, "\n"))
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	failed := false
+	s.Init(file, src, func(token.Position, string) { failed = true }, scanner.ScanComments)
+	var tokens []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if tok == token.COMMENT {
+			lit = strings.TrimRight(lit, " \t\r")
+		}
+		tokens = append(tokens, tok.String()+" "+lit)
+	}
+	return tokens, !failed
+}
+
+// docsOnlyShortcut 识别只修改文档的变更
+func docsOnlyShortcut(files []fileDiff) (string, bool) {
+	for _, f := range files {
+		if !isDocPath(f.Path()) || f.Added+f.Removed == 0 {
+			return "", false
+		}
+	}
+	scope := commitScope(diffPaths(files))
+	if len(files) == 1 {
+		verb := "update"
+		if files[0].IsNew {
+			verb = "add"
+		}
+		return conventionalHeader("docs", scope, fmt.Sprintf("%s %s", verb, path.Base(files[0].Path()))), true
+	}
+	var body []string
+	for _, f := range files {
+		body = append(body, "- update "+f.Path())
+	}
+	return joinMessage(conventionalHeader("docs", scope, "update documentation"), body), true
+}
+
+// isDocPath 判断路径是否为文档文件; CMakeLists.txt、requirements.txt 这类按文件名能识别出其他语言的文件不算
+func isDocPath(p string) bool {
+	base := strings.ToLower(path.Base(p))
+	if lang, ok := languageByName[base]; ok && lang != "Text" {
+		return false
+	}
+	switch path.Ext(base) {
+	case ".md", ".markdown", ".rst", ".adoc", ".txt":
+		return true
+	}
+	if strings.HasPrefix(base, "license") || strings.HasPrefix(base, "changelog") {
+		return true
+	}
+	return strings.HasPrefix(p, "docs/") || strings.Contains(p, "/docs/")
+}
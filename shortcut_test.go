@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestShortcutMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			name: "gofmt only",
+			diff: "diff --git a/cmd/main.go b/cmd/main.go\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/cmd/main.go\n" +
+				"+++ b/cmd/main.go\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				"-func main(){\n" +
+				"-  x:=1\n" +
+				"+func main() {\n" +
+				"+\tx := 1\n",
+			want: "style(cmd): apply gofmt",
+		},
+		{
+			name: "pure rename",
+			diff: "diff --git a/pkg/old.go b/internal/new.go\n" +
+				"similarity index 100%\n" +
+				"rename from pkg/old.go\n" +
+				"rename to internal/new.go\n",
+			want: "refactor: move pkg/old.go to internal/new.go",
+		},
+		{
+			name: "dependency bump",
+			diff: "diff --git a/go.mod b/go.mod\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/go.mod\n" +
+				"+++ b/go.mod\n" +
+				"@@ -5,3 +5,3 @@ require (\n" +
+				"-\tgithub.com/sirupsen/logrus v1.9.2\n" +
+				"+\tgithub.com/sirupsen/logrus v1.9.3\n" +
+				"diff --git a/go.sum b/go.sum\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/go.sum\n" +
+				"+++ b/go.sum\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"-github.com/sirupsen/logrus v1.9.2 h1:old=\n" +
+				"+github.com/sirupsen/logrus v1.9.3 h1:new=\n",
+			want: "build(deps): bump github.com/sirupsen/logrus from v1.9.2 to v1.9.3",
+		},
+		{
+			name: "multiple dependency changes",
+			diff: "diff --git a/go.mod b/go.mod\n" +
+				"--- a/go.mod\n" +
+				"+++ b/go.mod\n" +
+				"@@ -5,3 +5,3 @@ require (\n" +
+				"-\tgithub.com/a/x v1.0.0\n" +
+				"+\tgithub.com/a/x v1.1.0\n" +
+				"+\tgithub.com/b/y v0.2.0 // indirect\n",
+			want: "build(deps): update 2 dependencies\n\n" +
+				"- add github.com/b/y v0.2.0\n" +
+				"- bump github.com/a/x from v1.0.0 to v1.1.0",
+		},
+		{
+			name: "docs only",
+			diff: "diff --git a/README.md b/README.md\n" +
+				"--- a/README.md\n" +
+				"+++ b/README.md\n" +
+				"@@ -1 +1 @@\n" +
+				"-# Old\n" +
+				"+# New\n",
+			want: "docs: update README.md",
+		},
+		{
+			name: "file deletions",
+			diff: "diff --git a/tools/a.sh b/tools/a.sh\n" +
+				"deleted file mode 100755\n" +
+				"--- a/tools/a.sh\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-echo a\n" +
+				"diff --git a/tools/b.sh b/tools/b.sh\n" +
+				"deleted file mode 100755\n" +
+				"--- a/tools/b.sh\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-echo b\n",
+			want: "chore(tools): remove 2 files\n\n- remove tools/a.sh\n- remove tools/b.sh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := shortcutMessage(tt.diff)
+			if !ok {
+				t.Fatalf("shortcutMessage() ok = false, want shortcut %q", tt.want)
+			}
+			if got != tt.want {
+				t.Fatalf("shortcutMessage() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortcutMessageIgnoresRealChanges(t *testing.T) {
+	tests := map[string]string{
+		"code change": "diff --git a/main.go b/main.go\n" +
+			"--- a/main.go\n" +
+			"+++ b/main.go\n" +
+			"@@ -1 +1 @@\n" +
+			"-x := 1\n" +
+			"+x := 2\n",
+		"go directive change": "diff --git a/go.mod b/go.mod\n" +
+			"--- a/go.mod\n" +
+			"+++ b/go.mod\n" +
+			"@@ -1 +1 @@\n" +
+			"-go 1.21\n" +
+			"+go 1.22\n",
+		"rename with edits": "diff --git a/a.go b/b.go\n" +
+			"similarity index 90%\n" +
+			"rename from a.go\n" +
+			"rename to b.go\n" +
+			"@@ -1 +1 @@\n" +
+			"-package a\n" +
+			"+package b\n",
+		"header only": "diff --git a/a.txt b/a.txt\n",
+		"requirements.txt": "diff --git a/requirements.txt b/requirements.txt\n" +
+			"--- a/requirements.txt\n" +
+			"+++ b/requirements.txt\n" +
+			"@@ -1 +1 @@\n" +
+			"-requests==2.31.0\n" +
+			"+requests==2.32.0\n",
+		"CMakeLists.txt": "diff --git a/CMakeLists.txt b/CMakeLists.txt\n" +
+			"--- a/CMakeLists.txt\n" +
+			"+++ b/CMakeLists.txt\n" +
+			"@@ -1 +1 @@\n" +
+			"-cmake_minimum_required(VERSION 3.10)\n" +
+			"+cmake_minimum_required(VERSION 3.20)\n",
+		"python indentation": "diff --git a/app.py b/app.py\n" +
+			"--- a/app.py\n" +
+			"+++ b/app.py\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			" if ready:\n" +
+			"-    b()\n" +
+			"+b()\n",
+		"go string literal": "diff --git a/main.go b/main.go\n" +
+			"--- a/main.go\n" +
+			"+++ b/main.go\n" +
+			"@@ -1 +1 @@\n" +
+			"-var s = \"a b\"\n" +
+			"+var s = \"ab\"\n",
+		"yaml nesting": "diff --git a/deploy.yaml b/deploy.yaml\n" +
+			"--- a/deploy.yaml\n" +
+			"+++ b/deploy.yaml\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			" spec:\n" +
+			"-  replicas: 2\n" +
+			"+replicas: 2\n",
+		"swapped lines": "diff --git a/svc/store.go b/svc/store.go\n" +
+			"--- a/svc/store.go\n" +
+			"+++ b/svc/store.go\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			"-\ts.write()\n" +
+			"-\ts.mu.Unlock()\n" +
+			"+\ts.mu.Unlock()\n" +
+			"+\ts.write()\n",
+	}
+
+	for name, diff := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got, ok := shortcutMessage(diff); ok {
+				t.Fatalf("shortcutMessage() = %q, want no shortcut", got)
+			}
+		})
+	}
+}
+
+func TestGenerateCommentAnswersShortcutsWithoutModel(t *testing.T) {
+	diff := "diff --git a/README.md b/README.md\n" +
+		"--- a/README.md\n" +
+		"+++ b/README.md\n" +
+		"@@ -1 +1 @@\n" +
+		"-# Old\n" +
+		"+# New\n"
+
+	ask := func(context.Context, string, string, string) (string, error) {
+		t.Fatal("generateComment called the model for a docs-only change")
+		return "", nil
+	}
+	got, err := generateComment(context.Background(), commentOptions{Diff: diff}, ask)
+	if err != nil {
+		t.Fatalf("generateComment() error = %v, want nil", err)
+	}
+	if got != "docs: update README.md" {
+		t.Fatalf("generateComment() = %q, want docs shortcut", got)
+	}
+
+	called := false
+	ask = func(context.Context, string, string, string) (string, error) {
+		called = true
+		return "docs: rewrite the readme title", nil
+	}
+	opts := commentOptions{Diff: diff, AccessKey: "test-ak", SecretKey: "test-sk", Endpoint: "test-endpoint", NoShortcuts: true}
+	if _, err := generateComment(context.Background(), opts, ask); err != nil {
+		t.Fatalf("generateComment() with NoShortcuts error = %v, want nil", err)
+	}
+	if !called {
+		t.Fatal("generateComment() with NoShortcuts did not call the model")
+	}
+}
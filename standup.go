@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/khicago/got/util/typer"
+	"github.com/khicago/irr"
+)
+
+// standupConfig 是配置文件中 standup 的部分
+type standupConfig struct {
+	// Repos 是默认汇总的本地仓库路径, 可以用 ~/ 开头; 为空时只看当前仓库
+	Repos []string `yaml:"repos,omitempty"`
+}
+
+// standupOptions 汇总 standup 子命令的输入
+type standupOptions struct {
+	// Repos 是要汇总的仓库, 为空时使用配置中的 standup.repos, 再为空时使用当前仓库
+	Repos []string
+	// Committers 为空时使用每个仓库的 git config user.email
+	Committers []string
+	// Since 直接传给 git log, 为空时从上一个工作日的零点开始
+	Since      string
+	ConfigPath string
+	// LLM 请模型根据收集到的素材改写草稿, 否则只用本地启发式
+	LLM    bool
+	Prompt string
+	// DryRun 只打印 LLM 模式下将要发送的内容, 不联系模型
+	DryRun  bool
+	NoCache bool
+	Retry   retryPolicy
+	Output  string
+}
+
+// standupOperations 是 git 目录中表示操作进行中的文件, 按检查顺序排列
+var standupOperations = []struct{ file, name string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// standupAheadPattern 匹配 git status --branch 中尚未推送的提交数
+var standupAheadPattern = regexp.MustCompile(`\[ahead (\d+)`)
+
+// standupRepo 是一个仓库中的站会素材
+type standupRepo struct {
+	Name    string
+	Commits []commitRecord
+	// Branch 是当前分支, 分离 HEAD 时为空
+	Branch string
+	// Ahead 是当前分支领先上游的提交数
+	Ahead int
+	workTree
+	// Operation 是进行中的 rebase、merge、cherry-pick、revert 或 bisect
+	Operation string
+}
+
+// workTree 是 git status 中的未提交改动
+type workTree struct {
+	Staged, Modified, Untracked, Conflicted []string
+}
+
+// empty 判断工作区是否干净
+func (w workTree) empty() bool {
+	return len(w.Staged)+len(w.Modified)+len(w.Untracked)+len(w.Conflicted) == 0
+}
+
+// standup 汇总当前用户从上一个工作日起的提交和进行中的工作, 生成 yesterday / today / blockers 草稿
+func standup(ctx context.Context, opts standupOptions) error {
+	conf, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if len(opts.Repos) == 0 {
+		opts.Repos = conf.Standup.Repos
+	}
+	ask := commentOptions{Providers: conf.Providers, Retry: opts.Retry}
+	if opts.LLM {
+		if !opts.NoCache {
+			if ask.Cache, err = newResponseCache(conf.Cache); err != nil {
+				return err
+			}
+		}
+		if ask.Audit, err = newAuditLog(conf.Audit); err != nil {
+			return err
+		}
+		if ask.Usage, err = newUsageLedger(conf.Usage); err != nil {
+			return err
+		}
+	}
+
+	text, err := runStandup(ctx, opts, conf.Identities, ask, SimpleQuestion, time.Now())
+	if err != nil {
+		return err
+	}
+	return writeInsightOutput(opts.Output, []byte(text+"\n"))
+}
+
+// runStandup 收集各仓库的素材并生成草稿, 只有 --llm 时才用到 ask 和 doubaoAsk; now 决定默认的起始时间
+func runStandup(ctx context.Context, opts standupOptions, identities map[string][]string, ask commentOptions, doubaoAsk askQuestionFunc, now time.Time) (string, error) {
+	if opts.DryRun && !opts.LLM {
+		return "", irr.Error("--dry-run only applies with --llm, the heuristic draft sends nothing")
+	}
+	since := opts.Since
+	if since == "" {
+		since = previousWorkingDay(now).Format("2006-01-02 15:04:05 -0700")
+	}
+	dirs := opts.Repos
+	if len(dirs) == 0 {
+		dirs = []string{""}
+	}
+
+	repos := make([]standupRepo, 0, len(dirs))
+	for _, dir := range dirs {
+		dir, err := expandHome(dir)
+		if err != nil {
+			return "", err
+		}
+		repo, err := collectStandupRepo(dir, opts.Committers, identities, since)
+		if err != nil {
+			return "", err
+		}
+		repos = append(repos, repo)
+	}
+
+	draft := standupDraft(repos)
+	if !opts.LLM {
+		return draft, nil
+	}
+
+	// disable logrus to hide bot debug
+	logrus.SetOutput(io.Discard)
+	req := modelRequest{
+		Prompt:   typer.Or(opts.Prompt, standupPrompt),
+		Question: standupQuestion(repos, since),
+		Offline:  func() string { return draft },
+		Noun:     "standup note",
+	}
+	if opts.DryRun {
+		return describeQuestionDryRun(ask, req), nil
+	}
+	return askWithQuestion(ctx, ask, doubaoAsk, req)
+}
+
+// previousWorkingDay 返回 now 之前最近一个工作日 (周一到周五) 的零点, 使用 now 的时区
+func previousWorkingDay(now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// expandHome 将 ~/ 开头的路径展开到用户主目录
+func expandHome(dir string) (string, error) {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", irr.Wrap(err, "failed to expand %s", dir)
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~")), nil
+}
+
+// collectStandupRepo 读取 dir 中 since 之后全部本地分支上的提交、未提交的改动和进行中的操作
+//
+// committers 为空时使用该仓库的 git config user.email, 因此不同仓库可以使用不同的邮箱
+func collectStandupRepo(dir string, committers []string, identities map[string][]string, since string) (standupRepo, error) {
+	top, err := executeGitCommandIn(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return standupRepo{}, irr.Wrap(err, "%s is not a git repository", typer.Or(dir, "the current directory"))
+	}
+	repo := standupRepo{Name: filepath.Base(strings.TrimSpace(top))}
+
+	if len(committers) == 0 {
+		email, _ := executeGitCommandIn(dir, "config", "user.email")
+		if email = strings.TrimSpace(email); email == "" {
+			return standupRepo{}, irr.Error("%s: git config user.email is not set, pass --committer", repo.Name)
+		}
+		committers = []string{email}
+	}
+	if repo.Commits, err = getUserCommits(dir, insightOptions{Committers: committers, Identities: identities, Since: since, Branches: true, NoMerges: true}); err != nil {
+		return standupRepo{}, irr.Wrap(err, "failed to read the commits in %s", repo.Name)
+	}
+	sort.SliceStable(repo.Commits, func(i, j int) bool { return repo.Commits[i].AuthorDate.Before(repo.Commits[j].AuthorDate) })
+
+	status, err := executeGitCommandIn(dir, "status", "--porcelain=v1", "--branch", "-z")
+	if err != nil {
+		return standupRepo{}, irr.Wrap(err, "failed to read the status of %s", repo.Name)
+	}
+	repo.Branch, repo.Ahead, repo.workTree = parseStatusPorcelain(status)
+
+	for _, op := range standupOperations {
+		p, err := executeGitCommandIn(dir, "rev-parse", "--git-path", op.file)
+		if err != nil {
+			continue
+		}
+		if p = strings.TrimSpace(p); !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if _, err = os.Stat(p); err == nil {
+			repo.Operation = op.name
+			break
+		}
+	}
+	return repo, nil
+}
+
+// parseStatusPorcelain 解析 git status --porcelain=v1 --branch -z 的输出, 返回当前分支、领先上游的提交数和未提交的改动
+func parseStatusPorcelain(output string) (branch string, ahead int, tree workTree) {
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if header, ok := strings.CutPrefix(entry, "## "); ok {
+			if m := standupAheadPattern.FindStringSubmatch(header); m != nil {
+				ahead, _ = strconv.Atoi(m[1])
+			}
+			header, _, _ = strings.Cut(header, " [")
+			header, _, _ = strings.Cut(header, "...")
+			switch {
+			case strings.HasPrefix(header, "No commits yet on "):
+				branch = strings.TrimPrefix(header, "No commits yet on ")
+			case header != "HEAD (no branch)":
+				branch = header
+			}
+			continue
+		}
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, file := entry[0], entry[1], entry[3:]
+		// 重命名和复制的原路径是下一项
+		if x == 'R' || x == 'C' {
+			i++
+		}
+		switch {
+		case x == '?' && y == '?':
+			tree.Untracked = append(tree.Untracked, file)
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			tree.Conflicted = append(tree.Conflicted, file)
+		default:
+			if x != ' ' {
+				tree.Staged = append(tree.Staged, file)
+			}
+			if y != ' ' {
+				tree.Modified = append(tree.Modified, file)
+			}
+		}
+	}
+	return branch, ahead, tree
+}
+
+// standupDraft 不调用模型, 用提交标题、分支和未提交的改动生成 yesterday / today / blockers 草稿
+func standupDraft(repos []standupRepo) string {
+	var yesterday, today, blockers []string
+	for _, r := range repos {
+		label := ""
+		if len(repos) > 1 {
+			label = "[" + r.Name + "] "
+		}
+		for _, c := range r.Commits {
+			yesterday = append(yesterday, fmt.Sprintf("- %s%s (%s)", label, c.Subject, c.ShortHash()))
+		}
+
+		branch := typer.Or(r.Branch, "detached HEAD")
+		if wip := standupWorkInProgress(r.workTree); wip != "" {
+			today = append(today, fmt.Sprintf("- %sContinue on %s: %s", label, branch, wip))
+		}
+		if r.Ahead > 0 {
+			today = append(today, fmt.Sprintf("- %sPush %d commits on %s", label, r.Ahead, branch))
+		}
+
+		if r.Operation != "" {
+			blockers = append(blockers, fmt.Sprintf("- %s%s in progress on %s", label, r.Operation, branch))
+		}
+		if len(r.Conflicted) > 0 {
+			blockers = append(blockers, fmt.Sprintf("- %sConflicts in %s", label, standupFileList(r.Conflicted)))
+		}
+	}
+
+	var sb strings.Builder
+	for _, section := range []struct {
+		title, none string
+		lines       []string
+	}{
+		{"Yesterday", "No commits", yesterday},
+		{"Today", "Nothing in progress", today},
+		{"Blockers", "None", blockers},
+	} {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("## " + section.title + "\n")
+		if len(section.lines) == 0 {
+			sb.WriteString("- " + section.none + "\n")
+			continue
+		}
+		sb.WriteString(strings.Join(section.lines, "\n") + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// standupWorkInProgress 描述未提交的改动, 如 "a.go, b.go (1 staged, 1 modified)"; 没有改动时为空
+func standupWorkInProgress(tree workTree) string {
+	var files, counts []string
+	seen := make(map[string]bool)
+	for _, group := range []struct {
+		name  string
+		files []string
+	}{
+		{"staged", tree.Staged},
+		{"modified", tree.Modified},
+		{"untracked", tree.Untracked},
+	} {
+		if len(group.files) == 0 {
+			continue
+		}
+		counts = append(counts, fmt.Sprintf("%d %s", len(group.files), group.name))
+		for _, f := range group.files {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", standupFileList(files), strings.Join(counts, ", "))
+}
+
+// standupFileList 列出前 maxListedFiles 个文件, 其余只给出数量
+func standupFileList(files []string) string {
+	if len(files) <= maxListedFiles {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:maxListedFiles], ", "), len(files)-maxListedFiles)
+}
+
+// standupPrompt 是 --llm 未指定 --prompt 时使用的提示词
+const standupPrompt = `You write a developer's daily standup note from their git activity. The question lists, per repository, the developer's commits since the previous working day with subjects and diffstats, then their uncommitted work; it contains no code.
+
+Write concise Markdown in the first person with exactly these sections:
+## Yesterday
+What was done, grouping related commits into one bullet.
+## Today
+What is likely next, based on the uncommitted work, unpushed commits and unfinished threads in the commits.
+## Blockers
+Only conflicts, interrupted rebases or merges, or blockers the commit messages state. Write "- None" otherwise.
+
+Do not invent work the activity does not show. Output only the note.`
+
+// standupQuestion 按仓库列出提交、分支和未提交的改动
+func standupQuestion(repos []standupRepo, since string) string {
+	var sb strings.Builder
+	var commits []commitRecord
+	for _, r := range repos {
+		commits = append(commits, r.Commits...)
+	}
+	if len(commits) > 0 {
+		sb.WriteString("Author: " + strings.Join(commitAuthors(commits), ", ") + "\n")
+	}
+	sb.WriteString("Since: " + since + "\n")
+
+	for _, r := range repos {
+		sb.WriteString(fmt.Sprintf("\n## Repository %s, branch %s", r.Name, typer.Or(r.Branch, "detached HEAD")))
+		if r.Ahead > 0 {
+			sb.WriteString(fmt.Sprintf(", %d commits not pushed", r.Ahead))
+		}
+		sb.WriteString("\n\nCommits, oldest first:\n")
+		if len(r.Commits) == 0 {
+			sb.WriteString("- none\n")
+		}
+		for i, c := range r.Commits {
+			entry := commitEntry(c)
+			if sb.Len()+len(entry) > maxDiffLength {
+				sb.WriteString(fmt.Sprintf("- ... %d more commits omitted\n", len(r.Commits)-i))
+				break
+			}
+			sb.WriteString(entry)
+		}
+
+		sb.WriteString("\nUncommitted work:\n")
+		if r.empty() && r.Operation == "" {
+			sb.WriteString("- none\n")
+		}
+		for _, group := range []struct {
+			name  string
+			files []string
+		}{
+			{"staged", r.Staged},
+			{"modified", r.Modified},
+			{"untracked", r.Untracked},
+			{"conflicts", r.Conflicted},
+		} {
+			if len(group.files) > 0 {
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", group.name, standupFileList(group.files)))
+			}
+		}
+		if r.Operation != "" {
+			sb.WriteString("- " + r.Operation + " in progress\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
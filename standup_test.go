@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviousWorkingDay(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), "2026-10-16"}, // Monday
+		{time.Date(2026, 10, 20, 0, 30, 0, 0, time.UTC), "2026-10-19"},
+		{time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), "2026-10-16"}, // Sunday
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), "2026-10-16"}, // Saturday
+	}
+	for _, tt := range tests {
+		got := previousWorkingDay(tt.now)
+		if got.Format("2006-01-02 15:04") != tt.want+" 00:00" {
+			t.Errorf("previousWorkingDay(%s) = %s, want %s 00:00", tt.now.Format("Mon 2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestParseStatusPorcelain(t *testing.T) {
+	status := "## feature...origin/feature [ahead 2, behind 1]\x00M  a.go\x00 M b.go\x00MM c.go\x00R  new.go\x00old.go\x00?? d.go\x00UU e.go\x00"
+	branch, ahead, tree := parseStatusPorcelain(status)
+	if branch != "feature" || ahead != 2 {
+		t.Errorf("branch, ahead = %q, %d, want feature, 2", branch, ahead)
+	}
+	if strings.Join(tree.Staged, ",") != "a.go,c.go,new.go" || strings.Join(tree.Modified, ",") != "b.go,c.go" ||
+		strings.Join(tree.Untracked, ",") != "d.go" || strings.Join(tree.Conflicted, ",") != "e.go" {
+		t.Errorf("work tree = %+v", tree)
+	}
+
+	if branch, _, _ = parseStatusPorcelain("## HEAD (no branch)\x00"); branch != "" {
+		t.Errorf("detached branch = %q, want empty", branch)
+	}
+	if branch, _, _ = parseStatusPorcelain("## No commits yet on main\x00"); branch != "main" {
+		t.Errorf("unborn branch = %q, want main", branch)
+	}
+}
+
+func TestRunStandupAcrossRepos(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2026, 10, day, 9, 0, 0, 0, time.UTC) }
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ann := "Ann <ann@example.com>"
+	api := newScriptRepo(t, scriptCommit(ann, at(15), "chore: last week", scriptFile("list.go", "package api\n"))+
+		scriptCommit(ann, at(16), "feat: add search", scriptFile("list.go", "package api\n\nfunc Search() {}\n"))+
+		scriptCommit("Bob <bob@example.com>", at(16), "docs: by someone else", scriptFile("README.md", "# api\n"))+
+		scriptCommit(ann, at(18), "fix: handle empty query", scriptFile("list.go", "package api\n\nfunc Search(q string) {}\n")))
+	git(api, "config", "user.email", "ann@example.com")
+	// 最新的 fix 只在 main 上, 当前分支 feature 从它之前分出
+	git(api, "checkout", "-q", "-f", "-b", "feature", "HEAD~1")
+	write(filepath.Join(api, "list.go"), "package api\n\n// draft\n")
+	write(filepath.Join(api, "new.go"), "package api\n")
+	git(api, "add", "new.go")
+	write(filepath.Join(api, "notes.txt"), "todo\n")
+
+	web := newScriptRepo(t, scriptCommit("Ann <ann@work.example>", at(17), "docs: document the api", scriptFile("api.md", "# api\n")))
+	git(web, "config", "user.email", "ann@work.example")
+	git(web, "checkout", "-q", "-f", "main")
+	write(filepath.Join(web, ".git", "MERGE_HEAD"), "0000000000000000000000000000000000000000\n")
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	opts := standupOptions{Repos: []string{api, web}}
+	text, err := runStandup(context.Background(), opts, nil, commentOptions{}, nil, now)
+	if err != nil {
+		t.Fatalf("runStandup() error = %v", err)
+	}
+	apiLabel, webLabel := "["+filepath.Base(api)+"] ", "["+filepath.Base(web)+"] "
+	for _, want := range []string{
+		"## Yesterday\n- " + apiLabel + "feat: add search (",
+		"- " + apiLabel + "fix: handle empty query (",
+		"- " + webLabel + "docs: document the api (",
+		"## Today\n- " + apiLabel + "Continue on feature: new.go, list.go, notes.txt (1 staged, 1 modified, 1 untracked)\n",
+		"## Blockers\n- " + webLabel + "merge in progress on main",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("standup does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"last week", "someone else"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("standup contains %q:\n%s", unwanted, text)
+		}
+	}
+
+	t.Setenv("VOLC_ACCESSKEY", "ak")
+	t.Setenv("VOLC_SECRETKEY", "sk")
+	t.Setenv("DOUBAO_ENDPOINT", "ep")
+	var question string
+	ask := func(ctx context.Context, endpoint, prompt, q string) (string, error) {
+		question = q
+		return "## Yesterday\n- search", nil
+	}
+	opts.LLM = true
+	if text, err = runStandup(context.Background(), opts, nil, commentOptions{}, ask, now); err != nil {
+		t.Fatalf("runStandup(llm) error = %v", err)
+	}
+	if text != "## Yesterday\n- search" {
+		t.Errorf("runStandup(llm) = %q, want the model answer", text)
+	}
+	for _, want := range []string{"Since: 2026-10-16 00:00:00 +0000\n", ", branch feature\n", "- staged: new.go\n- modified: list.go\n- untracked: notes.txt\n", "- merge in progress"} {
+		if !strings.Contains(question, want) {
+			t.Errorf("question does not contain %q:\n%s", want, question)
+		}
+	}
+	if strings.Contains(question, "draft") || strings.Contains(question, "todo") {
+		t.Errorf("question leaks uncommitted content:\n%s", question)
+	}
+
+	if _, err = runStandup(context.Background(), standupOptions{Repos: []string{api}, DryRun: true}, nil, commentOptions{}, nil, now); err == nil {
+		t.Error("runStandup(dry run without llm) error = nil, want error")
+	}
+}
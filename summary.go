@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/khicago/got/util/typer"
+	"github.com/khicago/irr"
+)
+
+// summaryTopGroups 是问题和离线草稿中列出的目录和语言数量
+const summaryTopGroups = 5
+
+// summaryOptions 汇总 summary 子命令的输入
+type summaryOptions struct {
+	// Filter 选择要总结的提交, Committers 必填
+	Filter     insightOptions
+	Prompt     string
+	ConfigPath string
+	// DryRun 只打印将要发送的内容, 不联系模型
+	DryRun bool
+	// Offline 不调用模型, 直接按提交类型生成草稿
+	Offline bool
+	NoCache bool
+	Retry   retryPolicy
+	Output  string
+}
+
+// summary 请模型为一位作者在一段时间内的提交写工作总结
+func summary(ctx context.Context, opts summaryOptions) error {
+	conf, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+	opts.Filter.Identities = conf.Identities
+	ask := commentOptions{Providers: conf.Providers, Offline: opts.Offline, Retry: opts.Retry}
+	if !opts.NoCache {
+		if ask.Cache, err = newResponseCache(conf.Cache); err != nil {
+			return err
+		}
+	}
+	if ask.Audit, err = newAuditLog(conf.Audit); err != nil {
+		return err
+	}
+	if ask.Usage, err = newUsageLedger(conf.Usage); err != nil {
+		return err
+	}
+
+	text, err := runSummary(ctx, "", opts, ask, SimpleQuestion)
+	if err != nil {
+		return err
+	}
+	return writeInsightOutput(opts.Output, []byte(text+"\n"))
+}
+
+// runSummary 在 dir 中收集提交并生成总结; ask 提供 fallback 链、缓存、审计和预算, doubaoAsk 是 doubao 后端实际发起请求的函数
+func runSummary(ctx context.Context, dir string, opts summaryOptions, ask commentOptions, doubaoAsk askQuestionFunc) (string, error) {
+	// disable logrus to hide bot debug
+	logrus.SetOutput(io.Discard)
+
+	commits, err := getUserCommits(dir, opts.Filter)
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", irr.Error("no commits by %s match the filters", strings.Join(opts.Filter.Committers, ", "))
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].AuthorDate.Before(commits[j].AuthorDate) })
+
+	req := modelRequest{
+		Prompt:   typer.Or(opts.Prompt, summaryPrompt),
+		Question: summaryQuestion(commits, opts.Filter),
+		Offline:  func() string { return offlineSummary(commits) },
+		Noun:     "summary",
+	}
+	if opts.DryRun {
+		return describeQuestionDryRun(ask, req), nil
+	}
+	if opts.Offline {
+		return req.Offline(), nil
+	}
+	return askWithQuestion(ctx, ask, doubaoAsk, req)
+}
+
+// summaryPrompt 是未指定 --prompt 时使用的提示词
+const summaryPrompt = `You write a developer's work summary for a weekly report from their git history. The question lists the author's commits in the period, oldest first, with subjects, body excerpts and diffstats; it contains no code.
+
+Write concise Markdown with exactly these sections:
+## Themes
+2-4 bullets on what the work was about overall.
+## Shipped features
+User-visible additions, one bullet each.
+## Fixes
+Bugs fixed, one bullet each.
+## Risky changes
+Changes reviewers or operators should watch, such as breaking changes, large or wide changes and dependency updates, with the reason. Write "None" when there are none.
+
+Group related commits into one bullet and cite them by short hash. Write "None" for an empty section. Do not invent work the commits do not show. Output only the summary.`
+
+// summaryQuestion 列出作者、时间范围、总量、主要目录和语言, 以及每个提交的信息和 diffstat
+//
+// 提交按时间从旧到新排列, 超出 maxDiffLength 的提交只给出数量
+func summaryQuestion(commits []commitRecord, filter insightOptions) string {
+	var sb strings.Builder
+	sb.WriteString("Author: " + strings.Join(commitAuthors(commits), ", ") + "\n")
+	sb.WriteString("Period: " + summaryPeriod(commits) + "\n")
+	if filters := filter.describeFilters(); len(filters) > 0 {
+		sb.WriteString("Filtered by " + strings.Join(filters, "; ") + "\n")
+	}
+	sb.WriteString(summaryTotals(commits) + "\n")
+	dirs, langs := summaryGroups(commits)
+	sb.WriteString("Top directories: " + formatCountEntries(dirs) + "\n")
+	sb.WriteString("Languages: " + formatCountEntries(langs) + "\n")
+	sb.WriteString("\nCommits, oldest first:\n")
+
+	for i, c := range commits {
+		entry := commitEntry(c)
+		if sb.Len()+len(entry) > maxDiffLength {
+			sb.WriteString(fmt.Sprintf("- ... %d more commits omitted\n", len(commits)-i))
+			break
+		}
+		sb.WriteString(entry)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// summaryPeriod 返回第一个和最后一个提交的作者日期, commits 须按时间排好序
+func summaryPeriod(commits []commitRecord) string {
+	first, last := commits[0].AuthorDate.Format("2006-01-02"), commits[len(commits)-1].AuthorDate.Format("2006-01-02")
+	if first == last {
+		return first
+	}
+	return first + " to " + last
+}
+
+// summaryTotals 返回提交数、增删行数和不同文件数
+func summaryTotals(commits []commitRecord) string {
+	added, removed := 0, 0
+	files := make(map[string]bool)
+	for _, c := range commits {
+		added += c.Added()
+		removed += c.Removed()
+		for _, f := range c.Files {
+			files[f.Path] = true
+		}
+	}
+	return fmt.Sprintf("%d commits, +%d -%d lines in %d files", len(commits), added, removed, len(files))
+}
+
+// summaryGroups 按修改过的提交数统计前几个顶层目录和语言
+func summaryGroups(commits []commitRecord) (dirs, langs []countEntry) {
+	dirCounts, langCounts := make(map[string]int), make(map[string]int)
+	for _, c := range commits {
+		seenDirs, seenLangs := make(map[string]bool), make(map[string]bool)
+		for _, f := range c.Files {
+			seenDirs[getDirectory(f.Path)] = true
+			seenLangs[languageOf(f.Path)] = true
+		}
+		for d := range seenDirs {
+			dirCounts[d]++
+		}
+		for l := range seenLangs {
+			langCounts[l]++
+		}
+	}
+	return countEntries(dirCounts, len(commits), summaryTopGroups), countEntries(langCounts, len(commits), summaryTopGroups)
+}
+
+// formatCountEntries 格式化为 "name (n), name (n)", 没有时为 none
+func formatCountEntries(entries []countEntry) string {
+	if len(entries) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(entries))
+	for _, e := range entries {
+		parts = append(parts, fmt.Sprintf("%s (%d)", e.Name, e.Commits))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// offlineSummary 不调用模型, 按 Conventional Commits 类型把提交分到与 summaryPrompt 相同的几节, commits 须按时间排好序
+func offlineSummary(commits []commitRecord) string {
+	var features, fixes, risky []string
+	others := make(map[string]int)
+	scopes := make(map[string]int)
+	for _, c := range commits {
+		line := fmt.Sprintf("- %s (%s)", c.Subject, c.ShortHash())
+		cc, ok := parseConventionalSubject(c.Subject)
+		switch {
+		case ok && cc.Type == "feat":
+			features = append(features, line)
+		case ok && cc.Type == "fix":
+			fixes = append(fixes, line)
+		case ok:
+			others[cc.Type]++
+		default:
+			others["other"]++
+		}
+		if cc.Scope != "" {
+			scopes[cc.Scope]++
+		}
+		if risks := commitRisks(c); len(risks) > 0 {
+			risky = append(risky, fmt.Sprintf("- %s (%s): %s", c.Subject, c.ShortHash(), strings.Join(risks, ", ")))
+		}
+	}
+
+	dirs, _ := summaryGroups(commits)
+	themes := []string{"- " + summaryTotals(commits) + ", " + summaryPeriod(commits)}
+	themes = append(themes, "- Mostly in "+formatCountEntries(dirs))
+	if len(scopes) > 0 {
+		themes = append(themes, "- Scopes: "+formatCountEntries(countEntries(scopes, len(commits), summaryTopGroups)))
+	}
+	if len(others) > 0 {
+		themes = append(themes, "- Other commits: "+formatCountEntries(countEntries(others, len(commits), 0)))
+	}
+
+	var sb strings.Builder
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"Themes", themes},
+		{"Shipped features", features},
+		{"Fixes", fixes},
+		{"Risky changes", risky},
+	} {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("## " + section.title + "\n")
+		if len(section.lines) == 0 {
+			sb.WriteString("None\n")
+			continue
+		}
+		sb.WriteString(strings.Join(section.lines, "\n") + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSummaryRepo(t *testing.T) string {
+	t.Helper()
+	ann, bob := "Ann <ann@example.com>", "Bob <bob@example.com>"
+	at := func(n int) time.Time { return time.Date(2026, 10, 1+n, 9, 0, 0, 0, time.UTC) }
+	return newScriptRepo(t, scriptCommit(ann, at(0), "feat(api): add pagination\n\nList endpoints return a cursor.\n\nSigned-off-by: Ann <ann@example.com>", scriptFile("api/list.go", "package api\n\nconst pageSecret = 42\n"))+
+		scriptCommit(bob, at(1), "docs: describe the release", scriptFile("README.md", "# release\n"))+
+		scriptCommit(ann, at(2), "fix(api): keep the cursor stable", scriptFile("api/list.go", "package api\n\nconst pageSecret = 43\n"))+
+		scriptCommit(ann, at(3), "chore!: drop the v1 client", scriptFile("go.mod", "module example.com/app\n")))
+}
+
+func TestRunSummarySendsCommitMetadataWithoutCode(t *testing.T) {
+	t.Setenv("VOLC_ACCESSKEY", "ak")
+	t.Setenv("VOLC_SECRETKEY", "sk")
+	t.Setenv("DOUBAO_ENDPOINT", "ep")
+	dir := newSummaryRepo(t)
+
+	var questions []string
+	ask := func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		questions = append(questions, question)
+		return "## Themes\n- pagination", nil
+	}
+	text, err := runSummary(context.Background(), dir, summaryOptions{Filter: insightOptions{Committers: []string{"Ann"}, Since: "2026-09-01"}}, commentOptions{}, ask)
+	if err != nil {
+		t.Fatalf("runSummary() error = %v", err)
+	}
+	if text != "## Themes\n- pagination" || len(questions) != 1 {
+		t.Fatalf("runSummary() = %q after %d calls, want the model answer after one call", text, len(questions))
+	}
+
+	question := questions[0]
+	for _, want := range []string{
+		"Author: Ann\n",
+		"Period: 2026-10-01 to 2026-10-04\n",
+		"Filtered by since 2026-09-01\n",
+		"3 commits, +5 -1 lines in 2 files\n",
+		"Top directories: api (2), root (1)\n",
+		"add pagination [+3 -0, 1 files]\n  List endpoints return a cursor.\n  files: api/list.go (+3 -0)\n",
+		"chore!: drop the v1 client [+1 -0, 1 files] risk: breaking change, dependencies\n",
+	} {
+		if !strings.Contains(question, want) {
+			t.Errorf("question does not contain %q:\n%s", want, question)
+		}
+	}
+	if strings.Index(question, "add pagination") > strings.Index(question, "keep the cursor stable") {
+		t.Errorf("commits are not listed oldest first:\n%s", question)
+	}
+	for _, leaked := range []string{"pageSecret", "ann@example.com", "Signed-off-by", "describe the release"} {
+		if strings.Contains(question, leaked) {
+			t.Errorf("question leaks %q:\n%s", leaked, question)
+		}
+	}
+}
+
+func TestRunSummaryFallsBackToOfflineDraft(t *testing.T) {
+	t.Setenv("VOLC_ACCESSKEY", "")
+	t.Setenv("VOLC_SECRETKEY", "")
+	t.Setenv("DOUBAO_ENDPOINT", "")
+	dir := newSummaryRepo(t)
+	ask := func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		t.Fatal("ask called without credentials")
+		return "", nil
+	}
+
+	opts := summaryOptions{Filter: insightOptions{Committers: []string{"Ann"}}}
+	text, err := runSummary(context.Background(), dir, opts, commentOptions{}, ask)
+	if err != nil {
+		t.Fatalf("runSummary() error = %v", err)
+	}
+	for _, want := range []string{
+		"## Themes\n- 3 commits, +5 -1 lines in 2 files, 2026-10-01 to 2026-10-04\n- Mostly in api (2), root (1)\n- Scopes: api (2)\n- Other commits: chore (1)\n",
+		"## Shipped features\n- feat(api): add pagination (",
+		"## Fixes\n- fix(api): keep the cursor stable (",
+		"## Risky changes\n- chore!: drop the v1 client (",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("offline summary does not contain %q:\n%s", want, text)
+		}
+	}
+
+	opts.DryRun = true
+	if text, err = runSummary(context.Background(), dir, opts, commentOptions{}, ask); err != nil {
+		t.Fatalf("runSummary(dry run) error = %v", err)
+	}
+	if !strings.HasPrefix(text, "# Dry run: nothing was sent\n") || !strings.Contains(text, "## Question\n\nAuthor: Ann\n") {
+		t.Errorf("dry run =\n%s", text)
+	}
+
+	if _, err = runSummary(context.Background(), dir, summaryOptions{Filter: insightOptions{Committers: []string{"Carol"}}}, commentOptions{}, ask); err == nil {
+		t.Error("runSummary(unknown author) error = nil, want no commits")
+	}
+}
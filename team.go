@@ -0,0 +1,259 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/khicago/irr"
+	"gopkg.in/yaml.v3"
+)
+
+// teamMember 是团队文件中的一位成员
+type teamMember struct {
+	Name string `yaml:"name"`
+	// Identities 的写法与 --committer 相同, 为空时使用 Name
+	Identities []string `yaml:"identities,omitempty"`
+}
+
+// teamFile 是 --team 指定的团队文件
+type teamFile struct {
+	Members []teamMember `yaml:"members"`
+}
+
+// teamRow 是团队报告中一位作者的统计
+type teamRow struct {
+	Name string `json:"name"`
+	// Email 是 --all 时区分作者的小写邮箱 (经过 .mailmap 映射), --team 时为空
+	Email      string `json:"email,omitempty"`
+	Commits    int    `json:"commits"`
+	Added      int    `json:"added"`
+	Removed    int    `json:"removed"`
+	Files      int    `json:"files"`
+	ActiveDays int    `json:"active_days"`
+	Main       int    `json:"main_commits"`
+	NonMain    int    `json:"non_main_commits"`
+	AfterHours int    `json:"after_hours_commits"`
+	Weekend    int    `json:"weekend_commits"`
+}
+
+// teamSortColumns 是 --sort 可用的列; 除 name 外都按降序排列
+var teamSortColumns = map[string]func(teamRow) int{
+	"commits":     func(r teamRow) int { return r.Commits },
+	"added":       func(r teamRow) int { return r.Added },
+	"removed":     func(r teamRow) int { return r.Removed },
+	"files":       func(r teamRow) int { return r.Files },
+	"days":        func(r teamRow) int { return r.ActiveDays },
+	"main":        func(r teamRow) int { return r.Main },
+	"non-main":    func(r teamRow) int { return r.NonMain },
+	"after-hours": func(r teamRow) int { return r.AfterHours },
+	"weekend":     func(r teamRow) int { return r.Weekend },
+}
+
+// loadTeam 读取团队文件
+func loadTeam(path string) ([]teamMember, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, irr.Wrap(err, "failed to read team file %s", path)
+	}
+	var team teamFile
+	if err = yaml.Unmarshal(data, &team); err != nil {
+		return nil, irr.Wrap(err, "failed to parse team file %s", path)
+	}
+	if len(team.Members) == 0 {
+		return nil, irr.Error("team file %s has no members", path)
+	}
+	for i, m := range team.Members {
+		if strings.TrimSpace(m.Name) == "" {
+			return nil, irr.Error("team file %s: member %d has no name", path, i+1)
+		}
+	}
+	return team.Members, nil
+}
+
+// teamAccumulator 汇总一位作者的提交, 去重统计文件和活跃天数
+type teamAccumulator struct {
+	row   teamRow
+	clock activityClock
+	files map[string]bool
+	days  map[string]bool
+}
+
+func newTeamAccumulator(name string, clock activityClock) *teamAccumulator {
+	return &teamAccumulator{row: teamRow{Name: name}, clock: clock, files: map[string]bool{}, days: map[string]bool{}}
+}
+
+func (a *teamAccumulator) add(c commitRecord, onMain bool) {
+	a.row.Commits++
+	a.row.Added += c.Added()
+	a.row.Removed += c.Removed()
+	if onMain {
+		a.row.Main++
+	} else {
+		a.row.NonMain++
+	}
+	if a.clock.weekend(c.AuthorDate) {
+		a.row.Weekend++
+	} else if a.clock.afterHours(c.AuthorDate) {
+		a.row.AfterHours++
+	}
+	for _, f := range c.Files {
+		a.files[f.Path] = true
+	}
+	a.days[c.AuthorDate.Format("2006-01-02")] = true
+}
+
+func (a *teamAccumulator) result() teamRow {
+	a.row.Files, a.row.ActiveDays = len(a.files), len(a.days)
+	return a.row
+}
+
+// buildTeamRows 将提交归属到作者, 返回每位作者一行以及团队合计
+//
+// members 为空时按 .mailmap 映射后的邮箱分组, 与 ownership 一样用 contactKey 区分作者, 行中显示 git log 中最先遇到, 即最近一次提交的名字;
+// 共同作者同样计入, 合计中每个提交只计一次
+func buildTeamRows(commits []commitRecord, members []teamMember, matchers []authorMatcher, mainSet map[string]struct{}, clock activityClock) ([]teamRow, teamRow) {
+	accs := map[string]*teamAccumulator{}
+	var order []string
+	accFor := func(key, name, email string) *teamAccumulator {
+		if accs[key] == nil {
+			accs[key] = newTeamAccumulator(name, clock)
+			accs[key].row.Email = strings.ToLower(email)
+			order = append(order, key)
+		}
+		return accs[key]
+	}
+	for _, m := range members {
+		accFor(m.Name, m.Name, "")
+	}
+
+	total := newTeamAccumulator("Total", clock)
+	for _, c := range commits {
+		_, onMain := mainSet[c.Hash]
+		var people []*teamAccumulator
+		if len(members) > 0 {
+			for i, m := range members {
+				if matchers[i].matchCommit(c) {
+					people = append(people, accFor(m.Name, m.Name, ""))
+				}
+			}
+		} else {
+			author := contactKey(c.AuthorName, c.AuthorEmail)
+			people = append(people, accFor(author, c.AuthorName, c.AuthorEmail))
+			seen := map[string]bool{author: true}
+			for _, co := range c.CoAuthors {
+				name, email := parseContact(co)
+				if key := contactKey(name, email); key != "" && !seen[key] {
+					seen[key] = true
+					people = append(people, accFor(key, name, email))
+				}
+			}
+		}
+		if len(people) == 0 {
+			continue
+		}
+		for _, acc := range people {
+			acc.add(c, onMain)
+		}
+		total.add(c, onMain)
+	}
+
+	rows := make([]teamRow, 0, len(order))
+	for _, key := range order {
+		rows = append(rows, accs[key].result())
+	}
+	return rows, total.result()
+}
+
+// sortTeamRows 按列排序, name 升序, 其余列降序, 相同时按名字
+func sortTeamRows(rows []teamRow, column string) error {
+	if column == "" {
+		column = "commits"
+	}
+	if column == "name" {
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i].Name != rows[j].Name {
+				return rows[i].Name < rows[j].Name
+			}
+			return rows[i].Email < rows[j].Email
+		})
+		return nil
+	}
+	key, ok := teamSortColumns[column]
+	if !ok {
+		columns := []string{"name"}
+		for c := range teamSortColumns {
+			columns = append(columns, c)
+		}
+		sort.Strings(columns)
+		return irr.Error("unknown sort column %q, want one of %s", column, strings.Join(columns, ", "))
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if key(rows[i]) != key(rows[j]) {
+			return key(rows[i]) > key(rows[j])
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return nil
+}
+
+// collectTeamInsight 统计团队报告: --all 时包含全部作者, --team 时只包含团队文件中的成员
+func collectTeamInsight(dir string, opts insightOptions) (teamReport, error) {
+	clock, err := newActivityClock(opts.TimeZone, opts.WorkHours)
+	if err != nil {
+		return teamReport{}, err
+	}
+
+	var (
+		members  []teamMember
+		matchers []authorMatcher
+		keep     func(commitRecord) bool
+	)
+	if opts.TeamPath != "" {
+		if members, err = loadTeam(opts.TeamPath); err != nil {
+			return teamReport{}, err
+		}
+		for _, m := range members {
+			identities := m.Identities
+			if len(identities) == 0 {
+				identities = []string{m.Name}
+			}
+			matcher, err := newAuthorMatcher(identities, opts.Identities)
+			if err != nil {
+				return teamReport{}, irr.Wrap(err, "team member %s", m.Name)
+			}
+			matchers = append(matchers, matcher)
+		}
+		keep = func(c commitRecord) bool {
+			for _, m := range matchers {
+				if m.matchCommit(c) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	commits, err := loadInsightCommits(dir, opts, keep)
+	if err != nil {
+		return teamReport{}, err
+	}
+
+	rows, total := buildTeamRows(commits, members, matchers, getMainBranchSet(dir), clock)
+	if err = sortTeamRows(rows, opts.Sort); err != nil {
+		return teamReport{}, err
+	}
+
+	report := teamReport{
+		SchemaVersion: insightSchemaVersion,
+		Filters:       opts.describeFilters(),
+		Authors:       rows,
+		Total:         total,
+		TimeZone:      clock.zone(),
+		WorkHours:     clock.workHours(),
+	}
+	if report.Filters == nil {
+		report.Filters = make([]string, 0)
+	}
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildTeamRowsCountsCoAuthorsOnceInTotal(t *testing.T) {
+	day := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	commits := []commitRecord{
+		{Hash: "a1", AuthorName: "Ann", AuthorEmail: "ann@example.com", AuthorDate: day, Files: []fileStat{{Path: "x.go", Added: 5, Removed: 1}}},
+		{Hash: "a2", AuthorName: "Ann", AuthorEmail: "ann@example.com", AuthorDate: day.Add(time.Hour), Files: []fileStat{{Path: "x.go", Added: 1}}},
+		{Hash: "b1", AuthorName: "Bob", AuthorEmail: "bob@example.com", AuthorDate: day.AddDate(0, 0, 1), CoAuthors: []string{"Ann Lee <Ann@example.com>"}, Files: []fileStat{{Path: "y.go", Added: 2, Removed: 2}}},
+	}
+	mainSet := map[string]struct{}{"a1": {}, "b1": {}}
+
+	clock, err := newActivityClock("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, total := buildTeamRows(commits, nil, nil, mainSet, clock)
+	if err := sortTeamRows(rows, "commits"); err != nil {
+		t.Fatalf("sortTeamRows() error = %v", err)
+	}
+	wantAnn := teamRow{Name: "Ann", Email: "ann@example.com", Commits: 3, Added: 8, Removed: 3, Files: 2, ActiveDays: 2, Main: 2, NonMain: 1}
+	wantBob := teamRow{Name: "Bob", Email: "bob@example.com", Commits: 1, Added: 2, Removed: 2, Files: 1, ActiveDays: 1, Main: 1}
+	if len(rows) != 2 || rows[0] != wantAnn || rows[1] != wantBob {
+		t.Fatalf("rows = %+v, want %+v and %+v", rows, wantAnn, wantBob)
+	}
+	wantTotal := teamRow{Name: "Total", Commits: 3, Added: 8, Removed: 3, Files: 2, ActiveDays: 2, Main: 2, NonMain: 1}
+	if total != wantTotal {
+		t.Fatalf("total = %+v, want %+v", total, wantTotal)
+	}
+
+	if err := sortTeamRows(rows, "name"); err != nil || rows[0].Name != "Ann" {
+		t.Fatalf("sortTeamRows(name) = %+v, %v, want Ann first", rows, err)
+	}
+	if err := sortTeamRows(rows, "lines"); err == nil {
+		t.Fatal("sortTeamRows(unknown) error = nil, want error")
+	}
+}
+
+func TestBuildTeamRowsKeysAuthorsByEmail(t *testing.T) {
+	day := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	commits := []commitRecord{
+		{Hash: "a1", AuthorName: "Sam", AuthorEmail: "sam@a.example", AuthorDate: day},
+		{Hash: "b1", AuthorName: "Sam", AuthorEmail: "sam@b.example", AuthorDate: day},
+		{Hash: "b2", AuthorName: "Samuel", AuthorEmail: "SAM@b.example", AuthorDate: day},
+	}
+	clock, err := newActivityClock("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := buildTeamRows(commits, nil, nil, nil, clock)
+	if err = sortTeamRows(rows, "name"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Email != "sam@a.example" || rows[0].Commits != 1 || rows[1].Email != "sam@b.example" || rows[1].Commits != 2 {
+		t.Fatalf("rows = %+v, want two people named Sam kept apart and sam@b.example counted once across names", rows)
+	}
+}
+
+func TestBuildTeamReportForAllAuthorsAndTeamFile(t *testing.T) {
+	dir := newFixtureRepo(t, 40)
+
+	report, err := collectTeamInsight(dir, insightOptions{All: true})
+	if err != nil {
+		t.Fatalf("collectTeamInsight(all) error = %v", err)
+	}
+	if len(report.Authors) != 2 || report.Authors[0].Commits != 20 || report.Authors[1].Commits != 20 || report.Total.Commits != 40 {
+		t.Errorf("team report = %+v, want Alice and Bob with 20 commits each and 40 in total", report)
+	}
+
+	teamPath := filepath.Join(t.TempDir(), "team.yaml")
+	team := "members:\n  - name: Bobby\n    identities: [bob@example.com]\n  - name: Nobody\n"
+	if err = os.WriteFile(teamPath, []byte(team), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	report, err = collectTeamInsight(dir, insightOptions{TeamPath: teamPath, Sort: "name"})
+	if err != nil {
+		t.Fatalf("collectTeamInsight(team) error = %v", err)
+	}
+	if len(report.Authors) != 2 || report.Authors[0].Name != "Bobby" || report.Authors[0].Commits != 20 || report.Authors[1].Name != "Nobody" {
+		t.Errorf("team report authors = %+v, want only team members, including inactive ones", report.Authors)
+	}
+	if report.Total.Commits != 20 {
+		t.Errorf("team total = %+v, want only team members' commits", report.Total)
+	}
+}
+
+func TestInsightRejectsConflictingModes(t *testing.T) {
+	if err := insight(insightOptions{All: true, TeamPath: "team.yaml"}); err == nil {
+		t.Error("insight(--all --team) error = nil, want error")
+	}
+	if err := insight(insightOptions{All: true, Committers: []string{"Ann"}}); err == nil {
+		t.Error("insight(--all --committer) error = nil, want error")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khicago/irr"
+)
+
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// periodSummary 是时间线上的一个时间段, 没有提交的时间段也会出现, Commits 为空
+type periodSummary struct {
+	// Period 是 2026-01-05、2026-W02 (ISO 8601 周) 或 2026-01
+	Period string `json:"period"`
+	// Start 是时间段第一天的日期
+	Start   string          `json:"start"`
+	Added   int             `json:"added"`
+	Removed int             `json:"removed"`
+	Commits []commitSummary `json:"commits"`
+}
+
+// checkBucket 校验时间段粒度, 为空时使用 week
+func checkBucket(bucket string) (string, error) {
+	switch bucket {
+	case "":
+		return BucketWeek, nil
+	case BucketDay, BucketWeek, BucketMonth:
+		return bucket, nil
+	}
+	return "", irr.Error("unknown bucket %q, want day, week or month", bucket)
+}
+
+// bucketStart 返回 t 所在时间段的第一天, 按 t 自身时区的日期计算
+func bucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	case BucketWeek:
+		// ISO 8601 的一周从周一开始
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+// nextBucket 返回下一个时间段的第一天
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// bucketLabel 返回时间段的名字; 周使用 ISO 年份, 所以 2024-12-30 属于 2025-W01
+func bucketLabel(start time.Time, bucket string) string {
+	switch bucket {
+	case BucketMonth:
+		return start.Format("2006-01")
+	case BucketWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return start.Format("2006-01-02")
+}
+
+// buildTimeline 按作者日期把提交分到时间段, 与热力图和活跃度一致, 按时间顺序返回从第一个到最后一个时间段, 中间的空档补零
+func buildTimeline(commits []commitRecord, bucket string) []periodSummary {
+	timeline := make([]periodSummary, 0)
+	if len(commits) == 0 {
+		return timeline
+	}
+
+	byStart := make(map[time.Time][]commitRecord)
+	var first, last time.Time
+	for i, commit := range commits {
+		start := bucketStart(commit.AuthorDate, bucket)
+		byStart[start] = append(byStart[start], commit)
+		if i == 0 || start.Before(first) {
+			first = start
+		}
+		if i == 0 || start.After(last) {
+			last = start
+		}
+	}
+
+	for start := first; !start.After(last); start = nextBucket(start, bucket) {
+		group := byStart[start]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].AuthorDate.Before(group[j].AuthorDate) })
+		period := periodSummary{
+			Period:  bucketLabel(start, bucket),
+			Start:   start.Format("2006-01-02"),
+			Commits: make([]commitSummary, 0, len(group)),
+		}
+		for _, commit := range group {
+			period.Added += commit.Added()
+			period.Removed += commit.Removed()
+			period.Commits = append(period.Commits, summarizeCommit(commit))
+		}
+		timeline = append(timeline, period)
+	}
+	return timeline
+}
+
+// timelineBarWidth 是文本输出中柱状图的最大宽度
+const timelineBarWidth = 30
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline 用一行字符画出 values 的趋势, 0 使用空格
+func sparkline(values []int) string {
+	maxValue := 0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		level := (v*len(sparkLevels) - 1) / maxValue
+		sb.WriteRune(sparkLevels[level])
+	}
+	return sb.String()
+}
+
+// asciiBar 返回长度与 value / maxValue 成正比的 # 柱, 非零值至少一格
+func asciiBar(value, maxValue, width int) string {
+	if value <= 0 || maxValue <= 0 {
+		return ""
+	}
+	return strings.Repeat("#", max(1, value*width/maxValue))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBucketLabelUsesISOWeeksAcrossYearBoundaries(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2024-12-29", "2024-W52"}, // 周日仍属于上一年的最后一周
+		{"2024-12-30", "2025-W01"}, // 周一开始的一周大部分在 2025 年
+		{"2025-01-01", "2025-W01"},
+		{"2020-12-31", "2020-W53"}, // 有 53 周的年份
+		{"2021-01-03", "2020-W53"},
+		{"2021-01-04", "2021-W01"},
+		{"2027-01-01", "2026-W53"},
+	}
+	for _, tt := range tests {
+		day, err := time.Parse("2006-01-02", tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bucketLabel(bucketStart(day, BucketWeek), BucketWeek); got != tt.want {
+			t.Errorf("week of %s = %s, want %s", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestBucketStartUsesTheCommitTimeZone(t *testing.T) {
+	// UTC 周日 20:00 在 +08:00 已经是周一
+	commit := time.Date(2026, 1, 4, 20, 0, 0, 0, time.UTC).In(time.FixedZone("+0800", 8*3600))
+	if got := bucketLabel(bucketStart(commit, BucketWeek), BucketWeek); got != "2026-W02" {
+		t.Errorf("week = %s, want 2026-W02 in the author's zone", got)
+	}
+}
+
+func TestBuildTimelineSortsAndZeroFills(t *testing.T) {
+	rebased := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	at := func(date string, added int) commitRecord {
+		d, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 提交日期都是同一次 rebase 的时间, 时间段应当按作者日期划分
+		return commitRecord{Hash: date + "0000000", AuthorDate: d, CommitDate: rebased, Subject: date, Files: []fileStat{{Path: "a.go", Added: added}}}
+	}
+	commits := []commitRecord{at("2026-01-20", 1), at("2025-12-30", 2), at("2026-01-01", 3), at("2025-12-31", 4)}
+
+	tests := []struct {
+		bucket string
+		want   []string
+		counts []int
+	}{
+		{BucketWeek, []string{"2026-W01", "2026-W02", "2026-W03", "2026-W04"}, []int{3, 0, 0, 1}},
+		{BucketMonth, []string{"2025-12", "2026-01"}, []int{2, 2}},
+		{BucketDay, nil, nil},
+	}
+	for _, tt := range tests {
+		timeline := buildTimeline(commits, tt.bucket)
+		if tt.bucket == BucketDay {
+			if len(timeline) != 22 || timeline[0].Period != "2025-12-30" || timeline[21].Period != "2026-01-20" {
+				t.Errorf("day timeline has %d periods from %s, want 22 from 2025-12-30", len(timeline), timeline[0].Period)
+			}
+			continue
+		}
+		if len(timeline) != len(tt.want) {
+			t.Fatalf("%s timeline = %+v, want periods %v", tt.bucket, timeline, tt.want)
+		}
+		for i, period := range timeline {
+			if period.Period != tt.want[i] || len(period.Commits) != tt.counts[i] || period.Commits == nil {
+				t.Errorf("%s period %d = %s with %d commits, want %s with %d", tt.bucket, i, period.Period, len(period.Commits), tt.want[i], tt.counts[i])
+			}
+		}
+	}
+
+	first := buildTimeline(commits, BucketWeek)[0]
+	if first.Start != "2025-12-29" || first.Added != 9 || first.Commits[0].Subject != "2025-12-30" || first.Commits[0].Date != "2025-12-30" || first.Commits[2].Subject != "2026-01-01" {
+		t.Errorf("first week = %+v, want commits in date order starting on Monday 2025-12-29", first)
+	}
+	if got := buildTimeline(nil, BucketWeek); got == nil || len(got) != 0 {
+		t.Errorf("empty timeline = %#v, want empty slice", got)
+	}
+}
+
+func TestCheckBucket(t *testing.T) {
+	if got, err := checkBucket(""); got != BucketWeek || err != nil {
+		t.Errorf("checkBucket(\"\") = %q, %v, want week", got, err)
+	}
+	if _, err := checkBucket("year"); err == nil {
+		t.Error("checkBucket(year) error = nil, want error")
+	}
+}
+
+func TestSparklineAndBars(t *testing.T) {
+	if got := sparkline([]int{0, 1, 4, 8}); got != " ▁▄█" {
+		t.Errorf("sparkline = %q, want \" ▁▄█\"", got)
+	}
+	if got := asciiBar(1, 100, 30); got != "#" {
+		t.Errorf("asciiBar(1, 100) = %q, want one cell for a non-zero value", got)
+	}
+	if got := asciiBar(0, 100, 30); got != "" {
+		t.Errorf("asciiBar(0, 100) = %q, want empty", got)
+	}
+
+	var sb strings.Builder
+	writeTimelineText(&sb, []periodSummary{
+		{Period: "2026-W01", Added: 3, Commits: []commitSummary{{Hash: "abc1234", Date: "2026-01-01", Subject: "one", Added: 3}}},
+		{Period: "2026-W02", Commits: []commitSummary{}},
+	})
+	out := sb.String()
+	for _, want := range []string{"2026-W01 .. 2026-W02  █ \n", "2026-W01 " + strings.Repeat("#", 30) + " 1 commits [add:3 rem:0]\n", "2026-W02 " + strings.Repeat(" ", 30) + " 0 commits\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("timeline text is missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bagaking/botheater/utils"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/khicago/irr"
+)
+
+const (
+	BudgetActionWarn  = "warn"
+	BudgetActionBlock = "block"
+)
+
+// usageConfig 是配置文件中 usage 部分
+type usageConfig struct {
+	// Path 默认为 $XDG_STATE_HOME/commitron/usage.jsonl
+	Path string `yaml:"path,omitempty"`
+	// Currency 仅用于展示, 默认为 USD
+	Currency string `yaml:"currency,omitempty"`
+	// Prices 以后端名称、模型或后端类型为键, 依次查找
+	Prices map[string]modelPrice `yaml:"prices,omitempty"`
+	// Budget 是可选的月度预算
+	Budget budgetConfig `yaml:"budget,omitempty"`
+}
+
+// modelPrice 是每 1000 个 token 的价格
+type modelPrice struct {
+	PromptPer1K     float64 `yaml:"prompt_per_1k"`
+	CompletionPer1K float64 `yaml:"completion_per_1k"`
+}
+
+// budgetConfig 是自然月内的花费上限
+type budgetConfig struct {
+	// Monthly 为 0 表示不限制
+	Monthly float64 `yaml:"monthly,omitempty"`
+	// Action 取值 warn (默认) 或 block; block 时超出预算不再调用模型
+	Action string `yaml:"action,omitempty"`
+}
+
+// budgetExceededError 表示预算动作为 block 时, 请求在发送前就被拦下
+type budgetExceededError struct {
+	Spent, Monthly float64
+	Currency       string
+}
+
+func (e *budgetExceededError) Error() string {
+	return fmt.Sprintf("monthly budget exceeded: spent %.4f of %.2f %s", e.Spent, e.Monthly, e.Currency)
+}
+
+// usageRecord 是用量账本中的一行, 对应一次成功的模型调用
+type usageRecord struct {
+	Time             time.Time `json:"time"`
+	Repo             string    `json:"repo,omitempty"`
+	Provider         string    `json:"provider"`
+	ProviderType     string    `json:"provider_type"`
+	Model            string    `json:"model,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	// Estimated 表示 token 数由 utils.CountTokens 估算, 而不是后端返回
+	Estimated bool    `json:"estimated,omitempty"`
+	Cost      float64 `json:"cost"`
+}
+
+// tokenUsage 由后端在响应中报告的 token 数
+type tokenUsage struct {
+	Prompt, Completion int
+}
+
+type tokenUsageKey struct{}
+
+// withTokenUsage 返回携带 tokenUsage 的 ctx, 后端可通过 reportTokenUsage 填写
+func withTokenUsage(ctx context.Context) (context.Context, *tokenUsage) {
+	u := &tokenUsage{}
+	return context.WithValue(ctx, tokenUsageKey{}, u), u
+}
+
+// reportTokenUsage 记录后端返回的 token 数, ctx 未携带 tokenUsage 时忽略
+func reportTokenUsage(ctx context.Context, prompt, completion int) {
+	if u, ok := ctx.Value(tokenUsageKey{}).(*tokenUsage); ok {
+		u.Prompt, u.Completion = prompt, completion
+	}
+}
+
+// usageLedger 以追加方式记录每次模型调用的 token 用量与花费
+type usageLedger struct {
+	path     string
+	currency string
+	prices   map[string]modelPrice
+	budget   budgetConfig
+	now      func() time.Time
+	repo     func() string
+	warned   bool
+}
+
+// newUsageLedger 依据配置创建用量账本
+func newUsageLedger(conf usageConfig) (*usageLedger, error) {
+	path := conf.Path
+	if path == "" {
+		dir, err := stateDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "usage.jsonl")
+	}
+	switch conf.Budget.Action {
+	case "", BudgetActionWarn, BudgetActionBlock:
+	default:
+		return nil, irr.Error("unknown budget action %q, want %s or %s", conf.Budget.Action, BudgetActionWarn, BudgetActionBlock)
+	}
+	return &usageLedger{
+		path:     path,
+		currency: conf.Currency,
+		prices:   conf.Prices,
+		budget:   conf.Budget,
+		now:      time.Now,
+		repo:     currentRepo,
+	}, nil
+}
+
+// currentRepo 返回当前仓库的根目录, 不在仓库中时返回空
+func currentRepo() string {
+	repo, _ := executeGitCommand("rev-parse", "--show-toplevel")
+	return strings.TrimSpace(repo)
+}
+
+// priced 判断是否配置了非零的价格
+func (m modelPrice) priced() bool {
+	return m.PromptPer1K > 0 || m.CompletionPer1K > 0
+}
+
+// price 依次按后端名称、模型和后端类型查找价格, 未配置时为 0
+func (l *usageLedger) price(p providerConfig) modelPrice {
+	for _, key := range []string{p.displayName(), p.Model, p.Type} {
+		if price, ok := l.prices[key]; ok && key != "" {
+			return price
+		}
+	}
+	return modelPrice{}
+}
+
+// wrap 在调用前检查月度预算, 并为每次成功的调用记录用量
+//
+// 预算动作为 block 且本月花费已达上限时, 配置了价格的后端不再发起请求, fallback 链会继续尝试下一个后端;
+// 没有价格的后端 (如本地的 ollama) 不花钱, 照常调用
+func (l *usageLedger) wrap(ask askQuestionFunc, p providerConfig) askQuestionFunc {
+	return func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		if l.budget.Monthly > 0 {
+			spent, err := l.monthSpend()
+			if err != nil {
+				return "", backoff.Permanent(err)
+			}
+			if spent >= l.budget.Monthly {
+				exceeded := &budgetExceededError{Spent: spent, Monthly: l.budget.Monthly, Currency: l.currencyName()}
+				if l.budget.Action == BudgetActionBlock && l.price(p).priced() {
+					return "", backoff.Permanent(exceeded)
+				}
+				if !l.warned {
+					fmt.Fprintf(os.Stderr, "commitron: %v\n", exceeded)
+					l.warned = true
+				}
+			}
+		}
+
+		ctx, reported := withTokenUsage(ctx)
+		answer, err := ask(ctx, endpoint, prompt, question)
+		if err != nil {
+			return "", err
+		}
+
+		record := usageRecord{
+			Time:             l.now().UTC(),
+			Repo:             l.repo(),
+			Provider:         p.displayName(),
+			ProviderType:     p.Type,
+			Model:            p.Model,
+			PromptTokens:     reported.Prompt,
+			CompletionTokens: reported.Completion,
+		}
+		if record.PromptTokens == 0 && record.CompletionTokens == 0 {
+			record.PromptTokens = utils.CountTokens(prompt) + utils.CountTokens(question)
+			record.CompletionTokens = utils.CountTokens(answer)
+			record.Estimated = true
+		}
+		price := l.price(p)
+		record.Cost = float64(record.PromptTokens)/1000*price.PromptPer1K + float64(record.CompletionTokens)/1000*price.CompletionPer1K
+
+		// 记账失败不影响已经生成的结果
+		if err = l.append(record); err != nil {
+			fmt.Fprintf(os.Stderr, "commitron: %v\n", err)
+		}
+		return answer, nil
+	}
+}
+
+func (l *usageLedger) currencyName() string {
+	if l.currency == "" {
+		return "USD"
+	}
+	return l.currency
+}
+
+func (l *usageLedger) append(record usageRecord) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return irr.Wrap(err, "failed to create usage ledger directory")
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return irr.Wrap(err, "failed to open usage ledger")
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return irr.Wrap(err, "failed to encode usage record")
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return irr.Wrap(err, "failed to write usage ledger")
+	}
+	return nil
+}
+
+// records 读取 since 之后的用量记录, 账本不存在时返回空
+func (l *usageLedger) records(since time.Time) ([]usageRecord, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, irr.Wrap(err, "failed to open usage ledger")
+	}
+	defer f.Close()
+
+	var records []usageRecord
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record usageRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, irr.Wrap(err, "failed to parse usage ledger %s line %d", l.path, n)
+		}
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, irr.Wrap(err, "failed to read usage ledger")
+	}
+	return records, nil
+}
+
+// monthSpend 返回本自然月 (本地时间) 的花费
+func (l *usageLedger) monthSpend() (float64, error) {
+	records, err := l.records(startOfMonth(l.now()))
+	if err != nil {
+		return 0, err
+	}
+	spent := 0.0
+	for _, r := range records {
+		spent += r.Cost
+	}
+	return spent, nil
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// parseSince 解析 --since, 支持 YYYY-MM-DD、天数 (如 30d) 和 Go duration (如 72h); 为空时为本月初
+func parseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return startOfMonth(now), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			y, m, d := now.Date()
+			return time.Date(y, m, d-n, 0, 0, 0, 0, now.Location()), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, irr.Error("invalid --since %q, want YYYY-MM-DD, a number of days such as 30d, or a duration such as 72h", s)
+}
+
+// usageTotal 是一组调用的合计
+type usageTotal struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Estimated        bool
+}
+
+func (t *usageTotal) add(r usageRecord) {
+	t.Calls++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.Cost += r.Cost
+	t.Estimated = t.Estimated || r.Estimated
+}
+
+// writeUsageReport 按天 (本地时间) 和仓库汇总用量
+func (l *usageLedger) writeUsageReport(w io.Writer, since time.Time) error {
+	records, err := l.records(since)
+	if err != nil {
+		return err
+	}
+
+	var total usageTotal
+	byDay, byRepo := map[string]*usageTotal{}, map[string]*usageTotal{}
+	for _, r := range records {
+		day := r.Time.In(l.now().Location()).Format("2006-01-02")
+		repo := r.Repo
+		if repo == "" {
+			repo = "(unknown)"
+		}
+		addUsage(byDay, day, r)
+		addUsage(byRepo, repo, r)
+		total.add(r)
+	}
+
+	fmt.Fprintf(w, "Usage since %s (%s)\n", since.Format("2006-01-02 15:04"), l.currencyName())
+	if len(records) == 0 {
+		fmt.Fprintln(w, "\nNo model calls recorded.")
+	} else {
+		writeUsageTable(w, "DAY", byDay)
+		writeUsageTable(w, "REPO", byRepo)
+		fmt.Fprintf(w, "\nTotal: %d call(s), %d prompt + %d completion tokens, %.4f %s\n",
+			total.Calls, total.PromptTokens, total.CompletionTokens, total.Cost, l.currencyName())
+		if total.Estimated {
+			fmt.Fprintln(w, "Some token counts are estimates; the provider did not report usage.")
+		}
+	}
+
+	if l.budget.Monthly > 0 {
+		spent, err := l.monthSpend()
+		if err != nil {
+			return err
+		}
+		action := l.budget.Action
+		if action == "" {
+			action = BudgetActionWarn
+		}
+		fmt.Fprintf(w, "Budget: %.4f of %.2f %s spent this month (%s)\n", spent, l.budget.Monthly, l.currencyName(), action)
+	}
+	return nil
+}
+
+func addUsage(groups map[string]*usageTotal, key string, r usageRecord) {
+	if groups[key] == nil {
+		groups[key] = &usageTotal{}
+	}
+	groups[key].add(r)
+}
+
+func writeUsageTable(w io.Writer, title string, groups map[string]*usageTotal) {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCALLS\tPROMPT\tCOMPLETION\tCOST\n", title)
+	for _, k := range keys {
+		t := groups[k]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.4f\n", k, t.Calls, t.PromptTokens, t.CompletionTokens, t.Cost)
+	}
+	_ = tw.Flush()
+}
+
+// showUsage 是 usage 子命令, 汇总 since 之后的用量与花费
+func showUsage(since, configPath string) error {
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	ledger, err := newUsageLedger(conf.Usage)
+	if err != nil {
+		return err
+	}
+	from, err := parseSince(since, ledger.now())
+	if err != nil {
+		return err
+	}
+	return ledger.writeUsageReport(os.Stdout, from)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestUsageLedger(t *testing.T, conf usageConfig, now time.Time) *usageLedger {
+	t.Helper()
+
+	conf.Path = filepath.Join(t.TempDir(), "usage.jsonl")
+	l, err := newUsageLedger(conf)
+	if err != nil {
+		t.Fatalf("newUsageLedger() error = %v", err)
+	}
+	l.now = func() time.Time { return now }
+	l.repo = func() string { return "/src/app" }
+	return l
+}
+
+func TestUsageLedgerRecordsReportedAndEstimatedTokens(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	ledger := newTestUsageLedger(t, usageConfig{Prices: map[string]modelPrice{
+		"local":            {PromptPer1K: 1, CompletionPer1K: 2},
+		ProviderTypeDoubao: {PromptPer1K: 0.5, CompletionPer1K: 0.5},
+	}}, now)
+
+	// 后端报告了用量
+	local := providerConfig{Name: "local", Type: ProviderTypeOllama, Model: "test-model"}
+	reported := ledger.wrap(func(ctx context.Context, endpoint, prompt, question string) (string, error) {
+		reportTokenUsage(ctx, 1000, 500)
+		return "fix: answer", nil
+	}, local)
+	if _, err := reported(context.Background(), "http://localhost:11434", "prompt", "question"); err != nil {
+		t.Fatalf("wrapped ask error = %v", err)
+	}
+
+	// 后端没有报告用量时估算, 失败的调用不记账
+	doubao := providerConfig{Type: ProviderTypeDoubao}
+	estimated := ledger.wrap(func(context.Context, string, string, string) (string, error) {
+		return "fix: answer", nil
+	}, doubao)
+	if _, err := estimated(context.Background(), "ep", "prompt", "question"); err != nil {
+		t.Fatalf("wrapped ask error = %v", err)
+	}
+	failing := ledger.wrap(func(context.Context, string, string, string) (string, error) {
+		return "", errors.New("503 service unavailable")
+	}, doubao)
+	if _, err := failing(context.Background(), "ep", "prompt", "question"); err == nil {
+		t.Fatal("wrapped ask error = nil, want provider error")
+	}
+
+	records, err := ledger.records(time.Time{})
+	if err != nil {
+		t.Fatalf("records() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ledger has %d records, want 2 successful calls", len(records))
+	}
+	if r := records[0]; r.PromptTokens != 1000 || r.CompletionTokens != 500 || r.Estimated || r.Cost != 2 || r.Repo != "/src/app" {
+		t.Errorf("reported record = %+v, want 1000/500 tokens costing 2", r)
+	}
+	if r := records[1]; !r.Estimated || r.PromptTokens == 0 || r.CompletionTokens == 0 || r.Cost <= 0 {
+		t.Errorf("estimated record = %+v, want estimated tokens with a cost", r)
+	}
+}
+
+func TestOllamaAskReportsTokenUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ollamaChatResponse{
+			Message:         ollamaMessage{Role: "assistant", Content: "fix: answer"},
+			PromptEvalCount: 120,
+			EvalCount:       8,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, usage := withTokenUsage(context.Background())
+	if _, err := ollamaAsk("test-model", server.Client())(ctx, server.URL, "prompt", "question"); err != nil {
+		t.Fatalf("ollamaAsk() error = %v", err)
+	}
+	if usage.Prompt != 120 || usage.Completion != 8 {
+		t.Fatalf("usage = %+v, want counts reported by ollama", usage)
+	}
+}
+
+func TestUsageLedgerBudgetDoesNotBlockFreeProviders(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	ledger := newTestUsageLedger(t, usageConfig{
+		Prices: map[string]modelPrice{ProviderTypeDoubao: {PromptPer1K: 1000}},
+		Budget: budgetConfig{Monthly: 1, Action: BudgetActionBlock},
+	}, now)
+	if err := ledger.append(usageRecord{Time: now, Cost: 5}); err != nil {
+		t.Fatalf("append() error = %v", err)
+	}
+
+	ask := func(context.Context, string, string, string) (string, error) { return "fix: local answer", nil }
+	local := providerConfig{Name: "local", Type: ProviderTypeOllama, Model: "qwen"}
+	if got, err := ledger.wrap(ask, local)(context.Background(), "", "prompt", "question"); err != nil || got != "fix: local answer" {
+		t.Fatalf("free provider over budget = %q, %v, want the call to go through", got, err)
+	}
+	var blocked *budgetExceededError
+	if _, err := ledger.wrap(ask, providerConfig{Type: ProviderTypeDoubao})(context.Background(), "", "prompt", "question"); !errors.As(err, &blocked) {
+		t.Fatalf("priced provider over budget error = %v, want budgetExceededError", err)
+	}
+}
+
+func TestUsageLedgerBudgetBlocksFurtherCalls(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	ledger := newTestUsageLedger(t, usageConfig{
+		Prices: map[string]modelPrice{ProviderTypeDoubao: {PromptPer1K: 1000}},
+		Budget: budgetConfig{Monthly: 1, Action: BudgetActionBlock},
+	}, now)
+	// 上个月的花费不计入本月预算
+	if err := ledger.append(usageRecord{Time: now.AddDate(0, -1, 0), Cost: 100}); err != nil {
+		t.Fatalf("append() error = %v", err)
+	}
+
+	calls := 0
+	doubaoAsk := func(context.Context, string, string, string) (string, error) {
+		calls++
+		return "fix(main): bump x", nil
+	}
+	opts := commentOptions{
+		Diff:      providerTestDiff,
+		AccessKey: "test-ak",
+		SecretKey: "test-sk",
+		Endpoint:  "test-endpoint",
+		Retry:     fastRetryPolicy,
+		Usage:     ledger,
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := askProviders(context.Background(), opts, doubaoAsk, modelRequest{Prompt: "prompt", Question: "question"}); err != nil {
+			t.Fatalf("askProviders() run %d error = %v", i, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("model called %d times, want the budget to block the second call", calls)
+	}
+
+	var sb strings.Builder
+	if err := ledger.writeUsageReport(&sb, startOfMonth(now)); err != nil {
+		t.Fatalf("writeUsageReport() error = %v", err)
+	}
+	for _, want := range []string{"2026-10-18", "/src/app", "Total: 1 call(s)", "spent this month (block)"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("usage report missing %q in:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"":           time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		"2026-09-15": time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC),
+		"30d":        time.Date(2026, 9, 18, 0, 0, 0, 0, time.UTC),
+		"72h":        now.Add(-72 * time.Hour),
+	}
+	for in, want := range tests {
+		got, err := parseSince(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseSince("last week", now); err == nil {
+		t.Error("parseSince(\"last week\") error = nil, want error")
+	}
+}